@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/spec"
+	"github.com/xhd2015/data-driven-testing/decision_tree/svg"
+	"github.com/xhd2015/data-driven-testing/t_tree/t_tree_static"
+)
+
+// handleDiff compares the tree in file at the given git ref
+// against the working tree version
+func handleDiff(args []string) error {
+	var out string
+	var varName string
+	var serve bool
+	var remainArgs []string
+	n := len(args)
+	for i := 0; i < n; i++ {
+		if args[i] == "--out" || args[i] == "--var" {
+			if i+1 >= n {
+				return fmt.Errorf("%v requires arg", args[i])
+			}
+			if args[i] == "--out" {
+				out = args[i+1]
+			} else {
+				varName = args[i+1]
+			}
+			i++
+			continue
+		}
+		if args[i] == "--help" {
+			fmt.Println(strings.TrimSpace(help))
+			return nil
+		}
+		if args[i] == "--serve" {
+			serve = true
+			continue
+		}
+		if strings.HasPrefix(args[i], "-") {
+			return fmt.Errorf("unrecognized flag: %v", args[i])
+		}
+		remainArgs = append(remainArgs, args[i])
+	}
+	if len(remainArgs) != 2 {
+		return fmt.Errorf("usage: go-ddt diff [--out FILE.svg|png|pdf] [--serve] [--var VAR] <git-ref> <file>")
+	}
+	ref, file := remainArgs[0], remainArgs[1]
+
+	oldTree, newTree, err := loadDiffTrees(ref, file, varName)
+	if err != nil {
+		return err
+	}
+
+	changes := decision_tree.Diff(oldTree, newTree)
+	if len(changes) == 0 {
+		fmt.Println("no changes")
+	} else {
+		fmt.Println(decision_tree.FormatChanges(changes, false))
+	}
+	if out == "" && !serve {
+		return nil
+	}
+
+	merged := decision_tree.DiffTree(oldTree, newTree)
+	config := decision_tree.DefaultConfig()
+	if out != "" {
+		err := writeImage(out, config, merged)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("diff written to %s\n", out)
+	}
+	if serve {
+		return svg.NewServer(svg.NewRenderer(config)).Serve(merged)
+	}
+	return nil
+}
+
+// loadDiffTrees loads the tree of file at the git ref and in the
+// working tree, .go files are loaded with the rest of their package,
+// varName selects the tree among them
+func loadDiffTrees(ref string, file string, varName string) (*decision_tree.Node, *decision_tree.Node, error) {
+	var oldTree *decision_tree.Node
+	var err error
+	if strings.HasSuffix(file, ".go") {
+		oldTree, err = loadGoTreeAt(ref, file, varName)
+	} else {
+		var oldData []byte
+		oldData, err = gitShow(ref, file)
+		if err != nil {
+			return nil, nil, err
+		}
+		oldTree, err = loadTreeData(file, oldData)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s at %s: %v", file, ref, err)
+	}
+	newTree, err := loadViewTree(file, varName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return oldTree, newTree, nil
+}
+
+// loadGoTreeAt reconstructs the tree of file at the git ref,
+// together with the other Go files of its directory at that ref
+func loadGoTreeAt(ref string, file string, varName string) (*decision_tree.Node, error) {
+	dir := filepath.Dir(file)
+	cmd := exec.Command("git", "ls-tree", "--name-only", ref, "./")
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree %s: %v %s", ref, err, strings.TrimSpace(stderr.String()))
+	}
+	sources := make(map[string][]byte)
+	for _, name := range strings.Split(string(output), "\n") {
+		if !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		sibling := filepath.Join(dir, filepath.Base(name))
+		data, err := gitShow(ref, sibling)
+		if err != nil {
+			return nil, err
+		}
+		sources[sibling] = data
+	}
+	file = filepath.Join(dir, filepath.Base(file))
+	if _, ok := sources[file]; !ok {
+		// not tracked at ref, let git report it
+		if _, err := gitShow(ref, file); err != nil {
+			return nil, err
+		}
+	}
+	return t_tree_static.LoadSources(file, sources, t_tree_static.LoadOptions{Var: varName})
+}
+
+// gitShow reads file content at the given git ref, the
+// file path is resolved relative to the file's directory
+func gitShow(ref string, file string) ([]byte, error) {
+	dir := filepath.Dir(file)
+	cmd := exec.Command("git", "show", ref+":./"+filepath.ToSlash(filepath.Base(file)))
+	cmd.Dir = dir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show %s:%s: %v %s", ref, file, err, strings.TrimSpace(stderr.String()))
+	}
+	return data, nil
+}
+
+// loadTreeData loads the tree from the content of a .json or spec
+// file, .go files need the rest of their package, see loadViewTree
+func loadTreeData(file string, data []byte) (*decision_tree.Node, error) {
+	if spec.IsSpecFile(file) {
+		return spec.Parse(file, data)
+	}
+	if !strings.HasSuffix(file, ".json") {
+		return nil, fmt.Errorf("unsupported file type, requires .json, .md or .mmd")
+	}
+	var tree *decision_tree.Node
+	err := json.Unmarshal(data, &tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tree: %v", err)
+	}
+	return tree, nil
+}
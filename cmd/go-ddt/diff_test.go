@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+const diffMainFile = `package demo
+
+import (
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/t_tree"
+)
+
+type N = t_tree.Node[struct{}, struct{}, struct{}]
+
+var root = &N{ID: "root", Children: []*N{{ID: "a"}}}
+
+func TestTree(t *testing.T) {
+	t_tree.MustBuild(root, nodes).Run(t)
+}
+`
+
+const diffNodesFile = `package demo
+
+var nodes = []*N{
+	{ID: "b", ParentID: "a", Description: "B"},
+	{ID: "c", ParentID: "root"},
+}
+`
+
+func TestLoadDiffTreesSplitFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	write := func(name string, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main_test.go", diffMainFile)
+	write("nodes_test.go", diffNodesFile)
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+
+	// only the sibling file changes
+	write("nodes_test.go", strings.Replace(diffNodesFile, `Description: "B"`, `Description: "B renamed"`, 1))
+
+	oldTree, newTree, err := loadDiffTrees("HEAD", filepath.Join(dir, "main_test.go"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oldTree.Find("b") == nil || oldTree.Find("c") == nil {
+		t.Fatalf("expect nodes of sibling files at ref, actual: %+v", oldTree)
+	}
+	changes := decision_tree.FormatChanges(decision_tree.Diff(oldTree, newTree), false)
+	if changes != `~ b label: "B" -> "B renamed"` {
+		t.Errorf("unexpected changes: %s", changes)
+	}
+}
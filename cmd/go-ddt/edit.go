@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/svg"
+)
+
+// handleEdit serves the decision tree in file for editing in
+// browser, the file is created with a single root if missing
+func handleEdit(args []string) error {
+	var remainArgs []string
+	for _, arg := range args {
+		if arg == "--help" {
+			fmt.Println(strings.TrimSpace(help))
+			return nil
+		}
+		if strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("unrecognized flag: %v", arg)
+		}
+		remainArgs = append(remainArgs, arg)
+	}
+	if len(remainArgs) != 1 || !strings.HasSuffix(remainArgs[0], ".json") {
+		return fmt.Errorf("usage: go-ddt edit <file.json>")
+	}
+	file := remainArgs[0]
+
+	_, err := os.Stat(file)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		data, err := json.MarshalIndent(&decision_tree.Node{ID: "root", Label: "Root"}, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("created %s\n", file)
+	}
+
+	server := svg.NewServer(svg.NewRenderer(decision_tree.DefaultConfig()))
+	server.SetEditable(true)
+	return server.ServeFile(file)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree/export"
+)
+
+const exportTestName = "TestDDTExport"
+
+// handleExport exports a t_tree variable by compiling a driver
+// test into the variable's package through `go test -overlay`,
+// the driver evaluates the tree and writes it in the requested format
+func handleExport(args []string) error {
+	var varName string
+	var format string
+	var out string
+	var verbose bool
+	var remainArgs []string
+	n := len(args)
+	for i := 0; i < n; i++ {
+		if args[i] == "--var" || args[i] == "--format" || args[i] == "--out" {
+			if i+1 >= n {
+				return fmt.Errorf("%v requires arg", args[i])
+			}
+			switch args[i] {
+			case "--var":
+				varName = args[i+1]
+			case "--format":
+				format = args[i+1]
+			default:
+				out = args[i+1]
+			}
+			i++
+			continue
+		}
+		if args[i] == "--help" {
+			fmt.Println(strings.TrimSpace(help))
+			return nil
+		}
+		if args[i] == "--verbose" || args[i] == "-v" {
+			verbose = true
+			continue
+		}
+		if strings.HasPrefix(args[i], "-") {
+			return fmt.Errorf("unrecognized flag: %v", args[i])
+		}
+		remainArgs = append(remainArgs, args[i])
+	}
+	if varName == "" || len(remainArgs) > 1 {
+		return fmt.Errorf("usage: go-ddt export --var VAR [--format json|mermaid|svg|dot|mindmap|wbs|drawio] [--out FILE] [dir]")
+	}
+	if format == "" {
+		format = string(export.FormatJSON)
+	}
+	if !isExportFormat(format) {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+	dir := "./"
+	if len(remainArgs) == 1 {
+		dir = remainArgs[0]
+	}
+
+	pkgName, err := findVarPackage(dir, varName)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "go-ddt-export")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	outFile := filepath.Join(tmpDir, "out")
+
+	// the driver is added to the package by an overlay,
+	// so nothing is written into the package directory
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	driverFile := filepath.Join(absDir, "ddt_export_driver_test.go")
+	if _, err := os.Stat(driverFile); err == nil {
+		return fmt.Errorf("%s already exists, remove it and retry", driverFile)
+	}
+	tmpDriverFile := filepath.Join(tmpDir, "driver_test.go")
+	err = os.WriteFile(tmpDriverFile, []byte(exportDriver(pkgName, varName, format, outFile)), 0644)
+	if err != nil {
+		return err
+	}
+	overlay, err := json.Marshal(map[string]map[string]string{
+		"Replace": {driverFile: tmpDriverFile},
+	})
+	if err != nil {
+		return err
+	}
+	overlayFile := filepath.Join(tmpDir, "overlay.json")
+	if err := os.WriteFile(overlayFile, overlay, 0644); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("go", "test", "-count=1", "-overlay", overlayFile, "-run", "^"+exportTestName+"$", ".")
+	cmd.Dir = dir
+	if verbose {
+		fmt.Fprintf(os.Stderr, "go test -count=1 -overlay %s -run ^%s$ .\n", overlayFile, exportTestName)
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("export %s: %v\n%s", varName, err, output)
+	}
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		return fmt.Errorf("export %s: no output, is the test package built with build tags? %v", varName, err)
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(out, data, 0644)
+}
+
+func isExportFormat(format string) bool {
+	for _, f := range export.Formats {
+		if string(f) == format {
+			return true
+		}
+	}
+	return false
+}
+
+// findVarPackage finds the package name of the file
+// declaring the top level variable in dir
+func findVarPackage(dir string, varName string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return "", err
+		}
+		for _, decl := range f.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					if name.Name == varName {
+						return f.Name.Name, nil
+					}
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("var %s not found in %s", varName, dir)
+}
+
+// exportDriver generates the driver test, the variable
+// must have a ToDecisionTree method, e.g. *t_tree.Tree
+func exportDriver(pkgName string, varName string, format string, outFile string) string {
+	return fmt.Sprintf(`// Code generated by go-ddt export. DO NOT EDIT.
+
+package %s
+
+import (
+	"os"
+	"testing"
+
+	ddt_export "github.com/xhd2015/data-driven-testing/decision_tree/export"
+)
+
+func %s(t *testing.T) {
+	data, err := ddt_export.Export(%s.ToDecisionTree(), %q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(%q, data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+`, pkgName, exportTestName, varName, format, outFile)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+func TestFindVarPackage(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "tree_test.go"), []byte("package demo_test\n\nvar (\n\tOther = 1\n\tMyTree = 2\n)\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkgName, err := findVarPackage(dir, "MyTree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkgName != "demo_test" {
+		t.Errorf("expect demo_test, actual: %s", pkgName)
+	}
+	if _, err := findVarPackage(dir, "Missing"); err == nil {
+		t.Errorf("expect error for missing var")
+	}
+}
+
+func TestExportDriver(t *testing.T) {
+	code := exportDriver("demo", "MyTree", "json", "/tmp/out")
+	if _, err := parser.ParseFile(token.NewFileSet(), "driver_test.go", code, 0); err != nil {
+		t.Errorf("expect valid driver: %v\n%s", err, code)
+	}
+}
+
+func TestHandleExport(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+	dir := filepath.Join("testdata", "export")
+	before, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "tree.json")
+	if err := handleExport([]string{"--var", "MyTree", "--out", out, dir}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tree *decision_tree.Node
+	if err := json.Unmarshal(data, &tree); err != nil {
+		t.Fatalf("invalid export: %v\n%s", err, data)
+	}
+	if tree.ID != "root" || len(tree.Children) != 2 || tree.Find("a").EdgeLabel != "x=1" {
+		t.Errorf("unexpected export: %s", data)
+	}
+
+	// the driver is never written into the package
+	after, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before) {
+		t.Errorf("expect package directory unchanged, before: %d files, after: %d", len(before), len(after))
+	}
+}
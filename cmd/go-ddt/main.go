@@ -13,16 +13,63 @@ Usage: go-ddt <cmd> [OPTIONS] <ARGS>
 
 Commands:
   gen 
+  view <file>          serve the decision tree in browser, file is .json, .go,
+                       or a markdown (.md) or Mermaid (.mmd) spec, print it
+                       to terminal with --text, or render it to --out
+  replay <artifact>    re-run the Assert of a recorded path offline, the error
+                       is replayed by its message only, errors.Is/As won't match
+  edit <file.json>     edit the decision tree in browser, saved to file
+  scaffold <file>      generate t_tree nodes from a decision tree or spec, merging
+                       new nodes into the --out file if it exists
+  diff <ref> <file>    compare the tree in file against the git ref
+  export [dir]         export the t_tree variable given by --var
 
 Options:
     --dir DIR    directory
     --dry-run    dry run
+    --run REGEXP test to run when replaying, default to the recorded test
+    --out FILE   output file of diff, export or scaffold, the image of
+                 view and diff: .png, .pdf or .svg
+    --serve      serve the colored diff in browser
+    --var VAR    t_tree variable to export, or to scaffold into, the
+                 variable holding the tree to view or diff in a .go file,
+                 default to the first t_tree.Build call
+    --package P  package of scaffolded file, default to the directory's
+    --editor URL editor link of node sources when viewing, {file} and {line}
+                 are replaced, default vscode://file/{file}:{line}
+    --orientation O
+                 direction the viewed tree grows in: td, lr or radial, default td
+    --layout L   layout of the viewed tree: leaf-order or tidy, default leaf-order
+    --root ID    view only the branch of the node with ID
+    --depth N    view N levels below the root, deeper nodes are collapsed
+                 into "+N more" nodes
+    --tag TAG    view only nodes tagged TAG and their ancestors
+    --max-nodes N
+                 collapse subtrees of the viewed tree into "+N more" nodes
+                 when it has more than N nodes, default shows every node
+    --text       print the viewed tree to terminal instead of serving it
+    --boxed      print the viewed tree as boxes laid out top-down
+    --ascii      draw the printed tree with ASCII instead of box-drawing characters
+    --width N    wrap labels of the printed tree to N columns, default $COLUMNS
+    --no-color   print the tree without colors, default when not a terminal
+    --format FMT export format: json, mermaid, svg, dot, mindmap or wbs of
+                 PlantUML, or drawio, default json
  -v,--verbose    show verbose info
     --help       show help message
 
 Examples:
   $ go-ddt gen
   $ go-ddt gen ./...
+  $ DDT_RECORD_DIR=/tmp/ddt go test ./...
+  $ go-ddt replay /tmp/ddt/Root.BasicSuccess.json
+  $ go-ddt view --text tree.json
+  $ go-ddt view --orientation lr --layout tidy tree.json
+  $ go-ddt view --out tree.png tree.json
+  $ go-ddt view spec.md
+  $ go-ddt view --root login --depth 3 --tag happy_flow tree.json
+  $ go-ddt diff HEAD~1 tree.json --out diff.svg
+  $ go-ddt scaffold --out tree_test.go tree.json
+  $ go-ddt export --var MyTree --format mermaid --out tree.mmd ./
 `
 
 const VERSION = "0.0.1"
@@ -50,6 +97,16 @@ func handle(args []string) error {
 		return handleGen(args[1:])
 	case "view":
 		return handleView(args[1:])
+	case "replay":
+		return handleReplay(args[1:])
+	case "edit":
+		return handleEdit(args[1:])
+	case "scaffold":
+		return handleScaffold(args[1:])
+	case "diff":
+		return handleDiff(args[1:])
+	case "export":
+		return handleExport(args[1:])
 	default:
 		return fmt.Errorf("unrecognized command: %s", cmd)
 	}
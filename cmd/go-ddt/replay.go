@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/t_tree"
+)
+
+// handleReplay re-runs the Assert of a recorded path against
+// the captured response, by running `go test` with t_tree.EnvReplay set
+func handleReplay(args []string) error {
+	var dir string
+	var runPattern string
+	var verbose bool
+	var remainArgs []string
+	n := len(args)
+	for i := 0; i < n; i++ {
+		if args[i] == "--dir" || args[i] == "--run" {
+			if i+1 >= n {
+				return fmt.Errorf("%v requires arg", args[i])
+			}
+			if args[i] == "--dir" {
+				dir = args[i+1]
+			} else {
+				runPattern = args[i+1]
+			}
+			i++
+			continue
+		}
+		if args[i] == "--help" {
+			fmt.Println(strings.TrimSpace(help))
+			return nil
+		}
+		if args[i] == "--verbose" || args[i] == "-v" {
+			verbose = true
+			continue
+		}
+		if strings.HasPrefix(args[i], "-") {
+			return fmt.Errorf("unrecognized flag: %v", args[i])
+		}
+		remainArgs = append(remainArgs, args[i])
+	}
+	if len(remainArgs) != 1 {
+		return fmt.Errorf("usage: go-ddt replay [--dir DIR] [--run REGEXP] <artifact>")
+	}
+	artifact, err := filepath.Abs(remainArgs[0])
+	if err != nil {
+		return err
+	}
+	record, err := t_tree.ReadRecord(artifact)
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		dir = record.Dir
+	}
+	if dir == "" {
+		dir = "./"
+	}
+	if runPattern == "" && record.TestName != "" {
+		// only the top level test is needed, the tree itself
+		// filters out paths other than the recorded one
+		topName := strings.SplitN(record.TestName, "/", 2)[0]
+		runPattern = "^" + regexp.QuoteMeta(topName) + "$"
+	}
+
+	testArgs := []string{"test", "-count=1", "-v"}
+	if runPattern != "" {
+		testArgs = append(testArgs, "-run", runPattern)
+	}
+	testArgs = append(testArgs, ".")
+	if verbose {
+		fmt.Printf("replay %s in %s: go %s\n", strings.Join(record.Path, "/"), dir, strings.Join(testArgs, " "))
+	}
+
+	cmd := exec.Command("go", testArgs...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), t_tree.EnvReplay+"="+artifact)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
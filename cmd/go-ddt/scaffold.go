@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/t_tree/t_tree_static"
+)
+
+// handleScaffold generates t_tree nodes from a decision tree file,
+// an existing output file gets only the missing nodes
+func handleScaffold(args []string) error {
+	var out string
+	var opts t_tree_static.ScaffoldOptions
+	var remainArgs []string
+	n := len(args)
+	for i := 0; i < n; i++ {
+		if args[i] == "--out" || args[i] == "--var" || args[i] == "--package" {
+			if i+1 >= n {
+				return fmt.Errorf("%v requires arg", args[i])
+			}
+			switch args[i] {
+			case "--out":
+				out = args[i+1]
+			case "--var":
+				opts.Var = args[i+1]
+			default:
+				opts.Package = args[i+1]
+			}
+			i++
+			continue
+		}
+		if args[i] == "--help" {
+			fmt.Println(strings.TrimSpace(help))
+			return nil
+		}
+		if strings.HasPrefix(args[i], "-") {
+			return fmt.Errorf("unrecognized flag: %v", args[i])
+		}
+		remainArgs = append(remainArgs, args[i])
+	}
+	if len(remainArgs) != 1 {
+		return fmt.Errorf("usage: go-ddt scaffold [--out FILE] [--var VAR] [--package PKG] <tree-file>")
+	}
+	file := remainArgs[0]
+	tree, err := loadViewTree(file, "")
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+
+	dir := "./"
+	if out != "" {
+		dir = filepath.Dir(out)
+	}
+	pkgName, declaredTypes, consts, err := inspectPackage(dir)
+	if err != nil {
+		return err
+	}
+
+	if out != "" {
+		existing, err := os.ReadFile(out)
+		if err == nil {
+			opts.Consts = consts
+			code, added, err := t_tree_static.ScaffoldMerge(existing, tree, opts)
+			if err != nil {
+				return fmt.Errorf("%s: %v", out, err)
+			}
+			if len(added) == 0 {
+				fmt.Printf("%s is up to date\n", out)
+				return nil
+			}
+			if err := os.WriteFile(out, code, 0644); err != nil {
+				return err
+			}
+			fmt.Printf("added %d nodes to %s: %s\n", len(added), out, strings.Join(added, ", "))
+			return nil
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if opts.Package == "" {
+		opts.Package = pkgName
+	}
+	opts.DeclareTypes = true
+	opts.DeclaredTypes = declaredTypes
+	code, err := t_tree_static.Scaffold(tree, opts)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	if err := os.WriteFile(out, code, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("scaffolded %s\n", out)
+	return nil
+}
+
+// inspectPackage returns the package name of Go files in dir, defaulting
+// to the directory name, the type names and the string constants
+// declared by them
+func inspectPackage(dir string) (string, map[string]bool, map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	var pkgName string
+	types := make(map[string]bool)
+	consts := make(map[string]string)
+	fset := token.NewFileSet()
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if pkgName == "" {
+			pkgName = strings.TrimSuffix(f.Name.Name, "_test")
+		}
+		for _, decl := range f.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			switch genDecl.Tok {
+			case token.TYPE:
+				for _, spec := range genDecl.Specs {
+					types[spec.(*ast.TypeSpec).Name.Name] = true
+				}
+			case token.CONST:
+				for _, spec := range genDecl.Specs {
+					valSpec := spec.(*ast.ValueSpec)
+					for i, name := range valSpec.Names {
+						if i >= len(valSpec.Values) {
+							break
+						}
+						lit, ok := valSpec.Values[i].(*ast.BasicLit)
+						if !ok || lit.Kind != token.STRING {
+							continue
+						}
+						if value, err := strconv.Unquote(lit.Value); err == nil {
+							consts[name.Name] = value
+						}
+					}
+				}
+			}
+		}
+	}
+	if pkgName == "" {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		pkgName = packageNameOf(filepath.Base(absDir))
+	}
+	return pkgName, types, consts, nil
+}
+
+// packageNameOf turns a directory name into a valid package name
+func packageNameOf(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(name))
+	if name == "" || ('0' <= name[0] && name[0] <= '9') {
+		name = "p" + name
+	}
+	return name
+}
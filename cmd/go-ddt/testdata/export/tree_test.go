@@ -0,0 +1,19 @@
+package export_test
+
+import (
+	"github.com/xhd2015/data-driven-testing/t_tree"
+)
+
+type Req struct{}
+type Resp struct{}
+type TC struct{}
+
+type N = t_tree.Node[Req, Resp, TC]
+
+var MyTree = t_tree.MustBuild(&N{
+	ID:          "root",
+	Description: "Root",
+}, []*N{
+	{ID: "a", Description: "A", Condition: "x=1"},
+	{ID: "b", Description: "B"},
+})
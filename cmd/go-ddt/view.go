@@ -4,18 +4,212 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/pdf"
+	"github.com/xhd2015/data-driven-testing/decision_tree/png"
+	"github.com/xhd2015/data-driven-testing/decision_tree/spec"
 	"github.com/xhd2015/data-driven-testing/decision_tree/svg"
+	"github.com/xhd2015/data-driven-testing/decision_tree/text"
+	"github.com/xhd2015/data-driven-testing/t_tree/t_tree_static"
 )
 
 func handleView(args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("usage: go-ddt view <file>")
+	editorURL := svg.DefaultEditorURL
+	config := decision_tree.DefaultConfig()
+	var textMode bool
+	var out string
+	var varName string
+	var selection decision_tree.Selection
+	textOptions := text.DefaultOptions()
+	textOptions.UseColors = isTerminal(os.Stdout)
+	textOptions.Width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	var remainArgs []string
+	n := len(args)
+	for i := 0; i < n; i++ {
+		if args[i] == "--var" {
+			if i+1 >= n {
+				return fmt.Errorf("%v requires arg", args[i])
+			}
+			varName = args[i+1]
+			i++
+			continue
+		}
+		if args[i] == "--editor" {
+			if i+1 >= n {
+				return fmt.Errorf("%v requires arg", args[i])
+			}
+			editorURL = args[i+1]
+			i++
+			continue
+		}
+		if args[i] == "--orientation" {
+			if i+1 >= n {
+				return fmt.Errorf("%v requires arg", args[i])
+			}
+			orientation, err := decision_tree.ParseOrientation(args[i+1])
+			if err != nil {
+				return err
+			}
+			config.Orientation = orientation
+			i++
+			continue
+		}
+		if args[i] == "--layout" {
+			if i+1 >= n {
+				return fmt.Errorf("%v requires arg", args[i])
+			}
+			algorithm, err := decision_tree.ParseLayoutAlgorithm(args[i+1])
+			if err != nil {
+				return err
+			}
+			config.Algorithm = algorithm
+			i++
+			continue
+		}
+		if args[i] == "--out" {
+			if i+1 >= n {
+				return fmt.Errorf("%v requires arg", args[i])
+			}
+			out = args[i+1]
+			i++
+			continue
+		}
+		if args[i] == "--root" || args[i] == "--tag" || args[i] == "--depth" {
+			if i+1 >= n {
+				return fmt.Errorf("%v requires arg", args[i])
+			}
+			switch args[i] {
+			case "--root":
+				selection.Root = args[i+1]
+			case "--tag":
+				selection.Tag = args[i+1]
+			default:
+				depth, err := strconv.Atoi(args[i+1])
+				if err != nil || depth < 0 {
+					return fmt.Errorf("invalid %v: %v", args[i], args[i+1])
+				}
+				selection.Depth = depth
+			}
+			i++
+			continue
+		}
+		if args[i] == "--max-nodes" {
+			if i+1 >= n {
+				return fmt.Errorf("%v requires arg", args[i])
+			}
+			maxNodes, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return fmt.Errorf("invalid %v: %v", args[i], err)
+			}
+			config.MaxNodes = maxNodes
+			i++
+			continue
+		}
+		if args[i] == "--text" {
+			textMode = true
+			continue
+		}
+		if args[i] == "--boxed" {
+			textMode = true
+			textOptions.Boxed = true
+			continue
+		}
+		if args[i] == "--ascii" {
+			textOptions.UseUnicode = false
+			continue
+		}
+		if args[i] == "--no-color" {
+			textOptions.UseColors = false
+			continue
+		}
+		if args[i] == "--width" {
+			if i+1 >= n {
+				return fmt.Errorf("%v requires arg", args[i])
+			}
+			width, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return fmt.Errorf("invalid %v: %v", args[i], err)
+			}
+			textOptions.Width = width
+			i++
+			continue
+		}
+		if args[i] == "--help" {
+			fmt.Println(strings.TrimSpace(help))
+			return nil
+		}
+		if strings.HasPrefix(args[i], "-") {
+			return fmt.Errorf("unrecognized flag: %v", args[i])
+		}
+		remainArgs = append(remainArgs, args[i])
+	}
+	if len(remainArgs) != 1 {
+		return fmt.Errorf("usage: go-ddt view [--var VAR] [--editor URL] [--root ID] [--depth N] [--tag TAG] [--text] [--out FILE.png|pdf|svg] <file>")
+	}
+
+	file := remainArgs[0]
+	if out != "" {
+		tree, err := loadViewTree(file, varName)
+		if err != nil {
+			return err
+		}
+		tree, err = selection.Apply(tree)
+		if err != nil {
+			return err
+		}
+		if err := writeImage(out, config, tree); err != nil {
+			return err
+		}
+		fmt.Printf("tree written to %s\n", out)
+		return nil
+	}
+	if textMode {
+		tree, err := loadViewTree(file, varName)
+		if err != nil {
+			return err
+		}
+		tree, err = selection.Apply(tree)
+		if err != nil {
+			return err
+		}
+		fmt.Print(text.Render(tree.Collapse(config.MaxNodes), textOptions))
+		return nil
+	}
+
+	server := svg.NewServer(svg.NewRenderer(config))
+	server.SetEditorURL(editorURL)
+	server.SetSelection(selection)
+	if strings.HasSuffix(file, ".go") {
+		// reconstruct the tree from source, without executing it
+		tree, err := t_tree_static.LoadFileWith(file, t_tree_static.LoadOptions{Var: varName})
+		if err != nil {
+			return fmt.Errorf("failed to load tree: %v", err)
+		}
+		if _, err := selection.Apply(tree); err != nil {
+			return err
+		}
+		return server.Serve(tree)
+	}
+
+	if spec.IsSpecFile(file) {
+		// parse once to report errors before serving
+		tree, err := loadViewTree(file, varName)
+		if err != nil {
+			return err
+		}
+		if _, err := selection.Apply(tree); err != nil {
+			return err
+		}
+		server.SetFileParser(func(data []byte) (*decision_tree.Node, error) {
+			return spec.Parse(file, data)
+		})
+		return server.ServeFile(file)
 	}
 
-	file := args[0]
 	var tree *decision_tree.Node
 	var err error
 	if strings.HasSuffix(file, ".json") {
@@ -29,7 +223,58 @@ func handleView(args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load tree: %v", err)
 	}
-
-	server := svg.NewServer(svg.NewRenderer(decision_tree.DefaultConfig()))
+	if tree != nil {
+		if _, err := selection.Apply(tree); err != nil {
+			return err
+		}
+	}
 	return server.ServeFile(file)
 }
+
+// loadViewTree loads the tree of file, .go files are loaded together
+// with the rest of their package, varName selects the tree among them
+func loadViewTree(file string, varName string) (*decision_tree.Node, error) {
+	if strings.HasSuffix(file, ".go") {
+		tree, err := t_tree_static.LoadFileWith(file, t_tree_static.LoadOptions{Var: varName})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tree: %v", err)
+		}
+		return tree, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	return loadTreeData(file, data)
+}
+
+// writeImage renders the tree to file, in the format given
+// by its extension: .png, .pdf or .svg
+func writeImage(file string, config *decision_tree.Config, tree *decision_tree.Node) error {
+	var data []byte
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".png":
+		data, err = png.NewRenderer(config).RenderTree(tree)
+	case ".pdf":
+		data, err = pdf.NewRenderer(config).RenderTree(tree)
+	case ".svg":
+		data = []byte(svg.NewRenderer(config).RenderTree(tree))
+	default:
+		return fmt.Errorf("unsupported output format %q, requires .png, .pdf or .svg", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %v", file, err)
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// isTerminal tells whether f is a terminal, colors are only
+// written to terminals unless asked otherwise
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,91 @@
+package decision_tree
+
+import "fmt"
+
+// CollapsedClass is the style class of the "+N more" nodes
+// standing in for collapsed subtrees
+const CollapsedClass = "collapsed"
+
+var collapsedStyle = NodeStyle{Shape: ShapeStadium, Fill: "#f6f8fa", Stroke: "#999999", StrokeWidth: 1, Class: CollapsedClass}
+
+// Count returns the number of nodes in the tree
+func (n *Node) Count() int {
+	if n == nil {
+		return 0
+	}
+	count := 1
+	for _, child := range n.Children {
+		count += child.Count()
+	}
+	return count
+}
+
+// Collapse returns the tree reduced to about maxNodes nodes for large
+// trees to stay readable and fast to render. Levels are kept from the
+// root down, breadth first, while the children of a node fit in the
+// remaining budget, the children of the others are replaced by a
+// single "+N more" node counting the hidden nodes. The tree itself is
+// returned if it has no more than maxNodes nodes or maxNodes is not
+// positive, otherwise nodes are copied and the tree is left as it is.
+func (n *Node) Collapse(maxNodes int) *Node {
+	if n == nil || maxNodes <= 0 {
+		return n
+	}
+	counts := make(map[*Node]int)
+	var count func(node *Node) int
+	count = func(node *Node) int {
+		c := 1
+		for _, child := range node.Children {
+			c += count(child)
+		}
+		counts[node] = c
+		return c
+	}
+	if count(n) <= maxNodes {
+		return n
+	}
+
+	type pair struct{ src, dst *Node }
+	root := n.shallowCopy()
+	kept := 1
+	queue := []pair{{n, root}}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if len(p.src.Children) == 0 {
+			continue
+		}
+		if kept+len(p.src.Children) > maxNodes {
+			hidden := counts[p.src] - 1
+			p.dst.Children = []*Node{CollapsedNode(p.src.ID, hidden)}
+			kept++
+			continue
+		}
+		p.dst.Children = make([]*Node, len(p.src.Children))
+		for i, child := range p.src.Children {
+			p.dst.Children[i] = child.shallowCopy()
+			queue = append(queue, pair{child, p.dst.Children[i]})
+		}
+		kept += len(p.src.Children)
+	}
+	return root
+}
+
+// CollapsedNode returns the node standing in for the hidden
+// descendants of the node with parentID
+func CollapsedNode(parentID string, hidden int) *Node {
+	style := collapsedStyle
+	return &Node{
+		ID:    parentID + "/more",
+		Label: fmt.Sprintf("+%d more", hidden),
+		Style: &style,
+	}
+}
+
+// shallowCopy copies the node without its children,
+// the copy shares conditions, style and source
+func (n *Node) shallowCopy() *Node {
+	c := *n
+	c.Children = nil
+	return &c
+}
@@ -0,0 +1,58 @@
+package decision_tree
+
+import (
+	"fmt"
+	"testing"
+)
+
+// wideTree has a root with 3 children of 3 children each
+func wideTree() *Node {
+	root := &Node{ID: "root"}
+	for i := 0; i < 3; i++ {
+		child := &Node{ID: fmt.Sprintf("c%d", i)}
+		for j := 0; j < 3; j++ {
+			child.Children = append(child.Children, &Node{ID: fmt.Sprintf("c%d_%d", i, j)})
+		}
+		root.Children = append(root.Children, child)
+	}
+	return root
+}
+
+func TestCollapse(t *testing.T) {
+	tree := wideTree()
+	if tree.Count() != 13 {
+		t.Fatalf("expect 13 nodes, got %d", tree.Count())
+	}
+	if tree.Collapse(0) != tree || tree.Collapse(13) != tree {
+		t.Errorf("expect trees within the limit to be returned as they are")
+	}
+
+	collapsed := tree.Collapse(8)
+	// root, its 3 children, the children of c0, then c1 and c2 collapsed
+	var ids []string
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		ids = append(ids, node.ID)
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(collapsed)
+	expect := "[root c0 c0_0 c0_1 c0_2 c1 c1/more c2 c2/more]"
+	if actual := fmt.Sprint(ids); actual != expect {
+		t.Errorf("expect %s, got %s", expect, actual)
+	}
+	more := collapsed.Children[1].Children[0]
+	if more.Label != "+3 more" || more.Style == nil || more.Style.Class != CollapsedClass {
+		t.Errorf("unexpected collapsed node: %+v", more)
+	}
+	if tree.Count() != 13 || len(tree.Children[1].Children) != 3 {
+		t.Errorf("expect the tree to be left as it is")
+	}
+
+	// the root's children alone exceed the limit
+	collapsed = tree.Collapse(2)
+	if len(collapsed.Children) != 1 || collapsed.Children[0].Label != "+12 more" {
+		t.Errorf("expect every child collapsed, got %+v", collapsed.Children)
+	}
+}
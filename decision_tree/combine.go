@@ -0,0 +1,187 @@
+package decision_tree
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dimension is a named factor with its possible values
+type Dimension struct {
+	Name   string
+	Values []any
+}
+
+// Coverage selects how combinations of dimensions are generated
+type Coverage int
+
+const (
+	// Cartesian generates every combination of all dimension values
+	Cartesian Coverage = iota
+	// Pairwise generates combinations such that every pair of values
+	// from any two dimensions appears at least once
+	Pairwise
+)
+
+// Combinations returns the combinations of dimensions under the coverage,
+// each combination holds one value index per dimension, in dimension order.
+// The result is deterministic.
+func Combinations(dims []Dimension, coverage Coverage) [][]int {
+	if len(dims) == 0 {
+		return nil
+	}
+	for _, dim := range dims {
+		if len(dim.Values) == 0 {
+			return nil
+		}
+	}
+	if coverage == Pairwise && len(dims) > 2 {
+		return pairwise(dims)
+	}
+	return cartesian(dims)
+}
+
+func cartesian(dims []Dimension) [][]int {
+	combinations := [][]int{nil}
+	for _, dim := range dims {
+		next := make([][]int, 0, len(combinations)*len(dim.Values))
+		for _, prefix := range combinations {
+			for i := range dim.Values {
+				combination := make([]int, len(prefix)+1)
+				copy(combination, prefix)
+				combination[len(prefix)] = i
+				next = append(next, combination)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// pairwise greedily builds rows, each row starts from the first
+// uncovered pair and fills other dimensions with the value that
+// covers the most uncovered pairs.
+func pairwise(dims []Dimension) [][]int {
+	n := len(dims)
+	type pair struct {
+		i, vi, j, vj int
+	}
+	uncovered := make(map[pair]bool)
+	var order []pair
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			for vi := range dims[i].Values {
+				for vj := range dims[j].Values {
+					p := pair{i, vi, j, vj}
+					uncovered[p] = true
+					order = append(order, p)
+				}
+			}
+		}
+	}
+
+	var rows [][]int
+	next := 0
+	for len(uncovered) > 0 {
+		for !uncovered[order[next]] {
+			next++
+		}
+		seed := order[next]
+		row := make([]int, n)
+		fixed := make([]bool, n)
+		row[seed.i], fixed[seed.i] = seed.vi, true
+		row[seed.j], fixed[seed.j] = seed.vj, true
+
+		for k := 0; k < n; k++ {
+			if fixed[k] {
+				continue
+			}
+			best, bestCount := 0, -1
+			for v := range dims[k].Values {
+				count := 0
+				for m := 0; m < n; m++ {
+					if !fixed[m] {
+						continue
+					}
+					var p pair
+					if m < k {
+						p = pair{m, row[m], k, v}
+					} else {
+						p = pair{k, v, m, row[m]}
+					}
+					if uncovered[p] {
+						count++
+					}
+				}
+				if count > bestCount {
+					best, bestCount = v, count
+				}
+			}
+			row[k], fixed[k] = best, true
+		}
+
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				delete(uncovered, pair{i, row[i], j, row[j]})
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// FormatDimensionValue formats a `name=value` pair of a dimension
+func FormatDimensionValue(dim Dimension, valueIndex int) string {
+	return fmt.Sprintf("%s=%v", dim.Name, dim.Values[valueIndex])
+}
+
+// ExpandDimensions builds a tree whose levels split on dimensions in order.
+// Each node is labelled with its own `name=value` and carries it in Conditions,
+// leaves carry all chosen values. Only prefixes of generated combinations
+// appear in the tree, so pairwise coverage yields a sparse tree.
+func ExpandDimensions(id string, label string, dims []Dimension, coverage Coverage) *Node {
+	root := &Node{
+		ID:    id,
+		Label: label,
+	}
+	for _, combination := range Combinations(dims, coverage) {
+		parent := root
+		for level, valueIndex := range combination {
+			pairLabel := FormatDimensionValue(dims[level], valueIndex)
+			childID := CombinationID(id, dims, combination[:level+1])
+			var child *Node
+			for _, c := range parent.Children {
+				if c.ID == childID {
+					child = c
+					break
+				}
+			}
+			if child == nil {
+				child = &Node{
+					ID:         childID,
+					Label:      pairLabel,
+					Conditions: map[string]any{dims[level].Name: dims[level].Values[valueIndex]},
+				}
+				parent.Children = append(parent.Children, child)
+			}
+			parent = child
+		}
+		// leaf holds all values
+		for level, valueIndex := range combination {
+			parent.Conditions[dims[level].Name] = dims[level].Values[valueIndex]
+		}
+	}
+	return root
+}
+
+// CombinationID returns the ID of the node for a combination prefix,
+// i.e. the prefix followed by `name=value` pairs joined by ",".
+func CombinationID(prefix string, dims []Dimension, combination []int) string {
+	parts := make([]string, 0, len(combination)+1)
+	if prefix != "" {
+		parts = append(parts, prefix)
+	}
+	for level, valueIndex := range combination {
+		parts = append(parts, FormatDimensionValue(dims[level], valueIndex))
+	}
+	return strings.Join(parts, ",")
+}
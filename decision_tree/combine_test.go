@@ -0,0 +1,88 @@
+package decision_tree
+
+import (
+	"testing"
+)
+
+func TestCombinations(t *testing.T) {
+	dims := []Dimension{
+		{Name: "user_state", Values: []any{1, 2, 3}},
+		{Name: "feature_type", Values: []any{1, 2}},
+		{Name: "feature_state", Values: []any{1, 2, 3, 4}},
+		{Name: "config_link", Values: []any{"", "link"}},
+	}
+
+	t.Run("Cartesian", func(t *testing.T) {
+		combinations := Combinations(dims, Cartesian)
+		if len(combinations) != 3*2*4*2 {
+			t.Errorf("expect %d combinations, actual: %d", 3*2*4*2, len(combinations))
+		}
+		seen := make(map[string]bool)
+		for _, c := range combinations {
+			id := CombinationID("", dims, c)
+			if seen[id] {
+				t.Errorf("duplicate combination: %s", id)
+			}
+			seen[id] = true
+		}
+	})
+
+	t.Run("Pairwise", func(t *testing.T) {
+		combinations := Combinations(dims, Pairwise)
+		if len(combinations) >= 3*2*4*2 {
+			t.Errorf("expect pairwise smaller than cartesian, actual: %d", len(combinations))
+		}
+		for i := range dims {
+			for j := i + 1; j < len(dims); j++ {
+				for vi := range dims[i].Values {
+					for vj := range dims[j].Values {
+						var found bool
+						for _, c := range combinations {
+							if c[i] == vi && c[j] == vj {
+								found = true
+								break
+							}
+						}
+						if !found {
+							t.Errorf("pair not covered: %s, %s", FormatDimensionValue(dims[i], vi), FormatDimensionValue(dims[j], vj))
+						}
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		if Combinations(nil, Pairwise) != nil {
+			t.Errorf("expect no combinations for no dimensions")
+		}
+		if Combinations([]Dimension{{Name: "a"}}, Cartesian) != nil {
+			t.Errorf("expect no combinations for empty dimension")
+		}
+	})
+}
+
+func TestExpandDimensions(t *testing.T) {
+	dims := []Dimension{
+		{Name: "a", Values: []any{1, 2}},
+		{Name: "b", Values: []any{"x", "y"}},
+	}
+	root := ExpandDimensions("root", "Root", dims, Cartesian)
+	if len(root.Children) != 2 {
+		t.Fatalf("expect 2 children, actual: %d", len(root.Children))
+	}
+	first := root.Children[0]
+	if first.ID != "root,a=1" || first.Label != "a=1" {
+		t.Errorf("unexpected first child: %s %s", first.ID, first.Label)
+	}
+	if len(first.Children) != 2 {
+		t.Fatalf("expect 2 grandchildren, actual: %d", len(first.Children))
+	}
+	leaf := first.Children[1]
+	if leaf.ID != "root,a=1,b=y" {
+		t.Errorf("unexpected leaf id: %s", leaf.ID)
+	}
+	if leaf.Conditions["a"] != 1 || leaf.Conditions["b"] != "y" {
+		t.Errorf("expect leaf to carry all values, actual: %v", leaf.Conditions)
+	}
+}
@@ -0,0 +1,308 @@
+package decision_tree
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ChangeKind is the kind of a node change between two trees
+type ChangeKind string
+
+const (
+	ChangeAdded      ChangeKind = "added"
+	ChangeRemoved    ChangeKind = "removed"
+	ChangeMoved      ChangeKind = "moved"
+	ChangeRelabelled ChangeKind = "relabelled"
+	ChangeConditions ChangeKind = "conditions"
+	ChangeEdgeLabel  ChangeKind = "edge"
+)
+
+// Change describes a single difference of a node,
+// a node can have multiple changes, e.g. moved and relabelled
+type Change struct {
+	Kind ChangeKind
+	ID   string
+
+	OldParentID string
+	NewParentID string
+
+	OldLabel string
+	NewLabel string
+
+	OldConditions map[string]any
+	NewConditions map[string]any
+
+	OldEdgeLabel string
+	NewEdgeLabel string
+}
+
+// String formats the change as a single line
+func (c *Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s (%s) under %s", c.ID, c.NewLabel, c.NewParentID)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s (%s) from %s", c.ID, c.OldLabel, c.OldParentID)
+	case ChangeMoved:
+		return fmt.Sprintf("~ %s moved: %s -> %s", c.ID, c.OldParentID, c.NewParentID)
+	case ChangeRelabelled:
+		return fmt.Sprintf("~ %s label: %q -> %q", c.ID, c.OldLabel, c.NewLabel)
+	case ChangeConditions:
+		return fmt.Sprintf("~ %s conditions: %s -> %s", c.ID, conditionsJSON(c.OldConditions), conditionsJSON(c.NewConditions))
+	case ChangeEdgeLabel:
+		return fmt.Sprintf("~ %s edge: %q -> %q", c.ID, c.OldEdgeLabel, c.NewEdgeLabel)
+	default:
+		return fmt.Sprintf("? %s %s", c.ID, c.Kind)
+	}
+}
+
+// conditionsJSON formats conditions as JSON, for display and comparison
+func conditionsJSON(conditions map[string]any) string {
+	if len(conditions) == 0 {
+		return "{}"
+	}
+	data, err := json.Marshal(conditions)
+	if err != nil {
+		return fmt.Sprintf("%v", conditions)
+	}
+	return string(data)
+}
+
+// diffEntry is a node with its parent, keyed for matching
+type diffEntry struct {
+	node      *Node
+	parent    *Node
+	parentKey string
+}
+
+// Diff compares two versions of a tree, nodes are matched by ID,
+// nodes without ID are matched by their label path.
+// Changes of b's nodes are reported in pre-order, followed by removals.
+func Diff(a, b *Node) []*Change {
+	oldEntries, oldOrder := indexNodes(a)
+	newEntries, newOrder := indexNodes(b)
+
+	var changes []*Change
+	for _, key := range newOrder {
+		newEntry := newEntries[key]
+		oldEntry, ok := oldEntries[key]
+		if !ok {
+			changes = append(changes, &Change{
+				Kind:        ChangeAdded,
+				ID:          newEntry.node.ID,
+				NewParentID: parentID(newEntry.parent),
+				NewLabel:    newEntry.node.Label,
+			})
+			continue
+		}
+		if oldEntry.parentKey != newEntry.parentKey {
+			changes = append(changes, &Change{
+				Kind:        ChangeMoved,
+				ID:          newEntry.node.ID,
+				OldParentID: parentID(oldEntry.parent),
+				NewParentID: parentID(newEntry.parent),
+			})
+		}
+		if oldEntry.node.Label != newEntry.node.Label {
+			changes = append(changes, &Change{
+				Kind:     ChangeRelabelled,
+				ID:       newEntry.node.ID,
+				OldLabel: oldEntry.node.Label,
+				NewLabel: newEntry.node.Label,
+			})
+		}
+		if !conditionsEqual(oldEntry.node.Conditions, newEntry.node.Conditions) {
+			changes = append(changes, &Change{
+				Kind:          ChangeConditions,
+				ID:            newEntry.node.ID,
+				OldConditions: oldEntry.node.Conditions,
+				NewConditions: newEntry.node.Conditions,
+			})
+		}
+		if oldEntry.node.EdgeLabel != newEntry.node.EdgeLabel {
+			changes = append(changes, &Change{
+				Kind:         ChangeEdgeLabel,
+				ID:           newEntry.node.ID,
+				OldEdgeLabel: oldEntry.node.EdgeLabel,
+				NewEdgeLabel: newEntry.node.EdgeLabel,
+			})
+		}
+	}
+
+	for _, key := range oldOrder {
+		if _, ok := newEntries[key]; ok {
+			continue
+		}
+		oldEntry := oldEntries[key]
+		changes = append(changes, &Change{
+			Kind:        ChangeRemoved,
+			ID:          oldEntry.node.ID,
+			OldParentID: parentID(oldEntry.parent),
+			OldLabel:    oldEntry.node.Label,
+		})
+	}
+	return changes
+}
+
+// DiffTree merges two versions of a tree into one for rendering:
+// the result is a copy of b with removed nodes of a re-inserted under
+// their old parents, every changed node is styled by its change.
+func DiffTree(a, b *Node) *Node {
+	if b == nil {
+		b = &Node{}
+	}
+	changes := Diff(a, b)
+	merged := b.Clone()
+
+	// added > moved > relabelled or conditions
+	kinds := make(map[string]ChangeKind)
+	for _, change := range changes {
+		if change.Kind == ChangeRemoved || change.ID == "" {
+			continue
+		}
+		if diffStylePriority(change.Kind) > diffStylePriority(kinds[change.ID]) {
+			kinds[change.ID] = change.Kind
+		}
+	}
+	var apply func(node *Node)
+	apply = func(node *Node) {
+		if kind, ok := kinds[node.ID]; ok && node.ID != "" {
+			node.Style = diffStyle(kind)
+		}
+		for _, child := range node.Children {
+			apply(child)
+		}
+	}
+	apply(merged)
+
+	// re-insert removed nodes, only top-most removed nodes
+	// are inserted as their descendants come along
+	oldEntries, oldOrder := indexNodes(a)
+	newEntries, _ := indexNodes(merged)
+	for _, key := range oldOrder {
+		if _, ok := newEntries[key]; ok {
+			continue
+		}
+		oldEntry := oldEntries[key]
+		mergedParent := merged
+		if parentEntry, ok := newEntries[oldEntry.parentKey]; ok {
+			mergedParent = parentEntry.node
+		} else if oldEntry.parentKey != "" {
+			// parent removed as well, inserted with it
+			continue
+		}
+		// descendants still in b were moved out, they are shown where they are now
+		var cloneRemoved func(node *Node, key string) *Node
+		cloneRemoved = func(node *Node, key string) *Node {
+			leaf := *node
+			leaf.Children = nil
+			clone := leaf.Clone()
+			clone.Style = diffStyle(ChangeRemoved)
+			for _, child := range node.Children {
+				childKey := nodeKey(child, key)
+				if _, ok := newEntries[childKey]; ok {
+					continue
+				}
+				clone.Children = append(clone.Children, cloneRemoved(child, childKey))
+			}
+			return clone
+		}
+		mergedParent.Children = append(mergedParent.Children, cloneRemoved(oldEntry.node, key))
+	}
+	return merged
+}
+
+var diffStyles = map[ChangeKind]NodeStyle{
+	ChangeAdded:      {Shape: "rectangle", Fill: "#e6ffed", Stroke: "#28a745", StrokeWidth: 2},
+	ChangeRemoved:    {Shape: "rectangle", Fill: "#ffeef0", Stroke: "#d73a49", StrokeWidth: 2},
+	ChangeMoved:      {Shape: "rectangle", Fill: "#e6f4ff", Stroke: "#0366d6", StrokeWidth: 2},
+	ChangeRelabelled: {Shape: "rectangle", Fill: "#fff8e1", Stroke: "#f0ad4e", StrokeWidth: 2},
+	ChangeConditions: {Shape: "rectangle", Fill: "#fff8e1", Stroke: "#f0ad4e", StrokeWidth: 2},
+	ChangeEdgeLabel:  {Shape: "rectangle", Fill: "#fff8e1", Stroke: "#f0ad4e", StrokeWidth: 2},
+}
+
+func diffStyle(kind ChangeKind) *NodeStyle {
+	style := diffStyles[kind]
+	return &style
+}
+
+func diffStylePriority(kind ChangeKind) int {
+	switch kind {
+	case ChangeAdded:
+		return 3
+	case ChangeMoved:
+		return 2
+	case ChangeRelabelled, ChangeConditions, ChangeEdgeLabel:
+		return 1
+	}
+	return 0
+}
+
+// indexNodes indexes nodes by key, returns keys in pre-order
+func indexNodes(root *Node) (map[string]*diffEntry, []string) {
+	entries := make(map[string]*diffEntry)
+	var order []string
+	var visit func(node *Node, parent *Node, parentKey string)
+	visit = func(node *Node, parent *Node, parentKey string) {
+		if node == nil {
+			return
+		}
+		key := nodeKey(node, parentKey)
+		if _, ok := entries[key]; !ok {
+			entries[key] = &diffEntry{node: node, parent: parent, parentKey: parentKey}
+			order = append(order, key)
+		}
+		for _, child := range node.Children {
+			visit(child, node, key)
+		}
+	}
+	visit(root, nil, "")
+	return entries, order
+}
+
+// nodeKey is the key of a node for matching, its ID,
+// or its label path if it has no ID
+func nodeKey(node *Node, parentKey string) string {
+	if node.ID == "" {
+		return parentKey + "/" + node.Label
+	}
+	return "id:" + node.ID
+}
+
+func parentID(parent *Node) string {
+	if parent == nil {
+		return ""
+	}
+	return parent.ID
+}
+
+func conditionsEqual(a, b map[string]any) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	// compare by JSON form, so []string and []any are considered equal
+	return conditionsJSON(a) == conditionsJSON(b)
+}
+
+// FormatChanges formats changes one per line, sorted by kind
+// when sortByKind is true, otherwise in reported order
+func FormatChanges(changes []*Change, sortByKind bool) string {
+	lines := make([]string, 0, len(changes))
+	sorted := changes
+	if sortByKind {
+		sorted = append([]*Change(nil), changes...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Kind < sorted[j].Kind
+		})
+	}
+	for _, change := range sorted {
+		lines = append(lines, change.String())
+	}
+	return strings.Join(lines, "\n")
+}
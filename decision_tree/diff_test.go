@@ -0,0 +1,127 @@
+package decision_tree
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := &Node{ID: "root", Label: "Root", Children: []*Node{
+		{ID: "a", Label: "A", Conditions: map[string]any{"x": 1}, Children: []*Node{
+			{ID: "a1", Label: "A1"},
+		}},
+		{ID: "b", Label: "B", Children: []*Node{
+			{ID: "b1", Label: "B1"},
+		}},
+	}}
+	b := &Node{ID: "root", Label: "Root", Children: []*Node{
+		{ID: "a", Label: "A renamed", Conditions: map[string]any{"x": 2}, Children: []*Node{
+			{ID: "a1", Label: "A1", EdgeLabel: "x > 1"},
+			{ID: "b1", Label: "B1"},
+		}},
+		{ID: "c", Label: "C"},
+	}}
+
+	changes := Diff(a, b)
+	expect := []string{
+		`~ a label: "A" -> "A renamed"`,
+		`~ a conditions: {"x":1} -> {"x":2}`,
+		`~ a1 edge: "" -> "x > 1"`,
+		`~ b1 moved: b -> a`,
+		`+ c (C) under root`,
+		`- b (B) from root`,
+	}
+	if len(changes) != len(expect) {
+		t.Fatalf("expect %d changes, actual: %d\n%s", len(expect), len(changes), FormatChanges(changes, false))
+	}
+	for i, change := range changes {
+		if change.String() != expect[i] {
+			t.Errorf("change %d: expect %s, actual: %s", i, expect[i], change.String())
+		}
+	}
+
+	if changes := Diff(a, a.Clone()); len(changes) != 0 {
+		t.Errorf("expect no changes for identical trees, actual:\n%s", FormatChanges(changes, false))
+	}
+}
+
+func TestDiffTree(t *testing.T) {
+	a := &Node{ID: "root", Label: "Root", Children: []*Node{
+		{ID: "a", Label: "A"},
+		{ID: "b", Label: "B", Children: []*Node{
+			{ID: "b1", Label: "B1"},
+		}},
+	}}
+	b := &Node{ID: "root", Label: "Root", Children: []*Node{
+		{ID: "a", Label: "A renamed"},
+		{ID: "c", Label: "C"},
+	}}
+
+	merged := DiffTree(a, b)
+	if len(merged.Children) != 3 {
+		t.Fatalf("expect 3 children, actual: %d", len(merged.Children))
+	}
+	if merged.Style != nil {
+		t.Errorf("expect unchanged root to keep its style")
+	}
+	if merged.Children[0].Style.Stroke != diffStyles[ChangeRelabelled].Stroke {
+		t.Errorf("expect relabelled style on a, actual: %+v", merged.Children[0].Style)
+	}
+	if merged.Children[1].Style.Stroke != diffStyles[ChangeAdded].Stroke {
+		t.Errorf("expect added style on c, actual: %+v", merged.Children[1].Style)
+	}
+	removed := merged.Children[2]
+	if removed.ID != "b" || removed.Style.Stroke != diffStyles[ChangeRemoved].Stroke {
+		t.Errorf("expect removed b appended, actual: %s %+v", removed.ID, removed.Style)
+	}
+	if len(removed.Children) != 1 || removed.Children[0].Style.Stroke != diffStyles[ChangeRemoved].Stroke {
+		t.Errorf("expect removed descendants styled as removed")
+	}
+	if b.Children[0].Style != nil {
+		t.Errorf("expect DiffTree not to modify its input")
+	}
+}
+
+func TestDiffTreeMovedOutOfRemoved(t *testing.T) {
+	a := &Node{ID: "root", Label: "Root", Children: []*Node{
+		{ID: "a", Label: "A"},
+		{ID: "b", Label: "B", Children: []*Node{
+			{ID: "b1", Label: "B1", Children: []*Node{
+				{ID: "b1x", Label: "B1X"},
+			}},
+			{ID: "b2", Label: "B2"},
+		}},
+	}}
+	b := &Node{ID: "root", Label: "Root", Children: []*Node{
+		{ID: "a", Label: "A", Children: []*Node{
+			{ID: "b1", Label: "B1"},
+		}},
+	}}
+
+	merged := DiffTree(a, b)
+	count := make(map[string]int)
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		count[node.ID]++
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	visit(merged)
+	for _, id := range []string{"root", "a", "b", "b1", "b1x", "b2"} {
+		if count[id] != 1 {
+			t.Errorf("expect %s once in merged tree, actual: %d", id, count[id])
+		}
+	}
+	b1 := merged.Find("b1")
+	if b1.Style.Stroke != diffStyles[ChangeMoved].Stroke {
+		t.Errorf("expect moved style on b1, actual: %+v", b1.Style)
+	}
+	// removed from under b1, which is now under a
+	if len(b1.Children) != 1 || b1.Children[0].ID != "b1x" || b1.Children[0].Style.Stroke != diffStyles[ChangeRemoved].Stroke {
+		t.Errorf("expect removed b1x under moved b1")
+	}
+	removed := merged.Find("b")
+	if len(removed.Children) != 1 || removed.Children[0].ID != "b2" {
+		t.Errorf("expect only b2 left under removed b, actual: %d children", len(removed.Children))
+	}
+}
@@ -2,9 +2,10 @@
 //
 // The package supports:
 // - JSON-based tree definition
-// - Automatic layout calculation
+// - Automatic layout calculation, top-down, left-to-right or radial
 // - SVG rendering with proper spacing and connections
-// - Custom node styling
+// - Custom node styling: shapes, and Config.StyleRules by conditions or tags
+// - Diffing two versions of a tree
 //
 // Example usage:
 //
@@ -23,9 +24,13 @@
 // RoadMap:
 // - [ ] Add background color for terminal nodes
 // - [ ] Add extra legend
-// - [ ] Adjust font size for conditions
+// - [x] Adjust font size for conditions: Config.FontSize and Config.ConditionFontSize
 // - [ ] Make terminal node size more appropriate
-// - [ ] go-ddt supports rendering decision tree via server(live modification): go-ddt edit decision.dtree.json
-// - [ ] Draw ascii tree
-// - [ ] Serve via http
+// - [x] go-ddt supports rendering decision tree via server(live modification): go-ddt edit decision.dtree.json
+// - [x] Draw ascii tree: go-ddt view --text, see package text
+// - [x] Export PNG and PDF without external tools: go-ddt view --out tree.png, see packages png and pdf
+// - [x] Serve via http, with collapsing, search, zoom/pan and click-to-source
+// - [x] Build trees from markdown lists and Mermaid flowcharts: go-ddt view spec.md, see package spec
+// - [x] Level of detail for large trees: Config.MaxNodes collapses subtrees into "+N more" nodes
+// - [x] Show one branch or the top levels: Node.Subtree, Node.Prune, Node.Filter, go-ddt view --root ID --depth N --tag TAG
 package decision_tree
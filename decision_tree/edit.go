@@ -0,0 +1,180 @@
+package decision_tree
+
+import (
+	"fmt"
+)
+
+// NodePatch describes changes to a node, nil fields are left unchanged
+type NodePatch struct {
+	ID    *string `json:"id,omitempty"`    // rename
+	Label *string `json:"label,omitempty"` // relabel
+	// EdgeLabel relabels the edge from the parent, empty clears it
+	EdgeLabel *string `json:"edgeLabel,omitempty"`
+	// Conditions replaces all conditions, an empty map clears them
+	Conditions map[string]any `json:"conditions,omitempty"`
+	// Style replaces the style, an empty style clears it
+	Style *NodeStyle `json:"style,omitempty"`
+
+	// re-parent
+	ParentID *string `json:"parentID,omitempty"`
+	// Index is the position among the new siblings, nil or negative appends
+	Index *int `json:"index,omitempty"`
+}
+
+// AddChild inserts child under the node with parentID at index,
+// a negative or out of range index appends
+func (n *Node) AddChild(parentID string, index int, child *Node) error {
+	if child == nil {
+		return fmt.Errorf("child is nil")
+	}
+	if child.ID == "" {
+		return fmt.Errorf("requires id")
+	}
+	parent, _ := n.findWithParent(parentID)
+	if parent == nil {
+		return fmt.Errorf("parent not found: %s", parentID)
+	}
+	if err := n.checkIDs(child); err != nil {
+		return err
+	}
+	parent.Children = insertChild(parent.Children, index, child)
+	return nil
+}
+
+// Remove removes the node with id and its subtree, the root cannot be removed
+func (n *Node) Remove(id string) error {
+	node, parent := n.findWithParent(id)
+	if node == nil {
+		return fmt.Errorf("node not found: %s", id)
+	}
+	if parent == nil {
+		return fmt.Errorf("cannot remove root: %s", id)
+	}
+	parent.Children = removeChild(parent.Children, node)
+	return nil
+}
+
+// Update applies patch to the node with id
+func (n *Node) Update(id string, patch *NodePatch) error {
+	node, parent := n.findWithParent(id)
+	if node == nil {
+		return fmt.Errorf("node not found: %s", id)
+	}
+	if patch == nil {
+		return nil
+	}
+
+	// validate everything before changing anything
+	var newParent *Node
+	if patch.ParentID != nil || patch.Index != nil {
+		if parent == nil {
+			return fmt.Errorf("cannot move root: %s", id)
+		}
+		newParent = parent
+		if patch.ParentID != nil {
+			newParent, _ = n.findWithParent(*patch.ParentID)
+			if newParent == nil {
+				return fmt.Errorf("parent not found: %s", *patch.ParentID)
+			}
+			if _, p := node.findWithParent(*patch.ParentID); p != nil || newParent == node {
+				return fmt.Errorf("cannot move %s under itself", id)
+			}
+		}
+	}
+	if patch.ID != nil && *patch.ID != node.ID {
+		if *patch.ID == "" {
+			return fmt.Errorf("requires id")
+		}
+		if existing, _ := n.findWithParent(*patch.ID); existing != nil {
+			return fmt.Errorf("duplicate node: %s", *patch.ID)
+		}
+	}
+
+	if patch.ID != nil {
+		node.ID = *patch.ID
+	}
+	if patch.Label != nil {
+		node.Label = *patch.Label
+	}
+	if patch.EdgeLabel != nil {
+		node.EdgeLabel = *patch.EdgeLabel
+	}
+	if patch.Conditions != nil {
+		if len(patch.Conditions) == 0 {
+			node.Conditions = nil
+		} else {
+			node.Conditions = patch.Conditions
+		}
+	}
+	if patch.Style != nil {
+		if *patch.Style == (NodeStyle{}) {
+			node.Style = nil
+		} else {
+			style := *patch.Style
+			node.Style = &style
+		}
+	}
+	if newParent != nil {
+		index := -1
+		if patch.Index != nil {
+			index = *patch.Index
+		}
+		parent.Children = removeChild(parent.Children, node)
+		newParent.Children = insertChild(newParent.Children, index, node)
+	}
+	return nil
+}
+
+// findWithParent finds the node with id in pre-order, returns the node and its parent
+func (n *Node) findWithParent(id string) (node *Node, parent *Node) {
+	var visit func(node *Node, parent *Node) (*Node, *Node)
+	visit = func(node *Node, parent *Node) (*Node, *Node) {
+		if node.ID == id {
+			return node, parent
+		}
+		for _, child := range node.Children {
+			if found, p := visit(child, node); found != nil {
+				return found, p
+			}
+		}
+		return nil, nil
+	}
+	if n == nil {
+		return nil, nil
+	}
+	return visit(n, nil)
+}
+
+// checkIDs checks IDs in subtree do not exist in the tree
+func (n *Node) checkIDs(subtree *Node) error {
+	if subtree.ID != "" {
+		if existing, _ := n.findWithParent(subtree.ID); existing != nil {
+			return fmt.Errorf("duplicate node: %s", subtree.ID)
+		}
+	}
+	for _, child := range subtree.Children {
+		if err := n.checkIDs(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertChild(children []*Node, index int, child *Node) []*Node {
+	if index < 0 || index >= len(children) {
+		return append(children, child)
+	}
+	children = append(children, nil)
+	copy(children[index+1:], children[index:])
+	children[index] = child
+	return children
+}
+
+func removeChild(children []*Node, child *Node) []*Node {
+	for i, c := range children {
+		if c == child {
+			return append(children[:i:i], children[i+1:]...)
+		}
+	}
+	return children
+}
@@ -0,0 +1,118 @@
+package decision_tree
+
+import (
+	"strings"
+	"testing"
+)
+
+func formatIDs(node *Node) string {
+	if len(node.Children) == 0 {
+		return node.ID
+	}
+	var children []string
+	for _, child := range node.Children {
+		children = append(children, formatIDs(child))
+	}
+	return node.ID + "(" + strings.Join(children, ",") + ")"
+}
+
+func TestEdit(t *testing.T) {
+	newTree := func() *Node {
+		return &Node{ID: "root", Children: []*Node{
+			{ID: "a", Children: []*Node{{ID: "a1"}}},
+			{ID: "b"},
+		}}
+	}
+	str := func(s string) *string { return &s }
+	num := func(i int) *int { return &i }
+
+	t.Run("AddChild", func(t *testing.T) {
+		tree := newTree()
+		if err := tree.AddChild("root", 1, &Node{ID: "c"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := tree.AddChild("a", -1, &Node{ID: "a2"}); err != nil {
+			t.Fatal(err)
+		}
+		if actual := formatIDs(tree); actual != "root(a(a1,a2),c,b)" {
+			t.Errorf("unexpected tree: %s", actual)
+		}
+		if err := tree.AddChild("root", -1, &Node{ID: "a1"}); err == nil {
+			t.Errorf("expect duplicate error")
+		}
+		if err := tree.AddChild("missing", -1, &Node{ID: "x"}); err == nil {
+			t.Errorf("expect missing parent error")
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		tree := newTree()
+		if err := tree.Remove("a"); err != nil {
+			t.Fatal(err)
+		}
+		if actual := formatIDs(tree); actual != "root(b)" {
+			t.Errorf("unexpected tree: %s", actual)
+		}
+		if err := tree.Remove("root"); err == nil {
+			t.Errorf("expect error removing root")
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		tree := newTree()
+		err := tree.Update("a1", &NodePatch{
+			ID:         str("a_1"),
+			Label:      str("A 1"),
+			EdgeLabel:  str("k=v"),
+			Conditions: map[string]any{"k": "v"},
+			Style:      &NodeStyle{Fill: "#fff"},
+			ParentID:   str("b"),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := formatIDs(tree); actual != "root(a,b(a_1))" {
+			t.Errorf("unexpected tree: %s", actual)
+		}
+		node := tree.Children[1].Children[0]
+		if node.Label != "A 1" || node.EdgeLabel != "k=v" || node.Conditions["k"] != "v" || node.Style.Fill != "#fff" {
+			t.Errorf("unexpected node: %+v", node)
+		}
+
+		if err := tree.Update("b", &NodePatch{Index: num(0)}); err != nil {
+			t.Fatal(err)
+		}
+		if actual := formatIDs(tree); actual != "root(b(a_1),a)" {
+			t.Errorf("unexpected tree after reorder: %s", actual)
+		}
+
+		if err := tree.Update("a_1", &NodePatch{Conditions: map[string]any{}, Style: &NodeStyle{}}); err != nil {
+			t.Fatal(err)
+		}
+		if node.Conditions != nil || node.Style != nil {
+			t.Errorf("expect conditions and style cleared: %+v", node)
+		}
+	})
+
+	t.Run("UpdateErrors", func(t *testing.T) {
+		tree := newTree()
+		if err := tree.Update("a", &NodePatch{ParentID: str("a1")}); err == nil {
+			t.Errorf("expect error moving under descendant")
+		}
+		if err := tree.Update("a", &NodePatch{ParentID: str("a")}); err == nil {
+			t.Errorf("expect error moving under itself")
+		}
+		if err := tree.Update("root", &NodePatch{ParentID: str("a")}); err == nil {
+			t.Errorf("expect error moving root")
+		}
+		if err := tree.Update("a", &NodePatch{ID: str("b"), Label: str("changed")}); err == nil {
+			t.Errorf("expect duplicate error")
+		}
+		if tree.Children[0].Label == "changed" {
+			t.Errorf("expect failed update to leave node unchanged")
+		}
+		if actual := formatIDs(tree); actual != "root(a(a1),b)" {
+			t.Errorf("expect tree unchanged: %s", actual)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/layout"
+)
+
+// ToDrawIO generates a draw.io diagram of the tree, positioned by the
+// same layout as the svg so it opens ready to edit. Conditions are
+// written inside their nodes, which are grown over the space the
+// layout leaves for them.
+func ToDrawIO(root *decision_tree.Node) string {
+	var sb strings.Builder
+	sb.WriteString("<mxfile host=\"go-ddt\">\n")
+	sb.WriteString("  <diagram name=\"Decision Tree\" id=\"tree\">\n")
+	sb.WriteString("    <mxGraphModel>\n")
+	sb.WriteString("      <root>\n")
+	sb.WriteString("        <mxCell id=\"0\"/>\n")
+	sb.WriteString("        <mxCell id=\"1\" parent=\"0\"/>\n")
+	if root != nil {
+		config := decision_tree.DefaultConfig()
+		engine := layout.NewEngine(config)
+		conditionLineHeight := layout.NewTextStyle(config).ConditionLineHeight
+		ids := newIDAllocator(func(id string) string { return id })
+		// the cells of the diagram itself
+		ids.used["0"], ids.used["1"] = true, true
+		var visit func(node *layout.LayoutNode, parentID string)
+		visit = func(node *layout.LayoutNode, parentID string) {
+			id := ids.get(node.Node)
+			value := html.EscapeString(node.Node.DisplayLabel())
+			x, y, height := node.X-node.Width/2, node.Y, node.Height
+			if conditions := decision_tree.FormatConditions(node.Node.Conditions); len(conditions) > 0 {
+				value += "<br><i>" + html.EscapeString(strings.Join(conditions, ", ")) + "</i>"
+				condHeight := float64(len(node.ConditionLines)) * conditionLineHeight
+				height += condHeight
+				if len(node.Children) > 0 {
+					// conditions of parents are above them
+					y -= condHeight
+				}
+			}
+			fmt.Fprintf(&sb, "        <mxCell id=%s value=%s style=%s vertex=\"1\" parent=\"1\">\n",
+				quoteXML(id), quoteXML(value), quoteXML(drawIOStyle(node.Style)))
+			fmt.Fprintf(&sb, "          <mxGeometry x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" as=\"geometry\"/>\n",
+				drawIONum(x), drawIONum(y), drawIONum(node.Width), drawIONum(height))
+			sb.WriteString("        </mxCell>\n")
+			if parentID != "" {
+				edgeID := ids.unique(parentID + "-" + id)
+				fmt.Fprintf(&sb, "        <mxCell id=%s value=%s style=\"endArrow=classic;html=1;\" edge=\"1\" parent=\"1\" source=%s target=%s>\n",
+					quoteXML(edgeID), quoteXML(html.EscapeString(node.Node.EdgeLabel)), quoteXML(parentID), quoteXML(id))
+				sb.WriteString("          <mxGeometry relative=\"1\" as=\"geometry\"/>\n")
+				sb.WriteString("        </mxCell>\n")
+			}
+			for _, child := range node.Children {
+				visit(child, id)
+			}
+		}
+		visit(engine.CalculateLayout(root), "")
+	}
+	sb.WriteString("      </root>\n")
+	sb.WriteString("    </mxGraphModel>\n")
+	sb.WriteString("  </diagram>\n")
+	sb.WriteString("</mxfile>\n")
+	return sb.String()
+}
+
+// drawIOStyle converts a node style to draw.io's style string
+func drawIOStyle(style *decision_tree.NodeStyle) string {
+	parts := []string{"whiteSpace=wrap", "html=1"}
+	if style == nil {
+		return strings.Join(parts, ";") + ";"
+	}
+	switch style.Shape {
+	case decision_tree.ShapeDiamond:
+		parts = append(parts, "rhombus")
+	case decision_tree.ShapeEllipse:
+		parts = append(parts, "ellipse")
+	case decision_tree.ShapeHexagon:
+		parts = append(parts, "shape=hexagon", "perimeter=hexagonPerimeter2")
+	case decision_tree.ShapeStadium:
+		parts = append(parts, "rounded=1", "arcSize=50")
+	}
+	if isPlainColor(style.Fill) {
+		parts = append(parts, "fillColor="+style.Fill)
+	}
+	if isPlainColor(style.Stroke) {
+		parts = append(parts, "strokeColor="+style.Stroke)
+	}
+	if style.StrokeWidth > 0 {
+		parts = append(parts, fmt.Sprintf("strokeWidth=%d", style.StrokeWidth))
+	}
+	return strings.Join(parts, ";") + ";"
+}
+
+// quoteXML quotes an XML attribute value
+func quoteXML(s string) string {
+	return `"` + html.EscapeString(s) + `"`
+}
+
+// drawIONum formats a coordinate, rounded to a hundredth
+func drawIONum(f float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.2f", f), "0")
+	return strings.TrimSuffix(s, ".")
+}
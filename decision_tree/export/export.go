@@ -0,0 +1,290 @@
+// Package export writes decision trees in formats other than SVG,
+// such as JSON, Mermaid, Graphviz DOT, PlantUML mindmaps and WBS,
+// and draw.io diagrams.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/svg"
+)
+
+// Format is an export format
+type Format string
+
+const (
+	FormatJSON    Format = "json"
+	FormatMermaid Format = "mermaid"
+	FormatSVG     Format = "svg"
+	FormatDOT     Format = "dot"
+	FormatMindmap Format = "mindmap" // PlantUML mindmap
+	FormatWBS     Format = "wbs"     // PlantUML work breakdown structure
+	FormatDrawIO  Format = "drawio"
+)
+
+// Formats lists all supported formats
+var Formats = []Format{FormatJSON, FormatMermaid, FormatSVG, FormatDOT, FormatMindmap, FormatWBS, FormatDrawIO}
+
+// Export writes the tree in the given format
+func Export(root *decision_tree.Node, format Format) ([]byte, error) {
+	if root == nil {
+		return nil, fmt.Errorf("tree is nil")
+	}
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(root, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case FormatMermaid:
+		return []byte(ToMermaid(root)), nil
+	case FormatSVG:
+		return []byte(svg.NewRenderer(decision_tree.DefaultConfig()).RenderTree(root)), nil
+	case FormatDOT:
+		return []byte(ToDOT(root)), nil
+	case FormatMindmap:
+		return []byte(ToPlantUMLMindmap(root)), nil
+	case FormatWBS:
+		return []byte(ToPlantUMLWBS(root)), nil
+	case FormatDrawIO:
+		return []byte(ToDrawIO(root)), nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// ToMermaid generates a Mermaid flowchart of the tree. Shapes map to
+// Mermaid's node shapes, styles with a Class become classDefs shared
+// by their nodes, other styles are set on each node.
+func ToMermaid(root *decision_tree.Node) string {
+	return ToMermaidWith(root, MermaidOptions{})
+}
+
+// MermaidOptions customizes the flowchart written by ToMermaidWith
+type MermaidOptions struct {
+	HideConditions bool // Whether to leave conditions out of node labels
+
+	// Shape returns the brackets enclosing the label of a node, depth is
+	// 0 for the root. Nil to derive them from the shape of the node's style.
+	Shape func(node *decision_tree.Node, depth int) (string, string)
+
+	// ClassDefs replaces the properties of classDefs, by class name
+	ClassDefs map[string]string
+}
+
+// ToMermaidWith generates a Mermaid flowchart of the tree as ToMermaid,
+// customized by options
+func ToMermaidWith(root *decision_tree.Node, options MermaidOptions) string {
+	var sb strings.Builder
+	sb.WriteString("graph TD;\n")
+	if root == nil {
+		return sb.String()
+	}
+	ids := newIDAllocator(mermaidID)
+	var classes []string
+	classStyles := make(map[string]string)
+	classIDs := make(map[string][]string)
+	var visit func(node *decision_tree.Node, parentID string, depth int)
+	visit = func(node *decision_tree.Node, parentID string, depth int) {
+		id := ids.get(node)
+		label := escapeMermaid(node.DisplayLabel())
+		if conditions := decision_tree.FormatConditions(node.Conditions); len(conditions) > 0 && !options.HideConditions {
+			label += "<br><i>" + escapeMermaid(strings.Join(conditions, ", ")) + "</i>"
+		}
+		left, right := mermaidShape(node.Style)
+		if options.Shape != nil {
+			left, right = options.Shape(node, depth)
+		}
+		fmt.Fprintf(&sb, "    %s%s\"%s\"%s;\n", id, left, label, right)
+		if parentID != "" {
+			if node.EdgeLabel != "" {
+				fmt.Fprintf(&sb, "    %s -- \"%s\" --> %s;\n", parentID, escapeMermaid(node.EdgeLabel), id)
+			} else {
+				fmt.Fprintf(&sb, "    %s --> %s;\n", parentID, id)
+			}
+		}
+		if style := mermaidStyle(node.Style); style != "" {
+			if class := mermaidID(node.Style.Class); class != "" {
+				if _, ok := classStyles[class]; !ok {
+					if def, ok := options.ClassDefs[class]; ok {
+						style = def
+					}
+					classes = append(classes, class)
+					classStyles[class] = style
+				}
+				classIDs[class] = append(classIDs[class], id)
+			} else {
+				fmt.Fprintf(&sb, "    style %s %s;\n", id, style)
+			}
+		}
+		for _, child := range node.Children {
+			visit(child, id, depth+1)
+		}
+	}
+	visit(root, "", 0)
+	for _, class := range classes {
+		fmt.Fprintf(&sb, "    classDef %s %s;\n", class, classStyles[class])
+		fmt.Fprintf(&sb, "    class %s %s;\n", strings.Join(classIDs[class], ","), class)
+	}
+	return sb.String()
+}
+
+// ToDOT generates a Graphviz DOT digraph of the tree
+func ToDOT(root *decision_tree.Node) string {
+	var sb strings.Builder
+	sb.WriteString("digraph tree {\n")
+	sb.WriteString("  node [shape=box];\n")
+	if root == nil {
+		sb.WriteString("}\n")
+		return sb.String()
+	}
+	ids := newIDAllocator(func(id string) string { return id })
+	var visit func(node *decision_tree.Node, parentID string)
+	visit = func(node *decision_tree.Node, parentID string) {
+		id := ids.get(node)
+		lines := append([]string{node.DisplayLabel()}, decision_tree.FormatConditions(node.Conditions)...)
+		attrs := []string{fmt.Sprintf("label=%s", quoteDOT(strings.Join(lines, "\n")))}
+		attrs = append(attrs, dotStyle(node.Style)...)
+		fmt.Fprintf(&sb, "  %s [%s];\n", quoteDOT(id), strings.Join(attrs, ", "))
+		if parentID != "" {
+			if node.EdgeLabel != "" {
+				fmt.Fprintf(&sb, "  %s -> %s [label=%s];\n", quoteDOT(parentID), quoteDOT(id), quoteDOT(node.EdgeLabel))
+			} else {
+				fmt.Fprintf(&sb, "  %s -> %s;\n", quoteDOT(parentID), quoteDOT(id))
+			}
+		}
+		for _, child := range node.Children {
+			visit(child, id)
+		}
+	}
+	visit(root, "")
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// idAllocator assigns unique IDs to nodes, nodes without ID
+// or with duplicate IDs get generated ones
+type idAllocator struct {
+	sanitize func(id string) string
+	used     map[string]bool
+	next     int
+}
+
+func newIDAllocator(sanitize func(id string) string) *idAllocator {
+	return &idAllocator{sanitize: sanitize, used: make(map[string]bool)}
+}
+
+func (c *idAllocator) get(node *decision_tree.Node) string {
+	return c.unique(c.sanitize(node.ID))
+}
+
+// unique returns id, or a generated ID if it is empty or used
+func (c *idAllocator) unique(id string) string {
+	for id == "" || c.used[id] {
+		c.next++
+		id = fmt.Sprintf("node_%d", c.next)
+	}
+	c.used[id] = true
+	return id
+}
+
+// mermaidID replaces characters not allowed in Mermaid node IDs
+func mermaidID(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+func escapeMermaid(label string) string {
+	return strings.ReplaceAll(label, "\"", "#quot;")
+}
+
+// mermaidShape returns the brackets enclosing the label of a node of the style
+func mermaidShape(style *decision_tree.NodeStyle) (string, string) {
+	if style == nil {
+		return "[", "]"
+	}
+	switch style.Shape {
+	case decision_tree.ShapeDiamond:
+		return "{", "}"
+	case decision_tree.ShapeEllipse:
+		return "((", "))"
+	case decision_tree.ShapeHexagon:
+		return "{{", "}}"
+	case decision_tree.ShapeStadium:
+		return "([", "])"
+	}
+	return "[", "]"
+}
+
+func mermaidStyle(style *decision_tree.NodeStyle) string {
+	if style == nil {
+		return ""
+	}
+	var parts []string
+	if isPlainColor(style.Fill) {
+		parts = append(parts, "fill:"+style.Fill)
+	}
+	if isPlainColor(style.Stroke) {
+		parts = append(parts, "stroke:"+style.Stroke)
+	}
+	if style.StrokeWidth > 0 {
+		parts = append(parts, fmt.Sprintf("stroke-width:%dpx", style.StrokeWidth))
+	}
+	return strings.Join(parts, ",")
+}
+
+func dotStyle(style *decision_tree.NodeStyle) []string {
+	if style == nil {
+		return nil
+	}
+	var attrs []string
+	var styles []string
+	switch style.Shape {
+	case decision_tree.ShapeDiamond, decision_tree.ShapeEllipse, decision_tree.ShapeHexagon:
+		attrs = append(attrs, "shape="+style.Shape)
+	case decision_tree.ShapeStadium:
+		// DOT has no stadium, a fully rounded box comes closest
+		styles = append(styles, "rounded")
+	}
+	if isPlainColor(style.Fill) {
+		styles = append(styles, "filled")
+	}
+	if len(styles) > 0 {
+		value := strings.Join(styles, ",")
+		if len(styles) > 1 {
+			value = quoteDOT(value)
+		}
+		attrs = append(attrs, "style="+value)
+	}
+	if isPlainColor(style.Fill) {
+		attrs = append(attrs, "fillcolor="+quoteDOT(style.Fill))
+	}
+	if isPlainColor(style.Stroke) {
+		attrs = append(attrs, "color="+quoteDOT(style.Stroke))
+	}
+	if style.StrokeWidth > 0 {
+		attrs = append(attrs, fmt.Sprintf("penwidth=%d", style.StrokeWidth))
+	}
+	return attrs
+}
+
+// isPlainColor reports whether the color can be used outside SVG,
+// references like url(#nodeGradient) cannot
+func isPlainColor(color string) bool {
+	return color != "" && !strings.HasPrefix(color, "url(")
+}
+
+func quoteDOT(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
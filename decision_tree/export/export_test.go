@@ -0,0 +1,234 @@
+package export
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+func testTree() *decision_tree.Node {
+	return &decision_tree.Node{
+		ID:    "root",
+		Label: "Root",
+		Children: []*decision_tree.Node{
+			{ID: "a-1", Label: `Say "hi"`, Conditions: map[string]any{"b": 2, "a": 1}},
+			{Label: "No ID", EdgeLabel: `x="y"`, Style: &decision_tree.NodeStyle{Fill: "#ffffff", Stroke: "#000000", StrokeWidth: 2}},
+		},
+	}
+}
+
+func TestToMermaid(t *testing.T) {
+	expect := `graph TD;
+    root["Root"];
+    a_1["Say #quot;hi#quot;<br><i>a=1, b=2</i>"];
+    root --> a_1;
+    node_1["No ID"];
+    root -- "x=#quot;y#quot;" --> node_1;
+    style node_1 fill:#ffffff,stroke:#000000,stroke-width:2px;
+`
+	if actual := ToMermaid(testTree()); actual != expect {
+		t.Errorf("expect:\n%s\nactual:\n%s", expect, actual)
+	}
+}
+
+// styledTree has shaped nodes, two of them sharing a named style
+func styledTree() *decision_tree.Node {
+	skip := &decision_tree.NodeStyle{Fill: "#eeeeee", Stroke: "#999999", Class: "skip"}
+	return &decision_tree.Node{
+		ID:    "root",
+		Style: &decision_tree.NodeStyle{Shape: decision_tree.ShapeDiamond},
+		Children: []*decision_tree.Node{
+			{ID: "a", Style: skip},
+			{ID: "b", Style: &decision_tree.NodeStyle{Shape: decision_tree.ShapeStadium, Fill: "#ffffff"}},
+			{ID: "c", Style: skip},
+		},
+	}
+}
+
+func TestToMermaidStyles(t *testing.T) {
+	expect := `graph TD;
+    root{"root"};
+    a["a"];
+    root --> a;
+    b(["b"]);
+    root --> b;
+    style b fill:#ffffff;
+    c["c"];
+    root --> c;
+    classDef skip fill:#eeeeee,stroke:#999999;
+    class a,c skip;
+`
+	if actual := ToMermaid(styledTree()); actual != expect {
+		t.Errorf("expect:\n%s\nactual:\n%s", expect, actual)
+	}
+}
+
+func TestToMermaidWith(t *testing.T) {
+	tree := styledTree()
+	tree.Conditions = map[string]any{"k": "v"}
+	options := MermaidOptions{
+		HideConditions: true,
+		Shape: func(node *decision_tree.Node, depth int) (string, string) {
+			if depth == 0 {
+				return "(", ")"
+			}
+			return "[", "]"
+		},
+		ClassDefs: map[string]string{"skip": "fill:#eeeeee,stroke-dasharray:5 5"},
+	}
+	expect := `graph TD;
+    root("root");
+    a["a"];
+    root --> a;
+    b["b"];
+    root --> b;
+    style b fill:#ffffff;
+    c["c"];
+    root --> c;
+    classDef skip fill:#eeeeee,stroke-dasharray:5 5;
+    class a,c skip;
+`
+	if actual := ToMermaidWith(tree, options); actual != expect {
+		t.Errorf("expect:\n%s\nactual:\n%s", expect, actual)
+	}
+}
+
+func TestToDOT(t *testing.T) {
+	expect := `digraph tree {
+  node [shape=box];
+  "root" [label="Root"];
+  "a-1" [label="Say \"hi\"\na=1\nb=2"];
+  "root" -> "a-1";
+  "node_1" [label="No ID", style=filled, fillcolor="#ffffff", color="#000000", penwidth=2];
+  "root" -> "node_1" [label="x=\"y\""];
+}
+`
+	if actual := ToDOT(testTree()); actual != expect {
+		t.Errorf("expect:\n%s\nactual:\n%s", expect, actual)
+	}
+}
+
+func TestToDOTShapes(t *testing.T) {
+	dot := ToDOT(styledTree())
+	for _, s := range []string{
+		`"root" [label="root", shape=diamond];`,
+		`"b" [label="b", style="rounded,filled", fillcolor="#ffffff"];`,
+	} {
+		if !strings.Contains(dot, s) {
+			t.Errorf("expect %s in:\n%s", s, dot)
+		}
+	}
+}
+
+func TestToPlantUMLMindmap(t *testing.T) {
+	expect := `@startmindmap
+<style>
+mindmapDiagram {
+  .style_1 {
+    BackgroundColor #ffffff
+    LineColor #000000
+    LineThickness 2
+  }
+}
+</style>
+* Root
+**:Say "hi"
+<i>a=1, b=2</i>;
+**:<color:#666666>x="y"</color>
+No ID; <<style_1>>
+@endmindmap
+`
+	if actual := ToPlantUMLMindmap(testTree()); actual != expect {
+		t.Errorf("expect:\n%s\nactual:\n%s", expect, actual)
+	}
+}
+
+func TestToPlantUMLWBS(t *testing.T) {
+	expect := `@startwbs
+<style>
+wbsDiagram {
+  .skip {
+    BackgroundColor #eeeeee
+    LineColor #999999
+  }
+  .style_1 {
+    BackgroundColor #ffffff
+  }
+}
+</style>
+* root
+** a <<skip>>
+** b <<style_1>>
+** c <<skip>>
+@endwbs
+`
+	if actual := ToPlantUMLWBS(styledTree()); actual != expect {
+		t.Errorf("expect:\n%s\nactual:\n%s", expect, actual)
+	}
+}
+
+func TestToDrawIO(t *testing.T) {
+	var file struct {
+		Cells []struct {
+			ID       string `xml:"id,attr"`
+			Value    string `xml:"value,attr"`
+			Style    string `xml:"style,attr"`
+			Source   string `xml:"source,attr"`
+			Target   string `xml:"target,attr"`
+			Geometry struct {
+				Width  float64 `xml:"width,attr"`
+				Height float64 `xml:"height,attr"`
+			} `xml:"mxGeometry"`
+		} `xml:"diagram>mxGraphModel>root>mxCell"`
+	}
+	if err := xml.Unmarshal([]byte(ToDrawIO(testTree())), &file); err != nil {
+		t.Fatal(err)
+	}
+	// the two cells of the diagram, three nodes and two edges
+	if len(file.Cells) != 7 {
+		t.Fatalf("expect 7 cells, got %d", len(file.Cells))
+	}
+	node := file.Cells[3]
+	if node.ID != "a-1" || node.Value != `Say &#34;hi&#34;<br><i>a=1, b=2</i>` || node.Geometry.Width <= 0 || node.Geometry.Height <= 0 {
+		t.Errorf("unexpected node: %+v", node)
+	}
+	edge := file.Cells[6]
+	if edge.Source != "root" || edge.Target != "node_1" || edge.Value != `x=&#34;y&#34;` {
+		t.Errorf("unexpected edge: %+v", edge)
+	}
+	if !strings.Contains(file.Cells[5].Style, "fillColor=#ffffff;strokeColor=#000000;strokeWidth=2;") {
+		t.Errorf("unexpected style: %s", file.Cells[5].Style)
+	}
+}
+
+func TestExport(t *testing.T) {
+	for _, format := range Formats {
+		data, err := Export(testTree(), format)
+		if err != nil {
+			t.Errorf("%s: %v", format, err)
+			continue
+		}
+		if len(data) == 0 {
+			t.Errorf("%s: expect output", format)
+		}
+	}
+
+	data, err := Export(testTree(), FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var node *decision_tree.Node
+	if err := json.Unmarshal(data, &node); err != nil {
+		t.Fatal(err)
+	}
+	if len(node.Children) != 2 || node.Children[0].ID != "a-1" {
+		t.Errorf("unexpected json round trip: %s", data)
+	}
+
+	if _, err := Export(testTree(), "png"); err == nil {
+		t.Errorf("expect error for unsupported format")
+	}
+}
@@ -0,0 +1,117 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+// ToPlantUMLMindmap generates a PlantUML mindmap of the tree
+func ToPlantUMLMindmap(root *decision_tree.Node) string {
+	return toPlantUML(root, "mindmap", "mindmapDiagram")
+}
+
+// ToPlantUMLWBS generates a PlantUML work breakdown structure of the tree
+func ToPlantUMLWBS(root *decision_tree.Node) string {
+	return toPlantUML(root, "wbs", "wbsDiagram")
+}
+
+// toPlantUML writes the tree as the nested '*' list mindmaps and WBS
+// share. Neither has edge labels, so the label of the edge from the
+// parent is the first line of a node, followed by its label and its
+// conditions. Styles become stereotypes, named by their Class.
+func toPlantUML(root *decision_tree.Node, diagram string, styleSelector string) string {
+	var body strings.Builder
+	styles := newPlantUMLStyles()
+	var visit func(node *decision_tree.Node, depth int)
+	visit = func(node *decision_tree.Node, depth int) {
+		var lines []string
+		if node.EdgeLabel != "" {
+			lines = append(lines, "<color:#666666>"+node.EdgeLabel+"</color>")
+		}
+		lines = append(lines, strings.Split(node.DisplayLabel(), "\n")...)
+		if conditions := decision_tree.FormatConditions(node.Conditions); len(conditions) > 0 {
+			lines = append(lines, "<i>"+strings.Join(conditions, ", ")+"</i>")
+		}
+		body.WriteString(strings.Repeat("*", depth))
+		if len(lines) == 1 {
+			body.WriteString(" " + lines[0])
+		} else {
+			body.WriteString(":" + strings.Join(lines, "\n") + ";")
+		}
+		if name := styles.get(node.Style); name != "" {
+			fmt.Fprintf(&body, " <<%s>>", name)
+		}
+		body.WriteString("\n")
+		for _, child := range node.Children {
+			visit(child, depth+1)
+		}
+	}
+	if root != nil {
+		visit(root, 1)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "@start%s\n", diagram)
+	if len(styles.names) > 0 {
+		fmt.Fprintf(&sb, "<style>\n%s {\n", styleSelector)
+		for _, name := range styles.names {
+			fmt.Fprintf(&sb, "  .%s {\n", name)
+			for _, property := range styles.properties[name] {
+				fmt.Fprintf(&sb, "    %s\n", property)
+			}
+			sb.WriteString("  }\n")
+		}
+		sb.WriteString("}\n</style>\n")
+	}
+	sb.WriteString(body.String())
+	fmt.Fprintf(&sb, "@end%s\n", diagram)
+	return sb.String()
+}
+
+// plantUMLStyles names the distinct styles of a tree, by their
+// Class if set, otherwise style_1, style_2 and so on
+type plantUMLStyles struct {
+	names      []string
+	properties map[string][]string
+	byValue    map[string]string
+}
+
+func newPlantUMLStyles() *plantUMLStyles {
+	return &plantUMLStyles{properties: make(map[string][]string), byValue: make(map[string]string)}
+}
+
+// get returns the stereotype of the style, empty if it sets no property
+func (s *plantUMLStyles) get(style *decision_tree.NodeStyle) string {
+	if style == nil {
+		return ""
+	}
+	var properties []string
+	if isPlainColor(style.Fill) {
+		properties = append(properties, "BackgroundColor "+style.Fill)
+	}
+	if isPlainColor(style.Stroke) {
+		properties = append(properties, "LineColor "+style.Stroke)
+	}
+	if style.StrokeWidth > 0 {
+		properties = append(properties, fmt.Sprintf("LineThickness %d", style.StrokeWidth))
+	}
+	if len(properties) == 0 {
+		return ""
+	}
+	name := mermaidID(style.Class)
+	if name == "" {
+		key := strings.Join(properties, ";")
+		if name = s.byValue[key]; name != "" {
+			return name
+		}
+		name = fmt.Sprintf("style_%d", len(s.byValue)+1)
+		s.byValue[key] = name
+	}
+	if _, ok := s.properties[name]; !ok {
+		s.names = append(s.names, name)
+		s.properties[name] = properties
+	}
+	return name
+}
@@ -0,0 +1,259 @@
+package layout
+
+import (
+	"math"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+// Point is a position in layout coordinates
+type Point struct {
+	X, Y float64
+}
+
+// Rect is an axis aligned rectangle, X and Y are its top left corner
+type Rect struct {
+	X, Y, Width, Height float64
+}
+
+// Overlaps tells whether the rectangles share some area
+func (a Rect) Overlaps(b Rect) bool {
+	return a.X < b.X+b.Width && b.X < a.X+a.Width && a.Y < b.Y+b.Height && b.Y < a.Y+a.Height
+}
+
+// Curve is a cubic curve from Start to End, straight
+// curves are not Curved and ignore the controls
+type Curve struct {
+	Start, Control1, Control2, End Point
+	Curved                         bool
+}
+
+// At returns the point at t along the curve, from 0 at the start to 1 at the end
+func (c Curve) At(t float64) Point {
+	if !c.Curved {
+		return Point{c.Start.X + (c.End.X-c.Start.X)*t, c.Start.Y + (c.End.Y-c.Start.Y)*t}
+	}
+	u := 1 - t
+	a, b, cc, d := u*u*u, 3*u*u*t, 3*u*t*t, t*t*t
+	return Point{
+		a*c.Start.X + b*c.Control1.X + cc*c.Control2.X + d*c.End.X,
+		a*c.Start.Y + b*c.Control1.Y + cc*c.Control2.Y + d*c.End.Y,
+	}
+}
+
+// EndDirection returns the direction the curve arrives at its end in,
+// arrow heads point along it
+func (c Curve) EndDirection() Point {
+	from := c.Start
+	if c.Curved && c.Control2 != c.End {
+		from = c.Control2
+	}
+	return Point{c.End.X - from.X, c.End.Y - from.Y}
+}
+
+// Edge returns the curve connecting parent to child. Edges attach to the
+// middle of the facing sides of nodes, which every shape touches, radial
+// edges run along the line of the centers.
+func (e *Engine) Edge(parent, child *LayoutNode) Curve {
+	switch e.config.Orientation {
+	case decision_tree.LeftRight:
+		// Curve from the right side of the parent to the left side of the child
+		start := Point{parent.X + parent.Width/2, parent.Y + parent.Height/2}
+		end := Point{child.X - child.Width/2, child.Y + child.Height/2}
+		midX := (start.X + end.X) / 2
+		return Curve{Start: start, Control1: Point{midX, start.Y}, Control2: Point{midX, end.Y}, End: end, Curved: true}
+	case decision_tree.Radial:
+		// Straight line between the borders, along the line of the centers
+		parentCenter := Point{parent.X, parent.Y + parent.Height/2}
+		childCenter := Point{child.X, child.Y + child.Height/2}
+		return Curve{Start: BorderPoint(parent, childCenter), End: BorderPoint(child, parentCenter)}
+	}
+	// Straight edge from the bottom of the parent to the top of the child
+	return Curve{Start: Point{parent.X, parent.Y + parent.Height}, End: Point{child.X, child.Y}}
+}
+
+// HexagonInset is how far the slanted sides of a hexagon reach into its box
+func HexagonInset(node *LayoutNode) float64 {
+	return math.Min(node.Height/4, node.Width/2)
+}
+
+// BorderPoint returns where the line from the center of node
+// towards p leaves the node's shape
+func BorderPoint(node *LayoutNode, p Point) Point {
+	center := Point{node.X, node.Y + node.Height/2}
+	dx, dy := p.X-center.X, p.Y-center.Y
+	if dx == 0 && dy == 0 {
+		return center
+	}
+	// the border point is center + (dx, dy)*scale
+	a, b := node.Width/2, node.Height/2
+	adx, ady := math.Abs(dx), math.Abs(dy)
+	scale := rectScale(a, b, adx, ady)
+	shape := ""
+	if node.Style != nil {
+		shape = node.Style.Shape
+	}
+	switch shape {
+	case decision_tree.ShapeDiamond:
+		scale = 1 / (adx/a + ady/b)
+	case decision_tree.ShapeEllipse:
+		scale = 1 / math.Sqrt(adx*adx/(a*a)+ady*ady/(b*b))
+	case decision_tree.ShapeHexagon:
+		inset := HexagonInset(node)
+		if adx*scale > a-inset {
+			// the ray leaves through the slanted side from (a-inset, b)
+			// to (a, 0), where x*b + y*inset = a*b
+			scale = a * b / (adx*b + ady*inset)
+		}
+	case decision_tree.ShapeStadium:
+		r := math.Min(b, a)
+		if adx*scale > a-r {
+			// the ray leaves through a cap, a circle of radius
+			// r centered at (a-r, 0): |s*(adx,ady) - (a-r, 0)| = r
+			c := a - r
+			qa := adx*adx + ady*ady
+			qb := -2 * adx * c
+			qc := c*c - r*r
+			scale = (-qb + math.Sqrt(qb*qb-4*qa*qc)) / (2 * qa)
+		}
+	}
+	return Point{center.X + dx*scale, center.Y + dy*scale}
+}
+
+// rectScale returns the scale of (dx, dy) reaching the border of
+// the rectangle spanning -a..a and -b..b, dx and dy not negative
+func rectScale(a, b, dx, dy float64) float64 {
+	scale := math.Inf(1)
+	if dx != 0 {
+		scale = math.Min(scale, a/dx)
+	}
+	if dy != 0 {
+		scale = math.Min(scale, b/dy)
+	}
+	return scale
+}
+
+// Bounds returns the box covering the laid out nodes, including the
+// conditions drawn above parents and below leaves
+func (e *Engine) Bounds(root *LayoutNode) Rect {
+	conditionLineHeight := NewTextStyle(e.config).ConditionLineHeight
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	walkLayout(root, func(node *LayoutNode) {
+		top, bottom := node.Y, node.Y+node.Height
+		if len(node.ConditionLines) > 0 {
+			condHeight := float64(len(node.ConditionLines)) * conditionLineHeight
+			if len(node.Children) == 0 {
+				// For terminal nodes, conditions are below
+				bottom += condHeight + 6
+			} else {
+				// For non-terminal nodes, conditions are above
+				top -= condHeight + 3
+			}
+		}
+		minX = math.Min(minX, node.X-node.Width/2)
+		maxX = math.Max(maxX, node.X+node.Width/2)
+		minY = math.Min(minY, top)
+		maxY = math.Max(maxY, bottom)
+	})
+	if root == nil {
+		return Rect{}
+	}
+	return Rect{minX, minY, maxX - minX, maxY - minY}
+}
+
+// EdgeLabel is the placed label of the edge from Parent to Child
+type EdgeLabel struct {
+	Parent, Child *LayoutNode
+	Box           Rect // background of the label, lines are centered in it
+}
+
+// edgeLabelPositions are the positions tried along an edge for its
+// label, the middle first, then alternating towards both ends
+var edgeLabelPositions = func() []float64 {
+	positions := []float64{0.5}
+	for d := 0.05; d < 0.45; d += 0.05 {
+		positions = append(positions, 0.5-d, 0.5+d)
+	}
+	return positions
+}()
+
+// PlaceEdgeLabels places the labels of edges, each at the first
+// position along its edge not covering a node or another label
+func (e *Engine) PlaceEdgeLabels(root *LayoutNode) []EdgeLabel {
+	obstacles := newRectGrid(e.config.BaseNodeWidth)
+	var labels []EdgeLabel
+	walkLayout(root, func(node *LayoutNode) {
+		obstacles.add(Rect{node.X - node.Width/2, node.Y, node.Width, node.Height})
+		for _, child := range node.Children {
+			if len(child.EdgeLabelLines) > 0 {
+				labels = append(labels, EdgeLabel{Parent: node, Child: child})
+			}
+		}
+	})
+
+	text := NewTextStyle(e.config)
+	for i := range labels {
+		label := &labels[i]
+		width, height := text.EdgeLabelSize(label.Child.EdgeLabelLines)
+		edge := e.Edge(label.Parent, label.Child)
+		for j, t := range edgeLabelPositions {
+			p := edge.At(t)
+			candidate := Rect{p.X - width/2, p.Y - height/2, width, height}
+			free := !obstacles.overlaps(candidate)
+			// fall back to the middle if every position is taken
+			if free || j == 0 {
+				label.Box = candidate
+			}
+			if free {
+				break
+			}
+		}
+		obstacles.add(label.Box)
+	}
+	return labels
+}
+
+// rectGrid indexes rectangles by the cells of a grid they cover,
+// so overlap queries only test the rectangles nearby
+type rectGrid struct {
+	size  float64
+	cells map[[2]int][]Rect
+}
+
+func newRectGrid(size float64) *rectGrid {
+	if size <= 0 {
+		size = 100
+	}
+	return &rectGrid{size: size, cells: make(map[[2]int][]Rect)}
+}
+
+// cellRange returns the first and last cells covered by r
+func (g *rectGrid) cellRange(r Rect) (x0, y0, x1, y1 int) {
+	return int(math.Floor(r.X / g.size)), int(math.Floor(r.Y / g.size)),
+		int(math.Floor((r.X + r.Width) / g.size)), int(math.Floor((r.Y + r.Height) / g.size))
+}
+
+func (g *rectGrid) add(r Rect) {
+	x0, y0, x1, y1 := g.cellRange(r)
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			g.cells[[2]int{x, y}] = append(g.cells[[2]int{x, y}], r)
+		}
+	}
+}
+
+// overlaps tells whether r overlaps any added rectangle
+func (g *rectGrid) overlaps(r Rect) bool {
+	x0, y0, x1, y1 := g.cellRange(r)
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			for _, other := range g.cells[[2]int{x, y}] {
+				if r.Overlaps(other) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
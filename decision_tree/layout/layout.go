@@ -2,8 +2,10 @@ package layout
 
 import (
 	"math"
+	"strings"
 
 	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/metrics"
 )
 
 // LayoutNode represents a node with layout information
@@ -15,10 +17,14 @@ type LayoutNode struct {
 	Level     int     // Tree depth level
 	LeafCount int     // Number of leaf nodes under this node
 	Parent    *LayoutNode
-	Children  []*LayoutNode // Layout-specific children
-	Style     *decision_tree.NodeStyle
-	IsLeaf    bool // Whether this is a leaf node
-	LeafIndex int  // Sequential index for leaf nodes
+	Children  []*LayoutNode            // Layout-specific children
+	Style     *decision_tree.NodeStyle // Resolved style, see Config.NodeStyle
+	IsLeaf    bool                     // Whether this is a leaf node
+	LeafIndex int                      // Sequential index for leaf nodes
+
+	LabelLines     []string // Label wrapped to the configured width
+	ConditionLines []string // Conditions wrapped to the configured width
+	EdgeLabelLines []string // Label of the edge from the parent, wrapped to the configured width
 }
 
 // LevelInfo holds information about nodes at a specific level
@@ -33,6 +39,11 @@ type Engine struct {
 	config       *decision_tree.Config
 	centerParent bool
 	leafNodes    []*LayoutNode // All leaf nodes in left-to-right order
+
+	// text measurement, resolved from config for each layout
+	textStyle        TextStyle
+	labelMetrics     *metrics.Metrics
+	conditionMetrics *metrics.Metrics
 }
 
 // NewEngine creates a new layout engine
@@ -54,7 +65,13 @@ func (e *Engine) CalculateLayout(root *decision_tree.Node) *LayoutNode {
 		return nil
 	}
 
+	// Large trees are laid out collapsed, see Config.MaxNodes
+	root = root.Collapse(e.config.MaxNodes)
+
 	e.leafNodes = nil // Reset leaf nodes
+	e.textStyle = NewTextStyle(e.config)
+	e.labelMetrics = e.textStyle.LabelMetrics()
+	e.conditionMetrics = e.textStyle.ConditionMetrics()
 
 	// Create initial layout tree
 	layoutRoot := e.createBasicLayoutTree(root, nil, 0)
@@ -69,7 +86,15 @@ func (e *Engine) CalculateLayout(root *decision_tree.Node) *LayoutNode {
 	e.calculateDimensions(levels)
 
 	// Assign coordinates with sequential leaf distribution
-	e.assignCoordinatesWithLeafOrder(levels)
+	switch e.config.Orientation {
+	case decision_tree.LeftRight:
+		e.assignLeftRight(layoutRoot, levels)
+	case decision_tree.Radial:
+		e.assignCoordinates(layoutRoot, levels, e.config.ParentChildSpacing)
+		e.assignRadial(layoutRoot, levels)
+	default:
+		e.assignCoordinates(layoutRoot, levels, e.config.ParentChildSpacing)
+	}
 
 	return layoutRoot
 }
@@ -84,7 +109,7 @@ func (e *Engine) createBasicLayoutTree(node *decision_tree.Node, parent *LayoutN
 		Node:   node,
 		Level:  level,
 		Parent: parent,
-		Style:  node.Style,
+		Style:  e.config.NodeStyle(node),
 	}
 
 	// Create layout nodes for children
@@ -155,28 +180,66 @@ func (e *Engine) calculateDimensions(levels map[int]*LevelInfo) {
 
 // calculateNodeDimensions calculates dimensions for a single node
 func (e *Engine) calculateNodeDimensions(node *LayoutNode) {
-	const maxChars = 20
+	style := e.textStyle
+	label := e.labelMetrics
+	condition := e.conditionMetrics
+
+	node.LabelLines = label.Wrap(node.Node.DisplayLabel(), style.MaxLabelWidth)
+	node.ConditionLines = nil
+	if len(node.Node.Conditions) > 0 {
+		node.ConditionLines = condition.Wrap(strings.Join(decision_tree.FormatConditions(node.Node.Conditions), ", "), style.MaxLabelWidth)
+	}
+	node.EdgeLabelLines = nil
+	if node.Node.EdgeLabel != "" {
+		node.EdgeLabelLines = condition.Wrap(node.Node.EdgeLabel, style.MaxLabelWidth)
+	}
 
 	// Base height
 	height := 40.0
 
 	// Add space for conditions
-	if len(node.Node.Conditions) > 0 {
-		conditionLines := (len(node.Node.Conditions) + 1) / 2
-		height += float64(conditionLines) * 15
-	}
+	height += float64(len(node.ConditionLines)) * style.LineHeight
 
 	// Add height for wrapped label
-	labelLines := (len(node.Node.Label) + maxChars - 1) / maxChars
-	if labelLines > 1 {
-		height += float64(labelLines-1) * 15
+	if len(node.LabelLines) > 1 {
+		height += float64(len(node.LabelLines)-1) * style.LineHeight
 	}
 
-	node.Height = height
+	// Fit the shape to the label, and the widest line of the
+	// conditions, which are drawn centered on the node too
+	labelWidth := 0.0
+	for _, line := range node.LabelLines {
+		labelWidth = math.Max(labelWidth, label.Width(line))
+	}
+	conditionWidth := 0.0
+	for _, line := range node.ConditionLines {
+		conditionWidth = math.Max(conditionWidth, condition.Width(line))
+	}
+	labelHeight := float64(len(node.LabelLines)) * style.LineHeight
+	node.Width, node.Height = e.fitShape(node.Style.Shape, labelWidth, labelHeight, height)
+	node.Width = math.Max(node.Width, conditionWidth+e.config.NodePadding*2)
+}
 
-	// Calculate width with more space
-	labelWidth := math.Min(float64(len(node.Node.Label))*9, float64(maxChars)*9)
-	node.Width = math.Max(labelWidth+e.config.NodePadding*2, e.config.BaseNodeWidth)
+// fitShape returns the size of a shape enclosing text of the given size,
+// padded, and at least as large as a base node of the given height
+func (e *Engine) fitShape(shape string, textWidth, textHeight, height float64) (float64, float64) {
+	padding := e.config.NodePadding
+	width := textWidth + padding*2
+	switch shape {
+	case decision_tree.ShapeDiamond:
+		// text fits the diamond where x/(width/2) + y/(height/2) <= 1,
+		// spend a third of the height and two thirds of the width on it
+		width = textWidth*1.5 + padding*2
+		height = math.Max(height, textHeight*3+padding)
+	case decision_tree.ShapeEllipse:
+		// the corners of the text lie on the ellipse scaled by sqrt(2)
+		width = textWidth*math.Sqrt2 + padding*2
+		height = math.Max(height, textHeight*math.Sqrt2+padding)
+	case decision_tree.ShapeHexagon, decision_tree.ShapeStadium:
+		// the pointed or rounded ends add a quarter of the height on each side
+		width += height / 2
+	}
+	return math.Max(width, e.config.BaseNodeWidth), height
 }
 
 // calculateLevelWidth calculates total width required for a level
@@ -213,8 +276,18 @@ func (e *Engine) collectLeafNodes(node *LayoutNode) {
 	}
 }
 
-// assignCoordinatesWithLeafOrder assigns coordinates with sequential leaf distribution
-func (e *Engine) assignCoordinatesWithLeafOrder(levels map[int]*LevelInfo) {
+// assignCoordinates assigns top-down coordinates with the configured algorithm
+func (e *Engine) assignCoordinates(root *LayoutNode, levels map[int]*LevelInfo, minLevelSpacing float64) {
+	if e.config.Algorithm == decision_tree.Tidy {
+		e.assignTidy(root, levels, minLevelSpacing)
+		return
+	}
+	e.assignCoordinatesWithLeafOrder(levels, minLevelSpacing)
+}
+
+// assignCoordinatesWithLeafOrder assigns coordinates with sequential leaf distribution,
+// levels are separated by at least minLevelSpacing
+func (e *Engine) assignCoordinatesWithLeafOrder(levels map[int]*LevelInfo, minLevelSpacing float64) {
 	startX := 50.0
 	startY := 50.0
 	maxLevel := 0
@@ -224,10 +297,6 @@ func (e *Engine) assignCoordinatesWithLeafOrder(levels map[int]*LevelInfo) {
 		}
 	}
 
-	// Calculate Y coordinates for each level first
-	levelY := make(map[int]float64)
-	levelY[0] = startY
-
 	// First pass: position leaf nodes sequentially to calculate their positions
 	leafSpacing := e.config.LeafNodeSpacing
 	if leafSpacing == 0 {
@@ -257,6 +326,123 @@ func (e *Engine) assignCoordinatesWithLeafOrder(levels map[int]*LevelInfo) {
 	}
 
 	// Third pass: calculate Y coordinates with dynamic spacing
+	levelY := e.levelPositions(levels, maxLevel, startY, minLevelSpacing)
+
+	// Final pass: set Y coordinates and adjust horizontal spacing
+	for level := 0; level <= maxLevel; level++ {
+		info, exists := levels[level]
+		if !exists {
+			continue
+		}
+
+		// Set Y coordinates for all nodes at this level
+		for _, node := range info.nodes {
+			node.Y = levelY[level]
+		}
+
+		// Adjust horizontal spacing
+		nodes := info.nodes
+		for i := 1; i < len(nodes); i++ {
+			prev := nodes[i-1]
+			curr := nodes[i]
+			minSpacing := (prev.Width+curr.Width)/2 + e.config.NodeSpacing
+			if curr.X-prev.X < minSpacing {
+				shift := minSpacing - (curr.X - prev.X)
+				e.shiftSubtree(curr, shift)
+			}
+		}
+	}
+}
+
+func (e *Engine) shiftSubtree(node *LayoutNode, shift float64) {
+	if node == nil {
+		return
+	}
+	node.X += shift
+	for _, child := range node.Children {
+		e.shiftSubtree(child, shift)
+	}
+}
+
+// assignLeftRight lays out levels from left to right by running the
+// top-down layout on transposed nodes, so leaves keep their sequential
+// order, now from top to bottom
+func (e *Engine) assignLeftRight(root *LayoutNode, levels map[int]*LevelInfo) {
+	transpose := func(node *LayoutNode) {
+		node.Width, node.Height = node.Height, node.Width
+	}
+	walkLayout(root, transpose)
+
+	// edges leave nodes sideways and need room to bend
+	e.assignCoordinates(root, levels, math.Max(e.config.ParentChildSpacing, 2*e.config.NodeSpacing))
+
+	walkLayout(root, func(node *LayoutNode) {
+		transpose(node)
+		breadthCenter, depthTop := node.X, node.Y
+		node.X = depthTop + node.Width/2
+		node.Y = breadthCenter - node.Height/2
+	})
+}
+
+// assignRadial maps the top-down layout onto rings around the root:
+// the horizontal position becomes the angle, so leaves keep their
+// sequential order clockwise, and the level selects the ring
+func (e *Engine) assignRadial(root *LayoutNode, levels map[int]*LevelInfo) {
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	walkLayout(root, func(node *LayoutNode) {
+		minX = math.Min(minX, node.X-node.Width/2)
+		maxX = math.Max(maxX, node.X+node.Width/2)
+	})
+	leafSpacing := e.config.LeafNodeSpacing
+	if leafSpacing == 0 {
+		leafSpacing = e.config.NodeSpacing * 1.5
+	}
+	// leave a gap between the last and the first leaf
+	circumference := maxX - minX + leafSpacing
+
+	// rings are at least as long as the top-down breadth, so nodes of
+	// a level are no closer than they are top-down, and are separated
+	// from the previous ring by the extent of their nodes
+	extent := make(map[int]float64, len(levels))
+	for level, info := range levels {
+		for _, node := range info.nodes {
+			extent[level] = math.Max(extent[level], math.Hypot(node.Width, node.Height)/2)
+		}
+	}
+	radius := make(map[int]float64, len(levels))
+	for level := 1; level < len(levels); level++ {
+		r := radius[level-1] + extent[level-1] + extent[level] + math.Max(e.config.NodeSpacing, e.edgeLabelSpacing(levels[level].nodes))
+		if level == 1 {
+			r = math.Max(r, circumference/(2*math.Pi))
+		}
+		radius[level] = r
+	}
+
+	walkLayout(root, func(node *LayoutNode) {
+		angle := 2*math.Pi*(node.X-minX)/circumference - math.Pi/2
+		r := radius[node.Level]
+		node.X = r * math.Cos(angle)
+		node.Y = r*math.Sin(angle) - node.Height/2
+	})
+}
+
+// walkLayout calls fn for node and its descendants in pre-order
+func walkLayout(node *LayoutNode, fn func(node *LayoutNode)) {
+	if node == nil {
+		return
+	}
+	fn(node)
+	for _, child := range node.Children {
+		walkLayout(child, fn)
+	}
+}
+
+// levelPositions calculates the Y coordinate of each level, a level starts below
+// the tallest node of the previous level, separated by at least minLevelSpacing
+// and more if the previous level's nodes span their children widely
+func (e *Engine) levelPositions(levels map[int]*LevelInfo, maxLevel int, startY float64, minLevelSpacing float64) map[int]float64 {
+	levelY := make(map[int]float64)
+	levelY[0] = startY
 	for level := 1; level <= maxLevel; level++ {
 		prevLevelMaxHeight := 0.0
 		if prevInfo, exists := levels[level-1]; exists {
@@ -268,7 +454,7 @@ func (e *Engine) assignCoordinatesWithLeafOrder(levels map[int]*LevelInfo) {
 		}
 
 		// Calculate spacing based on parent nodes' children span
-		maxSpacing := e.config.ParentChildSpacing // minimum spacing
+		maxSpacing := minLevelSpacing // minimum spacing
 		if prevInfo, exists := levels[level-1]; exists {
 			for _, node := range prevInfo.nodes {
 				if len(node.Children) > 0 {
@@ -286,42 +472,73 @@ func (e *Engine) assignCoordinatesWithLeafOrder(levels map[int]*LevelInfo) {
 			}
 		}
 
+		// Leave room for labels on edges into this level
+		if info, exists := levels[level]; exists {
+			maxSpacing = math.Max(maxSpacing, e.edgeLabelSpacing(info.nodes))
+		}
+
 		// Apply the spacing
 		levelY[level] = levelY[level-1] + prevLevelMaxHeight + maxSpacing
 	}
+	return levelY
+}
 
-	// Final pass: set Y coordinates and adjust horizontal spacing
-	for level := 0; level <= maxLevel; level++ {
-		info, exists := levels[level]
-		if !exists {
+// edgeLabelMargin is the space kept around edge labels, so that
+// a part of the edge stays visible on both sides
+const edgeLabelMargin = 16
+
+// edgeLabelSpacing returns the space between levels needed to place the
+// labels of edges into nodes. Labels are placed around the middle of
+// edges: where labels of sibling edges would overlap there, they are
+// staggered in rows along the edges, each row taking more space.
+func (e *Engine) edgeLabelSpacing(nodes []*LayoutNode) float64 {
+	spacing := 0.0
+	var parents []*LayoutNode
+	children := make(map[*LayoutNode][]*LayoutNode)
+	for _, node := range nodes {
+		if len(node.EdgeLabelLines) == 0 || node.Parent == nil {
 			continue
 		}
-
-		// Set Y coordinates for all nodes at this level
-		for _, node := range info.nodes {
-			node.Y = levelY[level]
+		if children[node.Parent] == nil {
+			parents = append(parents, node.Parent)
 		}
-
-		// Adjust horizontal spacing
-		nodes := info.nodes
-		for i := 1; i < len(nodes); i++ {
-			prev := nodes[i-1]
-			curr := nodes[i]
-			minSpacing := (prev.Width+curr.Width)/2 + e.config.NodeSpacing
-			if curr.X-prev.X < minSpacing {
-				shift := minSpacing - (curr.X - prev.X)
-				e.shiftSubtree(curr, shift)
+		children[node.Parent] = append(children[node.Parent], node)
+	}
+	for _, parent := range parents {
+		var rowEnds []float64
+		depth := 0.0
+		for _, child := range children[parent] {
+			width, height := e.textStyle.EdgeLabelSize(child.EdgeLabelLines)
+			if e.config.Orientation == decision_tree.Radial {
+				spacing = math.Max(spacing, math.Max(width, height)+edgeLabelMargin)
+				continue
+			}
+			// the top-down pass of LeftRight runs on transposed nodes
+			breadth := width
+			if e.config.Orientation == decision_tree.LeftRight {
+				breadth, height = height, width
+			}
+			depth = math.Max(depth, height)
+
+			// children are ordered, place each label in the
+			// first row it does not overlap the last label of
+			mid := (parent.X + child.X) / 2
+			start := mid - breadth/2
+			placed := false
+			for i, end := range rowEnds {
+				if end <= start {
+					rowEnds[i] = start + breadth
+					placed = true
+					break
+				}
+			}
+			if !placed {
+				rowEnds = append(rowEnds, start+breadth)
 			}
 		}
+		// rows are searched for from the middle of edges, give each
+		// some slack so they need not be packed exactly
+		spacing = math.Max(spacing, float64(len(rowEnds))*(depth+edgeLabelMargin/2)+edgeLabelMargin/2)
 	}
-}
-
-func (e *Engine) shiftSubtree(node *LayoutNode, shift float64) {
-	if node == nil {
-		return
-	}
-	node.X += shift
-	for _, child := range node.Children {
-		e.shiftSubtree(child, shift)
-	}
+	return spacing
 }
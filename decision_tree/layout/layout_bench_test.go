@@ -0,0 +1,87 @@
+package layout
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+// benchTree builds a tree of about n nodes, each parent having
+// fanout children, with labels, conditions and edge labels like
+// the trees of real test suites
+func benchTree(n int, fanout int) *decision_tree.Node {
+	count := 0
+	newNode := func() *decision_tree.Node {
+		count++
+		return &decision_tree.Node{
+			ID:         fmt.Sprintf("node_%d", count),
+			Label:      fmt.Sprintf("Case %d of the scenario", count),
+			Conditions: map[string]any{"user_state": count % 5, "tags": []string{"happy_flow"}},
+			EdgeLabel:  fmt.Sprintf("branch=%d", count%fanout),
+		}
+	}
+	root := newNode()
+	queue := []*decision_tree.Node{root}
+	for len(queue) > 0 && count < n {
+		parent := queue[0]
+		queue = queue[1:]
+		for i := 0; i < fanout && count < n; i++ {
+			child := newNode()
+			parent.Children = append(parent.Children, child)
+			queue = append(queue, child)
+		}
+	}
+	return root
+}
+
+var benchSizes = []int{100, 1000, 3000}
+
+func BenchmarkCalculateLayout(b *testing.B) {
+	configs := []struct {
+		name   string
+		config func(config *decision_tree.Config)
+	}{
+		{"leaf-order", func(config *decision_tree.Config) {}},
+		{"tidy", func(config *decision_tree.Config) { config.Algorithm = decision_tree.Tidy }},
+		{"left-right", func(config *decision_tree.Config) { config.Orientation = decision_tree.LeftRight }},
+		{"radial", func(config *decision_tree.Config) { config.Orientation = decision_tree.Radial }},
+		{"max-nodes-500", func(config *decision_tree.Config) { config.MaxNodes = 500 }},
+	}
+	for _, c := range configs {
+		for _, n := range benchSizes {
+			b.Run(fmt.Sprintf("%s/%d", c.name, n), func(b *testing.B) {
+				config := decision_tree.DefaultConfig()
+				c.config(config)
+				engine := NewEngine(config)
+				root := benchTree(n, 4)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					engine.CalculateLayout(root)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkPlaceEdgeLabels(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			engine := NewEngine(decision_tree.DefaultConfig())
+			layoutRoot := engine.CalculateLayout(benchTree(n, 4))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				engine.PlaceEdgeLabels(layoutRoot)
+			}
+		})
+	}
+}
+
+func BenchmarkBounds(b *testing.B) {
+	engine := NewEngine(decision_tree.DefaultConfig())
+	layoutRoot := engine.CalculateLayout(benchTree(3000, 4))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		engine.Bounds(layoutRoot)
+	}
+}
@@ -0,0 +1,281 @@
+package layout
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+func loadTestTree(t *testing.T, file string) *decision_tree.Node {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root *decision_tree.Node
+	if err := json.Unmarshal(data, &root); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// flattenLayout lists nodes in pre-order
+func flattenLayout(root *LayoutNode) []*LayoutNode {
+	var nodes []*LayoutNode
+	walkLayout(root, func(node *LayoutNode) {
+		nodes = append(nodes, node)
+	})
+	return nodes
+}
+
+func overlaps(a, b *LayoutNode) bool {
+	return a.X-a.Width/2 < b.X+b.Width/2 && b.X-b.Width/2 < a.X+a.Width/2 &&
+		a.Y < b.Y+b.Height && b.Y < a.Y+a.Height
+}
+
+func checkNoOverlaps(t *testing.T, nodes []*LayoutNode) {
+	t.Helper()
+	for i, a := range nodes {
+		for _, b := range nodes[i+1:] {
+			if overlaps(a, b) {
+				t.Errorf("%s overlaps %s", a.Node.ID, b.Node.ID)
+			}
+		}
+	}
+}
+
+func TestOrientation(t *testing.T) {
+	root := loadTestTree(t, "../testdata/tree.json")
+	for _, orientation := range []decision_tree.Orientation{decision_tree.TopDown, decision_tree.LeftRight, decision_tree.Radial} {
+		t.Run(orientation.String(), func(t *testing.T) {
+			config := decision_tree.DefaultConfig()
+			config.Orientation = orientation
+			nodes := flattenLayout(NewEngine(config).CalculateLayout(root))
+			checkNoOverlaps(t, nodes)
+
+			var leaves []*LayoutNode
+			for _, node := range nodes {
+				if node.IsLeaf {
+					leaves = append(leaves, node)
+				}
+			}
+			for i := 1; i < len(leaves); i++ {
+				prev, leaf := leaves[i-1], leaves[i]
+				var inOrder bool
+				switch orientation {
+				case decision_tree.TopDown:
+					inOrder = prev.X < leaf.X
+				case decision_tree.LeftRight:
+					inOrder = prev.Y < leaf.Y
+				case decision_tree.Radial:
+					inOrder = angle(prev) < angle(leaf)
+				}
+				if !inOrder {
+					t.Errorf("leaf %s is not placed after %s", leaf.Node.ID, prev.Node.ID)
+				}
+			}
+
+			// children grow away from their parent
+			for _, node := range nodes {
+				for _, child := range node.Children {
+					switch orientation {
+					case decision_tree.TopDown:
+						if child.Y <= node.Y+node.Height {
+							t.Errorf("%s is not below %s", child.Node.ID, node.Node.ID)
+						}
+					case decision_tree.LeftRight:
+						if child.X-child.Width/2 <= node.X+node.Width/2 {
+							t.Errorf("%s is not right of %s", child.Node.ID, node.Node.ID)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// angle returns the clockwise angle of the node's center from the top
+func angle(node *LayoutNode) float64 {
+	a := math.Atan2(node.Y+node.Height/2, node.X) + math.Pi/2
+	if a < 0 {
+		a += 2 * math.Pi
+	}
+	return a
+}
+
+// treeWidth returns the horizontal extent of the layout
+func treeWidth(nodes []*LayoutNode) float64 {
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	for _, node := range nodes {
+		minX = math.Min(minX, node.X-node.Width/2)
+		maxX = math.Max(maxX, node.X+node.Width/2)
+	}
+	return maxX - minX
+}
+
+// asymmetricTree has a deep narrow branch next to a wide shallow one,
+// the case where leaf slots waste the most space
+func asymmetricTree() *decision_tree.Node {
+	deep := &decision_tree.Node{ID: "deep", Label: "Deep"}
+	node := deep
+	for i := 0; i < 4; i++ {
+		child := &decision_tree.Node{ID: fmt.Sprintf("deep_%d", i), Label: fmt.Sprintf("Deep %d", i)}
+		node.Children = []*decision_tree.Node{child, {ID: fmt.Sprintf("side_%d", i), Label: "Side"}}
+		node = child
+	}
+	wide := &decision_tree.Node{ID: "wide", Label: "Wide"}
+	for i := 0; i < 6; i++ {
+		wide.Children = append(wide.Children, &decision_tree.Node{ID: fmt.Sprintf("wide_%d", i), Label: fmt.Sprintf("A longer label %d", i)})
+	}
+	return &decision_tree.Node{ID: "root", Label: "Root", Children: []*decision_tree.Node{deep, wide}}
+}
+
+func TestTidy(t *testing.T) {
+	trees := map[string]*decision_tree.Node{
+		"tree.json":   loadTestTree(t, "../testdata/tree.json"),
+		"t_tree.json": loadTestTree(t, "../testdata/t_tree.json"),
+		"asymmetric":  asymmetricTree(),
+	}
+	for name, root := range trees {
+		for _, orientation := range []decision_tree.Orientation{decision_tree.TopDown, decision_tree.LeftRight, decision_tree.Radial} {
+			t.Run(name+"/"+orientation.String(), func(t *testing.T) {
+				config := decision_tree.DefaultConfig()
+				config.Orientation = orientation
+				config.Algorithm = decision_tree.Tidy
+				nodes := flattenLayout(NewEngine(config).CalculateLayout(root))
+				checkNoOverlaps(t, nodes)
+
+				// siblings keep their order
+				for _, node := range nodes {
+					for i := 1; i < len(node.Children); i++ {
+						prev, child := node.Children[i-1], node.Children[i]
+						if orientation == decision_tree.TopDown && prev.X >= child.X {
+							t.Errorf("%s is not placed after %s", child.Node.ID, prev.Node.ID)
+						}
+						if orientation == decision_tree.LeftRight && prev.Y >= child.Y {
+							t.Errorf("%s is not placed after %s", child.Node.ID, prev.Node.ID)
+						}
+					}
+				}
+			})
+		}
+
+		t.Run(name+"/Width", func(t *testing.T) {
+			config := decision_tree.DefaultConfig()
+			leafOrder := treeWidth(flattenLayout(NewEngine(config).CalculateLayout(root)))
+			config.Algorithm = decision_tree.Tidy
+			tidy := treeWidth(flattenLayout(NewEngine(config).CalculateLayout(root)))
+			if tidy > leafOrder {
+				t.Errorf("expected tidy layout to be at most %.0f wide, got %.0f", leafOrder, tidy)
+			}
+		})
+	}
+}
+
+func TestNodeDimensions(t *testing.T) {
+	root := &decision_tree.Node{
+		ID:    "root",
+		Label: "短",
+		Children: []*decision_tree.Node{
+			{ID: "cjk", Label: "未激活的功能需要显示激活入口以及更多的说明文字"},
+			{ID: "cond", Label: "C", Conditions: map[string]any{"feature_type": "a_very_long_condition_value"}},
+		},
+	}
+	config := decision_tree.DefaultConfig()
+	nodes := flattenLayout(NewEngine(config).CalculateLayout(root))
+	style := NewTextStyle(config)
+	label, condition := style.LabelMetrics(), style.ConditionMetrics()
+	for _, node := range nodes {
+		lines := node.LabelLines
+		if strings.Join(lines, "") != node.Node.Label {
+			t.Errorf("%s: wrapped lines %q lose text of %q", node.Node.ID, lines, node.Node.Label)
+		}
+		for _, line := range lines {
+			if w := label.Width(line); w > style.MaxLabelWidth || w+2*config.NodePadding > node.Width {
+				t.Errorf("%s: label line %q (%.1f) overflows node of width %.1f", node.Node.ID, line, w, node.Width)
+			}
+		}
+		for _, line := range node.ConditionLines {
+			if w := condition.Width(line); w+2*config.NodePadding > node.Width {
+				t.Errorf("%s: condition line %q (%.1f) overflows node of width %.1f", node.Node.ID, line, w, node.Width)
+			}
+		}
+	}
+	if cjk := nodes[1]; len(cjk.LabelLines) < 2 {
+		t.Errorf("expected wide label to wrap, got %q", cjk.LabelLines)
+	}
+
+	// a larger font makes nodes larger
+	config.FontSize = 24
+	if larger := flattenLayout(NewEngine(config).CalculateLayout(root)); larger[1].Width <= nodes[1].Width && len(larger[1].LabelLines) <= len(nodes[1].LabelLines) {
+		t.Error("expected larger font to take more space")
+	}
+}
+
+func TestShapeFitsLabel(t *testing.T) {
+	config := decision_tree.DefaultConfig()
+	label := NewTextStyle(config).LabelMetrics()
+	lineHeight := NewTextStyle(config).LineHeight
+	for _, shape := range []string{decision_tree.ShapeRectangle, decision_tree.ShapeDiamond, decision_tree.ShapeEllipse, decision_tree.ShapeHexagon, decision_tree.ShapeStadium} {
+		root := &decision_tree.Node{
+			ID:    "root",
+			Label: "Is the user allowed to activate the feature?",
+			Style: &decision_tree.NodeStyle{Shape: shape},
+		}
+		node := NewEngine(config).CalculateLayout(root)
+		if node.Style.Fill != config.DefaultStyle.Fill {
+			t.Errorf("%s: expected style to be merged with the default, got %+v", shape, *node.Style)
+		}
+		// the corners of the label must lie inside the shape
+		w := 0.0
+		for _, line := range node.LabelLines {
+			w = math.Max(w, label.Width(line))
+		}
+		x, y := w/2/(node.Width/2), float64(len(node.LabelLines))*lineHeight/2/(node.Height/2)
+		inside := x <= 1 && y <= 1
+		switch shape {
+		case decision_tree.ShapeDiamond:
+			inside = x+y <= 1
+		case decision_tree.ShapeEllipse:
+			inside = x*x+y*y <= 1
+		}
+		if !inside {
+			t.Errorf("%s: label of %.1fx%d lines does not fit node of %.1fx%.1f", shape, w, len(node.LabelLines), node.Width, node.Height)
+		}
+	}
+}
+
+func TestBorderPoint(t *testing.T) {
+	config := decision_tree.DefaultConfig()
+	for _, shape := range []string{decision_tree.ShapeRectangle, decision_tree.ShapeDiamond, decision_tree.ShapeEllipse, decision_tree.ShapeHexagon, decision_tree.ShapeStadium} {
+		node := NewEngine(config).CalculateLayout(&decision_tree.Node{ID: shape, Label: "Is the feature active?", Style: &decision_tree.NodeStyle{Shape: shape}})
+		a, b := node.Width/2, node.Height/2
+		center := Point{node.X, node.Y + b}
+		for angle := 0.0; angle < 2*math.Pi; angle += math.Pi / 7 {
+			p := BorderPoint(node, Point{center.X + 1000*math.Cos(angle), center.Y + 1000*math.Sin(angle)})
+			x, y := math.Abs(p.X-center.X), math.Abs(p.Y-center.Y)
+			onBorder := math.Max(x/a, y/b)
+			switch shape {
+			case decision_tree.ShapeDiamond:
+				onBorder = x/a + y/b
+			case decision_tree.ShapeEllipse:
+				onBorder = x*x/(a*a) + y*y/(b*b)
+			case decision_tree.ShapeHexagon:
+				inset := HexagonInset(node)
+				onBorder = math.Max(y/b, (x*b+y*inset)/(a*b))
+			case decision_tree.ShapeStadium:
+				onBorder = y / b
+				if c := a - b; x > c {
+					onBorder = math.Hypot(x-c, y) / b
+				}
+			}
+			if math.Abs(onBorder-1) > 1e-9 {
+				t.Errorf("%s: border point %v at angle %.2f is off the outline (%f)", shape, p, angle, onBorder)
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package layout
+
+import (
+	"math"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/metrics"
+)
+
+// TextStyle is the text configuration of Config, with defaults for unset fields
+type TextStyle struct {
+	FontFamily        string
+	FontSize          float64
+	ConditionFontSize float64
+	MaxLabelWidth     float64
+
+	LineHeight          float64 // distance between label lines
+	ConditionLineHeight float64 // distance between condition lines
+}
+
+// NewTextStyle resolves the text configuration of config
+func NewTextStyle(config *decision_tree.Config) TextStyle {
+	style := TextStyle{
+		FontFamily:        config.FontFamily,
+		FontSize:          config.FontSize,
+		ConditionFontSize: config.ConditionFontSize,
+		MaxLabelWidth:     config.MaxLabelWidth,
+	}
+	if style.FontFamily == "" {
+		style.FontFamily = "Arial"
+	}
+	if style.FontSize <= 0 {
+		style.FontSize = 12
+	}
+	if style.ConditionFontSize <= 0 {
+		style.ConditionFontSize = 10
+	}
+	if style.MaxLabelWidth <= 0 {
+		style.MaxLabelWidth = 150
+	}
+	style.LineHeight = style.FontSize * 1.25
+	style.ConditionLineHeight = style.ConditionFontSize * 1.2
+	return style
+}
+
+// LabelMetrics measures labels
+func (s TextStyle) LabelMetrics() *metrics.Metrics {
+	return metrics.New(s.FontFamily, s.FontSize)
+}
+
+// ConditionMetrics measures conditions
+func (s TextStyle) ConditionMetrics() *metrics.Metrics {
+	return metrics.New(s.FontFamily, s.ConditionFontSize)
+}
+
+// EdgeLabelPadding is the space between edge labels and the border of their background
+const EdgeLabelPadding = 3
+
+// EdgeLabelSize returns the size of the background of an edge label
+func (s TextStyle) EdgeLabelSize(lines []string) (width, height float64) {
+	measure := s.ConditionMetrics()
+	for _, line := range lines {
+		width = math.Max(width, measure.Width(line))
+	}
+	return width + 2*EdgeLabelPadding, float64(len(lines))*s.ConditionLineHeight + EdgeLabelPadding
+}
@@ -0,0 +1,96 @@
+package layout
+
+import "math"
+
+// contour holds the horizontal extent of a subtree at each depth below
+// its root, relative to the root's center
+type contour struct {
+	left, right []float64
+}
+
+// assignTidy lays out the tree with the Reingold-Tilford algorithm: each
+// subtree is laid out on its own, then siblings are pushed right only as far
+// as needed for their contours to keep NodeSpacing apart at every depth,
+// and parents are centered between their first and last child.
+// Sibling order is kept, but a deep leaf may end up left of a
+// shallower leaf of a previous sibling.
+func (e *Engine) assignTidy(root *LayoutNode, levels map[int]*LevelInfo, minLevelSpacing float64) {
+	startX := 50.0
+	startY := 50.0
+
+	offsets := make(map[*LayoutNode]float64)
+	c := e.tidySubtree(root, offsets)
+
+	// place the leftmost extent at startX
+	minLeft := math.Inf(1)
+	for _, left := range c.left {
+		minLeft = math.Min(minLeft, left)
+	}
+	root.X = startX - minLeft
+	walkLayout(root, func(node *LayoutNode) {
+		for _, child := range node.Children {
+			child.X = node.X + offsets[child]
+		}
+	})
+
+	maxLevel := 0
+	for level := range levels {
+		if level > maxLevel {
+			maxLevel = level
+		}
+	}
+	levelY := e.levelPositions(levels, maxLevel, startY, minLevelSpacing)
+	walkLayout(root, func(node *LayoutNode) {
+		node.Y = levelY[node.Level]
+	})
+}
+
+// tidySubtree lays out the subtree of node, recording the offset of each
+// child's center from its parent's, and returns the subtree's contour
+func (e *Engine) tidySubtree(node *LayoutNode, offsets map[*LayoutNode]float64) *contour {
+	if len(node.Children) == 0 {
+		return &contour{left: []float64{-node.Width / 2}, right: []float64{node.Width / 2}}
+	}
+
+	// place children left to right, each against the
+	// merged contour of the children before it
+	var merged *contour
+	positions := make([]float64, len(node.Children))
+	for i, child := range node.Children {
+		c := e.tidySubtree(child, offsets)
+		if merged == nil {
+			merged = c
+			continue
+		}
+		pos := math.Inf(-1)
+		for d := 0; d < len(c.left) && d < len(merged.right); d++ {
+			pos = math.Max(pos, merged.right[d]+e.config.NodeSpacing-c.left[d])
+		}
+		positions[i] = pos
+		for d := range c.left {
+			left, right := c.left[d]+pos, c.right[d]+pos
+			if d < len(merged.left) {
+				merged.left[d] = math.Min(merged.left[d], left)
+				merged.right[d] = math.Max(merged.right[d], right)
+			} else {
+				merged.left = append(merged.left, left)
+				merged.right = append(merged.right, right)
+			}
+		}
+	}
+
+	// center the parent between its first and last child
+	center := (positions[0] + positions[len(positions)-1]) / 2
+	for i, child := range node.Children {
+		offsets[child] = positions[i] - center
+	}
+	c := &contour{
+		left:  []float64{-node.Width / 2},
+		right: []float64{node.Width / 2},
+	}
+	for d := range merged.left {
+		c.left = append(c.left, merged.left[d]-center)
+		c.right = append(c.right, merged.right[d]-center)
+	}
+	return c
+}
@@ -0,0 +1,240 @@
+// Package metrics measures text without rendering it, using bundled
+// character width tables, so nodes can be sized to fit their labels.
+package metrics
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Font holds advance widths of a font, in units of 1/1000 em
+type Font struct {
+	Name string
+
+	ascii    [95]float64 // printable ASCII, from ' ' to '~'
+	wide     float64     // East Asian wide and fullwidth characters
+	fallback float64     // other characters
+}
+
+// Arial has the widths of Arial, which shares its metrics with Helvetica
+var Arial = &Font{
+	Name: "Arial",
+	ascii: [95]float64{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // ' ' - '/'
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // '0' - '9'
+		278, 278, 584, 584, 584, 556, 1015, // ':' - '@'
+		667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // 'A' - 'M'
+		722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // 'N' - 'Z'
+		278, 278, 278, 469, 556, 333, // '[' - '`'
+		556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // 'a' - 'm'
+		556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // 'n' - 'z'
+		334, 260, 334, 584, // '{' - '~'
+	},
+	wide:     1000,
+	fallback: 556,
+}
+
+// DejaVuSans has the widths of DejaVu Sans, the default
+// sans-serif font of many Linux systems
+var DejaVuSans = &Font{
+	Name: "DejaVu Sans",
+	ascii: [95]float64{
+		318, 402, 460, 838, 636, 950, 780, 275, 390, 390, 500, 838, 318, 361, 318, 337, // ' ' - '/'
+		636, 636, 636, 636, 636, 636, 636, 636, 636, 636, // '0' - '9'
+		337, 337, 838, 838, 838, 531, 1000, // ':' - '@'
+		684, 686, 698, 770, 632, 575, 775, 752, 295, 295, 656, 557, 863, // 'A' - 'M'
+		748, 787, 603, 787, 695, 635, 611, 732, 684, 989, 685, 611, 685, // 'N' - 'Z'
+		390, 337, 390, 838, 500, 500, // '[' - '`'
+		613, 635, 550, 635, 615, 352, 635, 634, 278, 278, 579, 278, 974, // 'a' - 'm'
+		634, 612, 635, 635, 411, 521, 392, 634, 592, 818, 592, 592, 525, // 'n' - 'z'
+		636, 337, 636, 838, // '{' - '~'
+	},
+	wide:     1000,
+	fallback: 615,
+}
+
+// Monospace has the widths of terminal cells: half an em for
+// ASCII, and two cells for East Asian wide characters
+var Monospace = &Font{
+	Name:     "monospace",
+	ascii:    monospaceASCII(),
+	wide:     1000,
+	fallback: 500,
+}
+
+func monospaceASCII() [95]float64 {
+	var widths [95]float64
+	for i := range widths {
+		widths[i] = 500
+	}
+	return widths
+}
+
+// FontByName finds a bundled font by family name, case insensitively,
+// the first known family of a comma separated list is used.
+// Unknown families are measured as Arial.
+func FontByName(family string) *Font {
+	for _, name := range strings.Split(family, ",") {
+		name = strings.ToLower(strings.Trim(strings.TrimSpace(name), `"'`))
+		switch name {
+		case "arial", "helvetica", "sans-serif":
+			return Arial
+		case "dejavu sans", "dejavusans", "dejavu":
+			return DejaVuSans
+		case "monospace":
+			return Monospace
+		}
+	}
+	return Arial
+}
+
+// RuneWidth returns the width of r in units of 1/1000 em
+func (f *Font) RuneWidth(r rune) float64 {
+	switch {
+	case r >= ' ' && r <= '~':
+		return f.ascii[r-' ']
+	case IsZeroWidth(r):
+		return 0
+	case IsWide(r):
+		return f.wide
+	}
+	return f.fallback
+}
+
+// Metrics measures text set in a font at a size in pixels
+type Metrics struct {
+	Font *Font
+	Size float64
+}
+
+// New creates metrics of the font family at size pixels
+func New(family string, size float64) *Metrics {
+	return &Metrics{Font: FontByName(family), Size: size}
+}
+
+// RuneWidth returns the width of r in pixels
+func (m *Metrics) RuneWidth(r rune) float64 {
+	return m.Font.RuneWidth(r) * m.Size / 1000
+}
+
+// Width returns the width of s in pixels
+func (m *Metrics) Width(s string) float64 {
+	var units float64
+	for _, r := range s {
+		units += m.Font.RuneWidth(r)
+	}
+	return units * m.Size / 1000
+}
+
+// Wrap breaks s into lines no wider than maxWidth pixels. Lines break
+// at spaces, and between wide characters which need no space to
+// break; words wider than maxWidth are split. 0 disables wrapping.
+func (m *Metrics) Wrap(s string, maxWidth float64) []string {
+	if maxWidth <= 0 || m.Width(s) <= maxWidth {
+		return []string{s}
+	}
+	var lines []string
+	var line strings.Builder
+	var lineWidth float64
+	flush := func() {
+		lines = append(lines, line.String())
+		line.Reset()
+		lineWidth = 0
+	}
+	space := m.RuneWidth(' ')
+	for _, word := range strings.Fields(s) {
+		for i, token := range splitWide(word) {
+			width := m.Width(token)
+			sep := line.Len() > 0 && i == 0
+			add := width
+			if sep {
+				add += space
+			}
+			if line.Len() > 0 && lineWidth+add > maxWidth {
+				flush()
+				sep, add = false, width
+			}
+			if width <= maxWidth {
+				if sep {
+					line.WriteByte(' ')
+				}
+				line.WriteString(token)
+				lineWidth += add
+				continue
+			}
+			// split the word, it does not fit even on its own line
+			for _, r := range token {
+				w := m.RuneWidth(r)
+				if line.Len() > 0 && lineWidth+w > maxWidth {
+					flush()
+				}
+				line.WriteRune(r)
+				lineWidth += w
+			}
+		}
+	}
+	if line.Len() > 0 || len(lines) == 0 {
+		flush()
+	}
+	return lines
+}
+
+// splitWide splits a word before and after each wide character,
+// as text of such scripts can break between any two characters
+func splitWide(word string) []string {
+	var tokens []string
+	start := 0
+	for i, r := range word {
+		if !IsWide(r) {
+			continue
+		}
+		if start < i {
+			tokens = append(tokens, word[start:i])
+		}
+		end := i + utf8.RuneLen(r)
+		tokens = append(tokens, word[i:end])
+		start = end
+	}
+	if start < len(word) {
+		tokens = append(tokens, word[start:])
+	}
+	return tokens
+}
+
+// IsWide tells whether r is an East Asian wide or fullwidth
+// character, taking two cells in terminals
+func IsWide(r rune) bool {
+	if r < 0x1100 {
+		return false
+	}
+	for _, rng := range wideRanges {
+		if r >= rng[0] && r <= rng[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsZeroWidth tells whether r takes no space, like combining marks
+func IsZeroWidth(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf)
+}
+
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x2E80, 0x303E},   // CJK Radicals, Kangxi Radicals, CJK Symbols and Punctuation
+	{0x3041, 0x33FF},   // Hiragana, Katakana, Bopomofo, Hangul Compatibility Jamo, CJK Compatibility
+	{0x3400, 0x4DBF},   // CJK Unified Ideographs Extension A
+	{0x4E00, 0x9FFF},   // CJK Unified Ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xAC00, 0xD7A3},   // Hangul Syllables
+	{0xF900, 0xFAFF},   // CJK Compatibility Ideographs
+	{0xFE30, 0xFE4F},   // CJK Compatibility Forms
+	{0xFF00, 0xFF60},   // Fullwidth Forms
+	{0xFFE0, 0xFFE6},   // Fullwidth Signs
+	{0x1F300, 0x1F64F}, // Miscellaneous Symbols and Pictographs, Emoticons
+	{0x1F900, 0x1F9FF}, // Supplemental Symbols and Pictographs
+	{0x20000, 0x2FFFD}, // CJK Unified Ideographs Extension B and later
+	{0x30000, 0x3FFFD},
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		family string
+		size   float64
+		text   string
+		want   float64
+	}{
+		{"Arial", 12, "Hello", (722 + 556 + 222 + 222 + 556) * 12.0 / 1000},
+		{"Helvetica, sans-serif", 10, "iii", 3 * 222 * 10.0 / 1000},
+		{"DejaVu Sans", 10, "mmm", 3 * 974 * 10.0 / 1000},
+		{"Arial", 12, "你好", 24},
+		{"Arial", 12, "é", 556 * 12.0 / 1000},
+		{"monospace", 2, "ab你", 4},
+		{"unknown", 10, "a", 5.56},
+	}
+	for _, tt := range tests {
+		got := New(tt.family, tt.size).Width(tt.text)
+		if diff := got - tt.want; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%s %v: width of %q = %v, want %v", tt.family, tt.size, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	columns := &Metrics{Font: Monospace, Size: 2}
+	tests := []struct {
+		text  string
+		width float64
+		want  []string
+	}{
+		{"short", 10, []string{"short"}},
+		{"no limit at all", 0, []string{"no limit at all"}},
+		{"hello big world", 9, []string{"hello big", "world"}},
+		{"abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"未激活的功能", 5, []string{"未激", "活的", "功能"}},
+		{"状态 is 已冻结", 8, []string{"状态 is", "已冻结"}},
+		{"user=已冻结", 8, []string{"user=已", "冻结"}},
+	}
+	for _, tt := range tests {
+		got := columns.Wrap(tt.text, tt.width)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("Wrap(%q, %v) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+		if tt.width > 0 {
+			for _, line := range got {
+				if w := columns.Width(line); w > tt.width {
+					t.Errorf("Wrap(%q, %v): line %q is %v wide", tt.text, tt.width, line, w)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,309 @@
+// Package paint draws laid out decision trees onto vector canvases. It
+// is shared by the png and pdf renderers, which draw the same picture as
+// package svg without external tools.
+package paint
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/layout"
+)
+
+// Margin is the space around the tree in a drawing
+const Margin = 20
+
+// BaselineShift is the distance from the middle of a line
+// of text to its baseline, in units of the font size
+const BaselineShift = 0.35
+
+// Canvas is a drawing surface in layout coordinates
+type Canvas interface {
+	// Fill fills the closed subpaths of path
+	Fill(path Path, c color.RGBA)
+	// Stroke draws the lines of path
+	Stroke(path Path, c color.RGBA, width float64)
+	// Text draws a single line of text centered on p, its baseline
+	// lies BaselineShift times size below p
+	Text(p layout.Point, s string, size float64, c color.RGBA)
+}
+
+// Op is the operation of a path segment
+type Op int
+
+const (
+	MoveTo Op = iota // start a subpath at Points[0]
+	LineTo           // line to Points[0]
+	CubeTo           // cubic curve through the controls Points[0] and Points[1] to Points[2]
+	Close            // line back to the start of the subpath
+)
+
+// Segment is a part of a path
+type Segment struct {
+	Op     Op
+	Points []layout.Point
+}
+
+// Path is a sequence of subpaths
+type Path []Segment
+
+// MoveTo starts a new subpath at pt
+func (p *Path) MoveTo(pt layout.Point) {
+	*p = append(*p, Segment{Op: MoveTo, Points: []layout.Point{pt}})
+}
+
+// LineTo adds a line to pt
+func (p *Path) LineTo(pt layout.Point) {
+	*p = append(*p, Segment{Op: LineTo, Points: []layout.Point{pt}})
+}
+
+// CubeTo adds a cubic curve through the controls c1 and c2 to pt
+func (p *Path) CubeTo(c1, c2, pt layout.Point) {
+	*p = append(*p, Segment{Op: CubeTo, Points: []layout.Point{c1, c2, pt}})
+}
+
+// Close closes the current subpath
+func (p *Path) Close() {
+	*p = append(*p, Segment{Op: Close})
+}
+
+// polygon adds a closed subpath through points
+func (p *Path) polygon(points ...layout.Point) {
+	p.MoveTo(points[0])
+	for _, pt := range points[1:] {
+		p.LineTo(pt)
+	}
+	p.Close()
+}
+
+// kappa places the controls of a cubic curve approximating a quarter circle
+const kappa = 0.5522847498
+
+// roundedRect adds a rectangle with corners of radius r, 0 for sharp corners
+func (p *Path) roundedRect(x, y, w, h, r float64) {
+	if r <= 0 {
+		p.polygon(layout.Point{X: x, Y: y}, layout.Point{X: x + w, Y: y}, layout.Point{X: x + w, Y: y + h}, layout.Point{X: x, Y: y + h})
+		return
+	}
+	k := r * kappa
+	p.MoveTo(layout.Point{X: x + r, Y: y})
+	p.LineTo(layout.Point{X: x + w - r, Y: y})
+	p.CubeTo(layout.Point{X: x + w - r + k, Y: y}, layout.Point{X: x + w, Y: y + r - k}, layout.Point{X: x + w, Y: y + r})
+	p.LineTo(layout.Point{X: x + w, Y: y + h - r})
+	p.CubeTo(layout.Point{X: x + w, Y: y + h - r + k}, layout.Point{X: x + w - r + k, Y: y + h}, layout.Point{X: x + w - r, Y: y + h})
+	p.LineTo(layout.Point{X: x + r, Y: y + h})
+	p.CubeTo(layout.Point{X: x + r - k, Y: y + h}, layout.Point{X: x, Y: y + h - r + k}, layout.Point{X: x, Y: y + h - r})
+	p.LineTo(layout.Point{X: x, Y: y + r})
+	p.CubeTo(layout.Point{X: x, Y: y + r - k}, layout.Point{X: x + r - k, Y: y}, layout.Point{X: x + r, Y: y})
+	p.Close()
+}
+
+// ellipse adds an ellipse centered on c
+func (p *Path) ellipse(c layout.Point, rx, ry float64) {
+	kx, ky := rx*kappa, ry*kappa
+	p.MoveTo(layout.Point{X: c.X + rx, Y: c.Y})
+	p.CubeTo(layout.Point{X: c.X + rx, Y: c.Y + ky}, layout.Point{X: c.X + kx, Y: c.Y + ry}, layout.Point{X: c.X, Y: c.Y + ry})
+	p.CubeTo(layout.Point{X: c.X - kx, Y: c.Y + ry}, layout.Point{X: c.X - rx, Y: c.Y + ky}, layout.Point{X: c.X - rx, Y: c.Y})
+	p.CubeTo(layout.Point{X: c.X - rx, Y: c.Y - ky}, layout.Point{X: c.X - kx, Y: c.Y - ry}, layout.Point{X: c.X, Y: c.Y - ry})
+	p.CubeTo(layout.Point{X: c.X + kx, Y: c.Y - ry}, layout.Point{X: c.X + rx, Y: c.Y - ky}, layout.Point{X: c.X + rx, Y: c.Y})
+	p.Close()
+}
+
+// Outline returns the outline of the node in the shape of its style,
+// matching the elements of package svg
+func Outline(node *layout.LayoutNode) Path {
+	x, y := node.X-node.Width/2, node.Y
+	w, h := node.Width, node.Height
+	cx, cy := node.X, node.Y+h/2
+	shape := ""
+	if node.Style != nil {
+		shape = node.Style.Shape
+	}
+	var path Path
+	switch shape {
+	case decision_tree.ShapeDiamond:
+		path.polygon(layout.Point{X: cx, Y: y}, layout.Point{X: x + w, Y: cy}, layout.Point{X: cx, Y: y + h}, layout.Point{X: x, Y: cy})
+	case decision_tree.ShapeEllipse:
+		path.ellipse(layout.Point{X: cx, Y: cy}, w/2, h/2)
+	case decision_tree.ShapeHexagon:
+		inset := layout.HexagonInset(node)
+		path.polygon(layout.Point{X: x + inset, Y: y}, layout.Point{X: x + w - inset, Y: y}, layout.Point{X: x + w, Y: cy},
+			layout.Point{X: x + w - inset, Y: y + h}, layout.Point{X: x + inset, Y: y + h}, layout.Point{X: x, Y: cy})
+	case decision_tree.ShapeStadium:
+		path.roundedRect(x, y, w, h, math.Min(h, w)/2)
+	default:
+		// Terminal nodes get rounded corners
+		radius := 0.0
+		if len(node.Children) == 0 {
+			radius = 3
+		}
+		path.roundedRect(x, y, w, h, radius)
+	}
+	return path
+}
+
+// Frame returns the area drawn for the laid out tree, with a margin
+func Frame(engine *layout.Engine, root *layout.LayoutNode) layout.Rect {
+	bounds := engine.Bounds(root)
+	return layout.Rect{
+		X:      bounds.X - Margin,
+		Y:      bounds.Y - Margin,
+		Width:  bounds.Width + 2*Margin,
+		Height: bounds.Height + 2*Margin,
+	}
+}
+
+var (
+	white     = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	black     = color.RGBA{0, 0, 0, 0xff}
+	labelGray = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	condGray  = color.RGBA{0x66, 0x66, 0x66, 0xff}
+)
+
+// Draw draws the tree laid out by engine onto c: edges with arrow heads
+// and their labels, then nodes with labels and conditions
+func Draw(c Canvas, config *decision_tree.Config, engine *layout.Engine, root *layout.LayoutNode) {
+	if root == nil {
+		return
+	}
+	text := layout.NewTextStyle(config)
+
+	var background Path
+	frame := Frame(engine, root)
+	background.roundedRect(frame.X, frame.Y, frame.Width, frame.Height, 0)
+	c.Fill(background, white)
+
+	var drawEdges func(node *layout.LayoutNode)
+	drawEdges = func(node *layout.LayoutNode) {
+		for _, child := range node.Children {
+			drawEdge(c, engine.Edge(node, child))
+			drawEdges(child)
+		}
+	}
+	drawEdges(root)
+
+	for _, label := range engine.PlaceEdgeLabels(root) {
+		box := label.Box
+		var path Path
+		path.roundedRect(box.X, box.Y, box.Width, box.Height, 2)
+		c.Fill(path, white)
+		for i, line := range label.Child.EdgeLabelLines {
+			center := layout.Point{X: box.X + box.Width/2, Y: box.Y + layout.EdgeLabelPadding/2 + (float64(i)+0.5)*text.ConditionLineHeight}
+			c.Text(center, line, text.ConditionFontSize, labelGray)
+		}
+	}
+
+	var drawNodes func(node *layout.LayoutNode)
+	drawNodes = func(node *layout.LayoutNode) {
+		drawNode(c, config, text, node)
+		for _, child := range node.Children {
+			drawNodes(child)
+		}
+	}
+	drawNodes(root)
+}
+
+// arrowLength and arrowWidth are the size of the arrow heads of edges
+const (
+	arrowLength = 10
+	arrowWidth  = 7
+)
+
+func drawEdge(c Canvas, edge layout.Curve) {
+	var path Path
+	path.MoveTo(edge.Start)
+	if edge.Curved {
+		path.CubeTo(edge.Control1, edge.Control2, edge.End)
+	} else {
+		path.LineTo(edge.End)
+	}
+	c.Stroke(path, black, 1)
+
+	// the arrow head ends at the end of the edge
+	d := edge.EndDirection()
+	length := math.Hypot(d.X, d.Y)
+	if length == 0 {
+		return
+	}
+	ux, uy := d.X/length, d.Y/length
+	base := layout.Point{X: edge.End.X - ux*arrowLength, Y: edge.End.Y - uy*arrowLength}
+	var head Path
+	head.polygon(edge.End,
+		layout.Point{X: base.X - uy*arrowWidth/2, Y: base.Y + ux*arrowWidth/2},
+		layout.Point{X: base.X + uy*arrowWidth/2, Y: base.Y - ux*arrowWidth/2})
+	c.Fill(head, black)
+}
+
+func drawNode(c Canvas, config *decision_tree.Config, text layout.TextStyle, node *layout.LayoutNode) {
+	style := node.Style
+	if style == nil {
+		style = config.NodeStyle(node.Node)
+	}
+	outline := Outline(node)
+	if fill, ok := ParseColor(style.Fill); ok {
+		c.Fill(outline, fill)
+	}
+	if stroke, ok := ParseColor(style.Stroke); ok && style.StrokeWidth > 0 {
+		c.Stroke(outline, stroke, float64(style.StrokeWidth))
+	}
+
+	lines := node.LabelLines
+	startY := node.Y + (node.Height-float64(len(lines))*text.LineHeight)/2 + text.LineHeight/2
+	for i, line := range lines {
+		c.Text(layout.Point{X: node.X, Y: startY + float64(i)*text.LineHeight}, line, text.FontSize, black)
+	}
+
+	// conditions are below leaves and above parents, positioned
+	// by their baselines like in svg
+	condLines := node.ConditionLines
+	shift := BaselineShift * text.ConditionFontSize
+	for i, line := range condLines {
+		var baseline float64
+		if len(node.Children) == 0 {
+			baseline = node.Y + node.Height + text.ConditionLineHeight + float64(i)*text.ConditionLineHeight
+		} else {
+			baseline = node.Y - 3 - float64(len(condLines)-1-i)*text.ConditionLineHeight
+		}
+		c.Text(layout.Point{X: node.X, Y: baseline - shift}, line, text.ConditionFontSize, condGray)
+	}
+}
+
+var namedColors = map[string]color.RGBA{
+	"black":  black,
+	"white":  white,
+	"gray":   {0x80, 0x80, 0x80, 0xff},
+	"grey":   {0x80, 0x80, 0x80, 0xff},
+	"red":    {0xff, 0, 0, 0xff},
+	"green":  {0, 0x80, 0, 0xff},
+	"blue":   {0, 0, 0xff, 0xff},
+	"yellow": {0xff, 0xff, 0, 0xff},
+	"orange": {0xff, 0xa5, 0, 0xff},
+}
+
+// ParseColor parses #rgb and #rrggbb colors and basic color names.
+// References like url(#nodeGradient), none and unknown colors are
+// not painted, as in svg where the referenced gradient is undefined.
+func ParseColor(s string) (color.RGBA, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[s]; ok {
+		return c, true
+	}
+	if !strings.HasPrefix(s, "#") {
+		return color.RGBA{}, false
+	}
+	hex := s[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, true
+}
@@ -0,0 +1,93 @@
+package paint
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/layout"
+)
+
+type recorder struct {
+	fills, strokes int
+	texts          []string
+	bounds         layout.Rect
+}
+
+func (r *recorder) Fill(path Path, c color.RGBA) {
+	if r.fills == 0 {
+		// the background
+		r.bounds = layout.Rect{X: path[0].Points[0].X, Y: path[0].Points[0].Y}
+		r.bounds.Width = path[2].Points[0].X - r.bounds.X
+		r.bounds.Height = path[2].Points[0].Y - r.bounds.Y
+	}
+	r.fills++
+}
+
+func (r *recorder) Stroke(path Path, c color.RGBA, width float64) {
+	r.strokes++
+}
+
+func (r *recorder) Text(p layout.Point, s string, size float64, c color.RGBA) {
+	if p.X < r.bounds.X || p.X > r.bounds.X+r.bounds.Width || p.Y < r.bounds.Y || p.Y > r.bounds.Y+r.bounds.Height {
+		r.texts = append(r.texts, "outside:"+s)
+	}
+	r.texts = append(r.texts, s)
+}
+
+func TestDraw(t *testing.T) {
+	tree := &decision_tree.Node{
+		ID:         "root",
+		Label:      "Check",
+		Conditions: map[string]any{"a": 1},
+		Children: []*decision_tree.Node{
+			{ID: "yes", Label: "Yes", EdgeLabel: "ok=true", Style: &decision_tree.NodeStyle{Shape: decision_tree.ShapeEllipse, Fill: "#e6ffed"}},
+			{ID: "no", Label: "No", Style: &decision_tree.NodeStyle{Fill: "none"}},
+		},
+	}
+	config := decision_tree.DefaultConfig()
+	engine := layout.NewEngine(config)
+	root := engine.CalculateLayout(tree)
+	r := &recorder{}
+	Draw(r, config, engine, root)
+
+	// background, 2 arrow heads, the edge label and the filled ellipse
+	if r.fills != 5 {
+		t.Errorf("expected 5 fills, got %d", r.fills)
+	}
+	// 2 edges and 3 nodes
+	if r.strokes != 5 {
+		t.Errorf("expected 5 strokes, got %d", r.strokes)
+	}
+	want := []string{"ok=true", "Check", "a=1", "Yes", "No"}
+	if len(r.texts) != len(want) {
+		t.Fatalf("expected texts %q, got %q", want, r.texts)
+	}
+	for i := range want {
+		if r.texts[i] != want[i] {
+			t.Errorf("expected texts %q, got %q", want, r.texts)
+			break
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		s    string
+		want color.RGBA
+		ok   bool
+	}{
+		{"#28a745", color.RGBA{0x28, 0xa7, 0x45, 0xff}, true},
+		{"#fff", color.RGBA{0xff, 0xff, 0xff, 0xff}, true},
+		{"Red", color.RGBA{0xff, 0, 0, 0xff}, true},
+		{"url(#nodeGradient)", color.RGBA{}, false},
+		{"none", color.RGBA{}, false},
+		{"#12345", color.RGBA{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseColor(tt.s)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseColor(%q) = %v, %v, want %v, %v", tt.s, got, ok, tt.want, tt.ok)
+		}
+	}
+}
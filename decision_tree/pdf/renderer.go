@@ -0,0 +1,200 @@
+// Package pdf renders decision trees as single page PDF documents, for
+// documents which do not accept svg. The document is written directly,
+// with text set in the standard Helvetica font every reader provides,
+// which has the metrics of Arial the layout measures with. Characters
+// outside of Latin-1 are replaced by '?'.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/layout"
+	"github.com/xhd2015/data-driven-testing/decision_tree/metrics"
+	"github.com/xhd2015/data-driven-testing/decision_tree/paint"
+)
+
+// pointsPerPixel converts layout units, CSS pixels at 96 dpi, to PDF points
+const pointsPerPixel = 0.75
+
+// Renderer handles PDF generation
+type Renderer struct {
+	config *decision_tree.Config
+	layout *layout.Engine
+}
+
+// NewRenderer creates a new PDF renderer
+func NewRenderer(config *decision_tree.Config) *Renderer {
+	return &Renderer{
+		config: config,
+		layout: layout.NewEngine(config),
+	}
+}
+
+// SetCenterParent sets whether parent nodes should be centered over their children
+func (r *Renderer) SetCenterParent(center bool) {
+	r.layout.SetCenterParent(center)
+}
+
+// RenderTree generates a PDF of the entire tree, on a page of its size
+func (r *Renderer) RenderTree(root *decision_tree.Node) ([]byte, error) {
+	layoutRoot := r.layout.CalculateLayout(root)
+	frame := paint.Frame(r.layout, layoutRoot)
+	pageWidth := frame.Width * pointsPerPixel
+	pageHeight := frame.Height * pointsPerPixel
+
+	c := &canvas{}
+	// flip the y axis, so layout coordinates relative to the
+	// frame can be used as they are
+	fmt.Fprintf(&c.content, "%s 0 0 %s %s %s cm\n",
+		num(pointsPerPixel), num(-pointsPerPixel), num(-frame.X*pointsPerPixel), num(pageHeight+frame.Y*pointsPerPixel))
+	paint.Draw(c, r.config, r.layout, layoutRoot)
+
+	var stream bytes.Buffer
+	zw := zlib.NewWriter(&stream)
+	if _, err := zw.Write(c.content.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{}
+	doc.add("<< /Type /Catalog /Pages 2 0 R >>")
+	doc.add("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	doc.add(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+		num(pageWidth), num(pageHeight)))
+	doc.add(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()))
+	doc.add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	return doc.bytes(), nil
+}
+
+// document collects the objects of a PDF file, numbered from 1
+type document struct {
+	objects []string
+}
+
+func (d *document) add(object string) {
+	d.objects = append(d.objects, object)
+}
+
+// bytes writes the objects followed by the cross-reference
+// table, which holds the offset of each object
+func (d *document) bytes() []byte {
+	var buf bytes.Buffer
+	// the binary comment marks the file as binary for transfer tools
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(d.objects))
+	for i, object := range d.objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(d.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.objects)+1, xref)
+	return buf.Bytes()
+}
+
+// canvas writes drawing operators of the page content
+type canvas struct {
+	content bytes.Buffer
+}
+
+func (c *canvas) path(path paint.Path) {
+	for _, seg := range path {
+		switch seg.Op {
+		case paint.MoveTo:
+			fmt.Fprintf(&c.content, "%s %s m\n", num(seg.Points[0].X), num(seg.Points[0].Y))
+		case paint.LineTo:
+			fmt.Fprintf(&c.content, "%s %s l\n", num(seg.Points[0].X), num(seg.Points[0].Y))
+		case paint.CubeTo:
+			p := seg.Points
+			fmt.Fprintf(&c.content, "%s %s %s %s %s %s c\n", num(p[0].X), num(p[0].Y), num(p[1].X), num(p[1].Y), num(p[2].X), num(p[2].Y))
+		case paint.Close:
+			c.content.WriteString("h\n")
+		}
+	}
+}
+
+func (c *canvas) Fill(path paint.Path, col color.RGBA) {
+	fmt.Fprintf(&c.content, "%s rg\n", rgb(col))
+	c.path(path)
+	c.content.WriteString("f\n")
+}
+
+func (c *canvas) Stroke(path paint.Path, col color.RGBA, width float64) {
+	fmt.Fprintf(&c.content, "%s RG %s w 1 j\n", rgb(col), num(width))
+	c.path(path)
+	c.content.WriteString("S\n")
+}
+
+// helvetica has the metrics of the standard font
+var helvetica = metrics.Arial
+
+func (c *canvas) Text(p layout.Point, s string, size float64, col color.RGBA) {
+	text := winAnsi(s)
+	width := 0.0
+	for _, b := range text {
+		width += helvetica.RuneWidth(rune(b)) * size / 1000
+	}
+	x := p.X - width/2
+	y := p.Y + paint.BaselineShift*size
+	// the text matrix flips the text back upright
+	fmt.Fprintf(&c.content, "BT %s rg /F1 %s Tf 1 0 0 -1 %s %s Tm (%s) Tj ET\n",
+		rgb(col), num(size), num(x), num(y), escape(text))
+}
+
+// winAnsi encodes s in WinAnsiEncoding, which matches Latin-1
+// except for 0x80 to 0x9f, other characters become '?'
+func winAnsi(s string) []byte {
+	var b []byte
+	for _, r := range s {
+		if r < 0x80 || (r >= 0xa0 && r <= 0xff) {
+			b = append(b, byte(r))
+		} else {
+			b = append(b, '?')
+		}
+	}
+	return b
+}
+
+// escape escapes a PDF string literal
+func escape(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		switch c {
+		case '(', ')', '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func rgb(col color.RGBA) string {
+	return fmt.Sprintf("%s %s %s", num(float64(col.R)/255), num(float64(col.G)/255), num(float64(col.B)/255))
+}
+
+// num formats a number compactly, PDF has no exponent notation
+func num(f float64) string {
+	s := fmt.Sprintf("%.3f", f)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+func TestRenderTree(t *testing.T) {
+	tree := &decision_tree.Node{
+		ID:    "root",
+		Label: "Check (a)",
+		Children: []*decision_tree.Node{
+			{ID: "yes", Label: "Café", EdgeLabel: `x\y`, Style: &decision_tree.NodeStyle{Shape: decision_tree.ShapeDiamond, Fill: "#e6ffed"}},
+			{ID: "cjk", Label: "功能"},
+		},
+	}
+	out, err := NewRenderer(decision_tree.DefaultConfig()).RenderTree(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := string(out)
+	if !strings.HasPrefix(doc, "%PDF-1.4\n") || !strings.HasSuffix(doc, "%%EOF\n") {
+		t.Fatalf("expected a PDF document, got:\n%s", doc)
+	}
+
+	// each object is found at its offset in the cross-reference table
+	xref := regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`).FindAllStringSubmatch(doc, -1)
+	if len(xref) != 5 {
+		t.Fatalf("expected 5 objects, got %d", len(xref))
+	}
+	for i, m := range xref {
+		offset, _ := strconv.Atoi(m[1])
+		if want := strconv.Itoa(i+1) + " 0 obj\n"; !strings.HasPrefix(doc[offset:], want) {
+			t.Errorf("expected object %d at offset %d, got %q", i+1, offset, doc[offset:offset+10])
+		}
+	}
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(doc)
+	if offset, _ := strconv.Atoi(startxref[1]); !strings.HasPrefix(doc[offset:], "xref\n") {
+		t.Errorf("startxref %d does not point to the cross-reference table", offset)
+	}
+
+	start := strings.Index(doc, "stream\n") + len("stream\n")
+	end := strings.Index(doc, "\nendstream")
+	zr, err := zlib.NewReader(bytes.NewReader(out[start:end]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"(Check \\(a\\)) Tj",
+		"(Caf\xe9) Tj",
+		"(x\\\\y) Tj",
+		"(??) Tj",
+		"0.902 1 0.929 rg\n", // the fill of the diamond
+	} {
+		if !bytes.Contains(content, []byte(want)) {
+			t.Errorf("expected %q in content:\n%s", want, content)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+// Package png renders decision trees as PNG images, for documents and
+// comments which do not accept svg. Shapes are rasterized with
+// golang.org/x/image/vector and text is set in the Go font, no external
+// tools are needed. The Go font has no glyphs for CJK text.
+package png
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	imagepng "image/png"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/layout"
+	"github.com/xhd2015/data-driven-testing/decision_tree/paint"
+)
+
+// DefaultScale is the number of pixels per layout unit, twice
+// the svg size keeps text sharp on high density screens
+const DefaultScale = 2
+
+// DefaultMaxPixels limits the size of images to 8192x8192 pixels or
+// the same area, 256MB in memory
+const DefaultMaxPixels = 8192 * 8192
+
+// Renderer handles PNG generation
+type Renderer struct {
+	config    *decision_tree.Config
+	layout    *layout.Engine
+	scale     float64
+	maxPixels int
+}
+
+// NewRenderer creates a new PNG renderer
+func NewRenderer(config *decision_tree.Config) *Renderer {
+	return &Renderer{
+		config:    config,
+		layout:    layout.NewEngine(config),
+		scale:     DefaultScale,
+		maxPixels: DefaultMaxPixels,
+	}
+}
+
+// SetCenterParent sets whether parent nodes should be centered over their children
+func (r *Renderer) SetCenterParent(center bool) {
+	r.layout.SetCenterParent(center)
+}
+
+// SetScale sets the number of pixels per layout unit
+func (r *Renderer) SetScale(scale float64) {
+	r.scale = scale
+}
+
+// SetMaxPixels sets the largest image, in pixels, RenderTree and
+// RenderImage refuse to draw larger ones
+func (r *Renderer) SetMaxPixels(maxPixels int) {
+	r.maxPixels = maxPixels
+}
+
+// RenderTree generates a PNG of the entire tree
+func (r *Renderer) RenderTree(root *decision_tree.Node) ([]byte, error) {
+	img, err := r.RenderImage(root)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := imagepng.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderImage draws the entire tree into an image, it fails if the
+// image would have more pixels than allowed by SetMaxPixels
+func (r *Renderer) RenderImage(root *decision_tree.Node) (*image.RGBA, error) {
+	layoutRoot := r.layout.CalculateLayout(root)
+	frame := paint.Frame(r.layout, layoutRoot)
+	width := math.Ceil(frame.Width * r.scale)
+	height := math.Ceil(frame.Height * r.scale)
+	// compared in float64, the product of ints can overflow
+	if width*height > float64(r.maxPixels) {
+		return nil, fmt.Errorf("image of %.0fx%.0f pixels exceeds the limit of %d pixels, lower the scale or render fewer nodes", width, height, r.maxPixels)
+	}
+	c := &canvas{
+		img: image.NewRGBA(image.Rect(0, 0, int(width), int(height))),
+		// sized to the bounds of each shape by rasterize
+		raster: vector.NewRasterizer(0, 0),
+		origin: layout.Point{X: frame.X, Y: frame.Y},
+		scale:  r.scale,
+		faces:  make(map[float64]font.Face),
+	}
+	paint.Draw(c, r.config, r.layout, layoutRoot)
+	return c.img, nil
+}
+
+// goRegular is the parsed Go font, text of all images is set in it
+var goRegular, goRegularErr = opentype.Parse(goregular.TTF)
+
+// canvas rasterizes into img, mapping layout coordinates
+// relative to origin to pixels
+type canvas struct {
+	img    *image.RGBA
+	raster *vector.Rasterizer
+	origin layout.Point
+	scale  float64
+	faces  map[float64]font.Face // by size in pixels
+}
+
+func (c *canvas) point(p layout.Point) (float32, float32) {
+	return float32((p.X - c.origin.X) * c.scale), float32((p.Y - c.origin.Y) * c.scale)
+}
+
+// op is a rasterizer operation in pixels, a move, a line, a
+// cubic curve with the last point as its end, or a close
+type op struct {
+	kind   paint.Op
+	points [][2]float32
+}
+
+// rasterize fills the closed subpaths of ops with col. Only the
+// bounds of the points are rasterized, which keeps many small
+// shapes on a large image cheap.
+func (c *canvas) rasterize(ops []op, col color.RGBA) {
+	minX, minY := float32(math.Inf(1)), float32(math.Inf(1))
+	maxX, maxY := float32(math.Inf(-1)), float32(math.Inf(-1))
+	for _, o := range ops {
+		for _, p := range o.points {
+			minX, minY = min32(minX, p[0]), min32(minY, p[1])
+			maxX, maxY = max32(maxX, p[0]), max32(maxY, p[1])
+		}
+	}
+	bounds := image.Rect(int(math.Floor(float64(minX))), int(math.Floor(float64(minY))), int(math.Ceil(float64(maxX)))+1, int(math.Ceil(float64(maxY)))+1)
+	bounds = bounds.Intersect(c.img.Bounds())
+	if bounds.Empty() {
+		return
+	}
+	// the rasterizer's origin is the top left corner of bounds
+	ox, oy := float32(bounds.Min.X), float32(bounds.Min.Y)
+	c.raster.Reset(bounds.Dx(), bounds.Dy())
+	c.raster.DrawOp = draw.Over
+	for _, o := range ops {
+		switch o.kind {
+		case paint.MoveTo:
+			c.raster.MoveTo(o.points[0][0]-ox, o.points[0][1]-oy)
+		case paint.LineTo:
+			c.raster.LineTo(o.points[0][0]-ox, o.points[0][1]-oy)
+		case paint.CubeTo:
+			c.raster.CubeTo(o.points[0][0]-ox, o.points[0][1]-oy, o.points[1][0]-ox, o.points[1][1]-oy, o.points[2][0]-ox, o.points[2][1]-oy)
+		case paint.Close:
+			c.raster.ClosePath()
+		}
+	}
+	c.raster.Draw(c.img, bounds, image.NewUniform(col), bounds.Min)
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func (c *canvas) Fill(path paint.Path, col color.RGBA) {
+	ops := make([]op, 0, len(path))
+	for _, seg := range path {
+		o := op{kind: seg.Op}
+		for _, p := range seg.Points {
+			x, y := c.point(p)
+			o.points = append(o.points, [2]float32{x, y})
+		}
+		ops = append(ops, o)
+	}
+	c.rasterize(ops, col)
+}
+
+// Stroke fills a quad along each line of the flattened path, and
+// a disc at each joint. All are wound the same way, so overlaps
+// add up instead of cancelling out.
+func (c *canvas) Stroke(path paint.Path, col color.RGBA, width float64) {
+	half := width * c.scale / 2
+	var ops []op
+	polygon := func(points ...[2]float32) {
+		ops = append(ops, op{kind: paint.MoveTo, points: points[:1]})
+		for _, p := range points[1:] {
+			ops = append(ops, op{kind: paint.LineTo, points: [][2]float32{p}})
+		}
+		ops = append(ops, op{kind: paint.Close})
+	}
+	for _, line := range flatten(path) {
+		for i, p := range line {
+			x, y := c.point(p)
+			polygon(disc(float64(x), float64(y), half)...)
+			if i == 0 {
+				continue
+			}
+			px, py := c.point(line[i-1])
+			dx, dy := float64(x-px), float64(y-py)
+			length := math.Hypot(dx, dy)
+			if length == 0 {
+				continue
+			}
+			nx, ny := float32(-dy/length*half), float32(dx/length*half)
+			polygon([2]float32{px - nx, py - ny}, [2]float32{x - nx, y - ny}, [2]float32{x + nx, y + ny}, [2]float32{px + nx, py + ny})
+		}
+	}
+	c.rasterize(ops, col)
+}
+
+// disc returns an octagon approximating a disc of radius r
+func disc(x, y, r float64) [][2]float32 {
+	points := make([][2]float32, 8)
+	for i := range points {
+		angle := float64(i) * math.Pi / 4
+		points[i] = [2]float32{float32(x + r*math.Cos(angle)), float32(y + r*math.Sin(angle))}
+	}
+	return points
+}
+
+func (c *canvas) Text(p layout.Point, s string, size float64, col color.RGBA) {
+	face := c.face(size * c.scale)
+	if face == nil {
+		return
+	}
+	x, y := c.point(p)
+	width := font.MeasureString(face, s)
+	d := &font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot: fixed.Point26_6{
+			X: fixed.Int26_6(float64(x)*64) - width/2,
+			Y: fixed.Int26_6((float64(y) + paint.BaselineShift*size*c.scale) * 64),
+		},
+	}
+	d.DrawString(s)
+}
+
+func (c *canvas) face(size float64) font.Face {
+	if face, ok := c.faces[size]; ok {
+		return face
+	}
+	if goRegularErr != nil {
+		return nil
+	}
+	face, err := opentype.NewFace(goRegular, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		face = nil
+	}
+	c.faces[size] = face
+	return face
+}
+
+// flatten approximates the subpaths of path by polylines
+func flatten(path paint.Path) [][]layout.Point {
+	const steps = 16
+	var lines [][]layout.Point
+	var line []layout.Point
+	for _, seg := range path {
+		switch seg.Op {
+		case paint.MoveTo:
+			if len(line) > 1 {
+				lines = append(lines, line)
+			}
+			line = []layout.Point{seg.Points[0]}
+		case paint.LineTo:
+			line = append(line, seg.Points[0])
+		case paint.CubeTo:
+			if len(line) == 0 {
+				continue
+			}
+			curve := layout.Curve{Start: line[len(line)-1], Control1: seg.Points[0], Control2: seg.Points[1], End: seg.Points[2], Curved: true}
+			for i := 1; i <= steps; i++ {
+				line = append(line, curve.At(float64(i)/steps))
+			}
+		case paint.Close:
+			if len(line) > 0 {
+				line = append(line, line[0])
+			}
+		}
+	}
+	if len(line) > 1 {
+		lines = append(lines, line)
+	}
+	return lines
+}
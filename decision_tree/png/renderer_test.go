@@ -0,0 +1,108 @@
+package png
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	imagepng "image/png"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/layout"
+	"github.com/xhd2015/data-driven-testing/decision_tree/paint"
+)
+
+func TestRenderTree(t *testing.T) {
+	data, err := os.ReadFile("../testdata/tree.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tree *decision_tree.Node
+	if err := json.Unmarshal(data, &tree); err != nil {
+		t.Fatal(err)
+	}
+	tree.Style = &decision_tree.NodeStyle{Stroke: "#d73a49", StrokeWidth: 2}
+
+	config := decision_tree.DefaultConfig()
+	out, err := NewRenderer(config).RenderTree(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := imagepng.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := layout.NewEngine(config)
+	root := engine.CalculateLayout(tree)
+	frame := paint.Frame(engine, root)
+	if w, h := img.Bounds().Dx(), img.Bounds().Dy(); w != int(math.Ceil(frame.Width*DefaultScale)) || h != int(math.Ceil(frame.Height*DefaultScale)) {
+		t.Errorf("unexpected image size %dx%d for frame %v", w, h, frame)
+	}
+
+	at := func(x, y float64) (r, g, b uint32) {
+		r, g, b, _ = img.At(int((x-frame.X)*DefaultScale), int((y-frame.Y)*DefaultScale)).RGBA()
+		return r >> 8, g >> 8, b >> 8
+	}
+	if r, g, b := at(frame.X, frame.Y); r != 0xff || g != 0xff || b != 0xff {
+		t.Errorf("expected white background, got %d,%d,%d", r, g, b)
+	}
+	// the top border of the root is red
+	if r, g, b := at(root.X+root.Width/4, root.Y); r != 0xd7 || g != 0x3a || b != 0x49 {
+		t.Errorf("expected red border of root, got %d,%d,%d", r, g, b)
+	}
+	// the edge to the first child is drawn
+	edge := engine.Edge(root, root.Children[0]).At(0.5)
+	if r, _, _ := at(edge.X, edge.Y); r > 0x80 {
+		t.Errorf("expected dark edge at %v, got %d", edge, r)
+	}
+	// the label is drawn in the middle of the root
+	dark := 0
+	for x := root.X - root.Width/4; x < root.X+root.Width/4; x += 0.5 {
+		if r, _, _ := at(x, root.Y+root.Height/2); r < 0x80 {
+			dark++
+		}
+	}
+	if dark == 0 {
+		t.Error("expected text in the middle of the root")
+	}
+}
+
+func TestSetScale(t *testing.T) {
+	tree := &decision_tree.Node{ID: "root", Label: "Root"}
+	renderer := NewRenderer(decision_tree.DefaultConfig())
+	img, err := renderer.RenderImage(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	small := img.Bounds()
+	renderer.SetScale(4)
+	if img, err = renderer.RenderImage(tree); err != nil {
+		t.Fatal(err)
+	}
+	large := img.Bounds()
+	if d := large.Dx() - 2*small.Dx(); d < -1 || d > 1 {
+		t.Errorf("expected scale 4 to double the width of scale 2, got %v and %v", small, large)
+	}
+}
+
+func TestMaxPixels(t *testing.T) {
+	// wide enough to need gigabytes at full size
+	tree := &decision_tree.Node{ID: "root", Label: "Root"}
+	for i := 0; i < 3000; i++ {
+		tree.Children = append(tree.Children, &decision_tree.Node{ID: fmt.Sprintf("leaf_%d", i), Label: "Leaf"})
+	}
+	renderer := NewRenderer(decision_tree.DefaultConfig())
+	if _, err := renderer.RenderTree(tree); err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+		t.Errorf("expect large image refused, actual: %v", err)
+	}
+
+	small := &decision_tree.Node{ID: "root", Label: "Root"}
+	renderer.SetMaxPixels(100)
+	if _, err := renderer.RenderImage(small); err == nil {
+		t.Errorf("expect image over SetMaxPixels refused")
+	}
+}
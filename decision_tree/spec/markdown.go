@@ -0,0 +1,237 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+// ParseMarkdown builds a tree from a markdown spec. The first mermaid
+// code block is parsed with ParseMermaid if there is one, otherwise
+// the first bullet list: each item is a node and nested items are its
+// children. Trailing key=value words of an item are its conditions,
+// values may be quoted:
+//
+//	# Login
+//	- valid password
+//	  - admin role=admin
+//	  - guest role=guest tags=[happy_flow]
+//	- wrong password attempts=3 error="locked out"
+//
+// IDs are made from the labels. A list of several top level items
+// gets a root labelled by the heading before it.
+func ParseMarkdown(src string) (*decision_tree.Node, error) {
+	lines := strings.Split(src, "\n")
+	if block, ok := mermaidBlock(lines); ok {
+		return ParseMermaid(block)
+	}
+	return parseList(lines)
+}
+
+// mermaidBlock returns the content of the first ```mermaid block
+func mermaidBlock(lines []string) (string, bool) {
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "```") || strings.TrimSpace(line[3:]) != "mermaid" {
+			continue
+		}
+		var block []string
+		for _, line := range lines[i+1:] {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				break
+			}
+			block = append(block, line)
+		}
+		return strings.Join(block, "\n"), true
+	}
+	return "", false
+}
+
+var (
+	headingPattern = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*$`)
+	itemPattern    = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+(.*)$`)
+	checkboxPrefix = regexp.MustCompile(`^\[[ xX]\]\s+`)
+)
+
+// listItem is a node of the list and the text of its item,
+// continuation lines included
+type listItem struct {
+	indent int
+	node   *decision_tree.Node
+	text   string
+}
+
+func parseList(lines []string) (*decision_tree.Node, error) {
+	var heading string
+	var items []*listItem
+	var tops []*decision_tree.Node
+	var stack []*listItem
+	inCode := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		if trimmed == "" {
+			continue
+		}
+		m := itemPattern.FindStringSubmatch(line)
+		if m == nil {
+			if len(items) == 0 {
+				if h := headingPattern.FindStringSubmatch(trimmed); h != nil {
+					heading = h[1]
+				}
+				continue
+			}
+			if indentWidth(line) == 0 {
+				// the list has ended
+				break
+			}
+			last := items[len(items)-1]
+			last.text += " " + trimmed
+			continue
+		}
+		item := &listItem{
+			indent: indentWidth(m[1]),
+			node:   &decision_tree.Node{},
+			text:   checkboxPrefix.ReplaceAllString(strings.TrimSpace(m[2]), ""),
+		}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= item.indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			tops = append(tops, item.node)
+		} else {
+			parent := stack[len(stack)-1].node
+			parent.Children = append(parent.Children, item.node)
+		}
+		stack = append(stack, item)
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("markdown has no list")
+	}
+
+	ids := make(map[string]bool)
+	for _, item := range items {
+		item.node.Label, item.node.Conditions = parseItem(item.text)
+		item.node.ID = uniqueID(ids, slug(item.node.Label))
+	}
+	if len(tops) == 1 {
+		return tops[0], nil
+	}
+	label := heading
+	if label == "" {
+		label = "Root"
+	}
+	return &decision_tree.Node{ID: uniqueID(ids, slug(label)), Label: label, Children: tops}, nil
+}
+
+// indentWidth returns the width of the leading whitespace, tabs count as 4
+func indentWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// parseItem splits the trailing key=value words off the text of an item
+func parseItem(text string) (string, map[string]any) {
+	words := splitWords(text)
+	labelEnd := len(text)
+	conditions := make(map[string]any)
+	for i := len(words) - 1; i >= 0; i-- {
+		word := text[words[i][0]:words[i][1]]
+		k, v, ok := strings.Cut(word, "=")
+		if !ok || !isConditionKey(k) || v == "" {
+			break
+		}
+		if _, exists := conditions[k]; !exists {
+			conditions[k] = parseValue(v)
+		}
+		labelEnd = words[i][0]
+	}
+	if len(conditions) == 0 {
+		return text, nil
+	}
+	label := strings.TrimSpace(text[:labelEnd])
+	if label == "" {
+		// an item of only conditions is labelled by them
+		label = text
+	}
+	return label, conditions
+}
+
+// splitWords returns the start and end of the space separated
+// words of s, spaces inside double quotes do not separate
+func splitWords(s string) [][2]int {
+	var words [][2]int
+	start := -1
+	quoted := false
+	for i, r := range s {
+		if unicode.IsSpace(r) && !quoted {
+			if start >= 0 {
+				words = append(words, [2]int{start, i})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		if r == '"' {
+			quoted = !quoted
+		}
+	}
+	if start >= 0 {
+		words = append(words, [2]int{start, len(s)})
+	}
+	return words
+}
+
+// slug makes an ID of a label: lower case letters and digits
+// joined by underscores
+func slug(label string) string {
+	var sb strings.Builder
+	sep := false
+	for _, r := range strings.ToLower(label) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if sep && sb.Len() > 0 {
+				sb.WriteByte('_')
+			}
+			sep = false
+			sb.WriteRune(r)
+			continue
+		}
+		sep = true
+	}
+	if sb.Len() == 0 {
+		return "node"
+	}
+	return sb.String()
+}
+
+// uniqueID returns id, suffixed with _2, _3 and so on if already used
+func uniqueID(used map[string]bool, id string) string {
+	unique := id
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s_%d", id, n)
+	}
+	used[unique] = true
+	return unique
+}
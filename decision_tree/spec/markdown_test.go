@@ -0,0 +1,77 @@
+package spec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	root, err := ParseMarkdown("# Login\n" +
+		"\n" +
+		"Cases of the login page.\n" +
+		"\n" +
+		"- valid password\n" +
+		"  - admin role=admin\n" +
+		"  - [x] guest role=guest\n" +
+		"    tags=[happy_flow]\n" +
+		"- wrong password attempts=3 error=\"locked out\"\n" +
+		"- wrong password\n" +
+		"\n" +
+		"Notes after the list.\n" +
+		"- not part of the tree\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := &decision_tree.Node{
+		ID: "login", Label: "Login",
+		Children: []*decision_tree.Node{
+			{
+				ID: "valid_password", Label: "valid password",
+				Children: []*decision_tree.Node{
+					{ID: "admin", Label: "admin", Conditions: map[string]any{"role": "admin"}},
+					{ID: "guest", Label: "guest", Conditions: map[string]any{"role": "guest", "tags": []string{"happy_flow"}}},
+				},
+			},
+			{ID: "wrong_password", Label: "wrong password", Conditions: map[string]any{"attempts": 3, "error": "locked out"}},
+			{ID: "wrong_password_2", Label: "wrong password"},
+		},
+	}
+	if !reflect.DeepEqual(root, expect) {
+		t.Errorf("expect %s, got %s", jsonString(expect), jsonString(root))
+	}
+}
+
+func TestParseMarkdownSingleRoot(t *testing.T) {
+	root, err := ParseMarkdown("1. Checkout\n    1. paid=true\n    2. paid=false\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := &decision_tree.Node{
+		ID: "checkout", Label: "Checkout",
+		Children: []*decision_tree.Node{
+			{ID: "paid_true", Label: "paid=true", Conditions: map[string]any{"paid": true}},
+			{ID: "paid_false", Label: "paid=false", Conditions: map[string]any{"paid": false}},
+		},
+	}
+	if !reflect.DeepEqual(root, expect) {
+		t.Errorf("expect %s, got %s", jsonString(expect), jsonString(root))
+	}
+}
+
+func TestParseMarkdownMermaidBlock(t *testing.T) {
+	root, err := ParseMarkdown("# Spec\n\n- ignored\n\n```mermaid\ngraph TD\n  A --> B\n```\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.ID != "A" || len(root.Children) != 1 || root.Children[0].ID != "B" {
+		t.Errorf("expect the mermaid block, got %s", jsonString(root))
+	}
+}
+
+func TestParseMarkdownNoList(t *testing.T) {
+	if _, err := ParseMarkdown("# Title\n\nJust text.\n"); err == nil {
+		t.Errorf("expect error")
+	}
+}
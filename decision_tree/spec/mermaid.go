@@ -0,0 +1,351 @@
+package spec
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+// ParseMermaid builds a tree from a Mermaid flowchart, "graph TD" or
+// "flowchart TD" in any direction. The flowchart must be a tree: one
+// root, every other node linked from exactly one parent. Node shapes
+// map to NodeStyle.Shape, link texts to EdgeLabel, and style, classDef
+// and class statements to NodeStyle. Labels in the form export.ToMermaid
+// writes, "label<br><i>a=1, b=2</i>", get their conditions back.
+//
+// Subgraphs, click and linkStyle statements are ignored, "A --> B & C"
+// is not supported.
+func ParseMermaid(src string) (*decision_tree.Node, error) {
+	p := &mermaidParser{
+		nodes:     make(map[string]*decision_tree.Node),
+		parents:   make(map[string]string),
+		classDefs: make(map[string]*decision_tree.NodeStyle),
+		classes:   make(map[string]string),
+		styles:    make(map[string]*decision_tree.NodeStyle),
+	}
+	header := false
+	for i, line := range strings.Split(src, "\n") {
+		for _, stmt := range splitStatements(line) {
+			stmt = strings.TrimSpace(stmt)
+			if stmt == "" || strings.HasPrefix(stmt, "%%") {
+				continue
+			}
+			if !header {
+				keyword := strings.Fields(stmt)[0]
+				if keyword != "graph" && keyword != "flowchart" {
+					return nil, fmt.Errorf("line %d: expect graph or flowchart, got %q", i+1, stmt)
+				}
+				header = true
+				continue
+			}
+			if err := p.statement(stmt); err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+		}
+	}
+	if !header {
+		return nil, fmt.Errorf("expect graph or flowchart")
+	}
+	return p.tree()
+}
+
+type mermaidParser struct {
+	order     []string // node IDs in order of appearance
+	nodes     map[string]*decision_tree.Node
+	parents   map[string]string // child ID -> parent ID
+	classDefs map[string]*decision_tree.NodeStyle
+	classes   map[string]string // node ID -> class
+	styles    map[string]*decision_tree.NodeStyle
+}
+
+func (p *mermaidParser) statement(stmt string) error {
+	keyword, rest := stmt, ""
+	if i := strings.IndexFunc(stmt, unicode.IsSpace); i >= 0 {
+		keyword, rest = stmt[:i], strings.TrimSpace(stmt[i:])
+	}
+	switch keyword {
+	case "subgraph", "end", "direction", "click", "linkStyle":
+		return nil
+	case "style":
+		id, props := splitFirstField(rest)
+		p.styles[id] = parseMermaidStyle(props)
+		return nil
+	case "classDef":
+		name, props := splitFirstField(rest)
+		style := parseMermaidStyle(props)
+		style.Class = name
+		p.classDefs[name] = style
+		return nil
+	case "class":
+		ids, name := splitFirstField(rest)
+		for _, id := range strings.Split(ids, ",") {
+			p.classes[strings.TrimSpace(id)] = strings.TrimSpace(name)
+		}
+		return nil
+	}
+	return p.chain(stmt)
+}
+
+// chain parses nodes joined by links, e.g. A[Start] -->|yes| B{Check} --> C
+func (p *mermaidParser) chain(stmt string) error {
+	rest := stmt
+	id, rest, err := p.nodeRef(rest)
+	if err != nil {
+		return err
+	}
+	for {
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			return nil
+		}
+		if strings.HasPrefix(rest, "&") {
+			return fmt.Errorf("& is not supported: %s", stmt)
+		}
+		var label string
+		var ok bool
+		label, rest, ok = parseLink(rest)
+		if !ok {
+			return fmt.Errorf("expect link at %q", rest)
+		}
+		var child string
+		child, rest, err = p.nodeRef(rest)
+		if err != nil {
+			return err
+		}
+		if parent, ok := p.parents[child]; ok {
+			return fmt.Errorf("node %s is linked from both %s and %s, requires a tree", child, parent, id)
+		}
+		p.parents[child] = id
+		parent := p.nodes[id]
+		p.nodes[child].EdgeLabel = label
+		parent.Children = append(parent.Children, p.nodes[child])
+		id = child
+	}
+}
+
+var mermaidIDPattern = regexp.MustCompile(`^[\p{L}\p{N}_]+`)
+
+// mermaidShapes are the brackets of node shapes, longer openings first
+var mermaidShapes = []struct {
+	open, close string
+	shape       string
+}{
+	{"([", "])", decision_tree.ShapeStadium},
+	{"((", "))", decision_tree.ShapeEllipse},
+	{"{{", "}}", decision_tree.ShapeHexagon},
+	{"[[", "]]", ""},
+	{"[(", ")]", ""},
+	{"[", "]", ""},
+	{"(", ")", ""},
+	{"{", "}", decision_tree.ShapeDiamond},
+	{">", "]", ""},
+}
+
+// nodeRef parses a node reference, an ID optionally followed by
+// its shape and label, and returns the ID
+func (p *mermaidParser) nodeRef(s string) (string, string, error) {
+	s = strings.TrimSpace(s)
+	id := mermaidIDPattern.FindString(s)
+	if id == "" {
+		return "", "", fmt.Errorf("expect node at %q", s)
+	}
+	s = s[len(id):]
+	node := p.nodes[id]
+	if node == nil {
+		node = &decision_tree.Node{ID: id, Label: id}
+		p.nodes[id] = node
+		p.order = append(p.order, id)
+	}
+	for _, shape := range mermaidShapes {
+		if !strings.HasPrefix(s, shape.open) {
+			continue
+		}
+		s = s[len(shape.open):]
+		var label string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.Index(s[1:], `"`)
+			if end < 0 || !strings.HasPrefix(s[end+2:], shape.close) {
+				return "", "", fmt.Errorf("unterminated label of node %s", id)
+			}
+			label, s = s[1:end+1], s[end+2+len(shape.close):]
+		} else {
+			end := strings.Index(s, shape.close)
+			if end < 0 {
+				return "", "", fmt.Errorf("unterminated label of node %s", id)
+			}
+			label, s = strings.TrimSpace(s[:end]), s[end+len(shape.close):]
+		}
+		node.Label, node.Conditions = parseMermaidLabel(label)
+		if shape.shape != "" {
+			if node.Style == nil {
+				node.Style = &decision_tree.NodeStyle{}
+			}
+			node.Style.Shape = shape.shape
+		}
+		break
+	}
+	if strings.HasPrefix(s, ":::") {
+		name := mermaidIDPattern.FindString(s[3:])
+		p.classes[id] = name
+		s = s[3+len(name):]
+	}
+	return id, s, nil
+}
+
+var (
+	// arrow links, optionally followed by |text|
+	linkPattern = regexp.MustCompile(`^(?:<?--+[>ox]?|<?==+[>ox]?|<?-\.+-[>ox]?)(?:\s*\|([^|]*)\|)?`)
+	// links with the text inside them, -- text -->
+	textLinkPattern = regexp.MustCompile(`^(?:--|==|-\.)\s+(.*?)\s+(?:--+[>ox]?|==+[>ox]?|\.-+[>ox]?)`)
+)
+
+// parseLink parses a link, returning its text and the rest after it
+func parseLink(s string) (string, string, bool) {
+	// "-- " opens a link with text, "-->" does not
+	m := textLinkPattern.FindStringSubmatch(s)
+	if m == nil {
+		m = linkPattern.FindStringSubmatch(s)
+	}
+	if m == nil {
+		return "", "", false
+	}
+	label := strings.TrimSpace(m[1])
+	if len(label) >= 2 && label[0] == '"' && label[len(label)-1] == '"' {
+		label = label[1 : len(label)-1]
+	}
+	return unescapeMermaid(label), s[len(m[0]):], true
+}
+
+// parseMermaidLabel splits the conditions off labels like
+// "label<br><i>a=1, b=2</i>"
+func parseMermaidLabel(label string) (string, map[string]any) {
+	label = unescapeMermaid(label)
+	const start, end = "<br><i>", "</i>"
+	i := strings.LastIndex(label, start)
+	if i < 0 || !strings.HasSuffix(label, end) {
+		return label, nil
+	}
+	conditions := make(map[string]any)
+	for _, pair := range strings.Split(label[i+len(start):len(label)-len(end)], ", ") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || !isConditionKey(k) {
+			return label, nil
+		}
+		conditions[k] = parseValue(v)
+	}
+	return label[:i], conditions
+}
+
+func unescapeMermaid(s string) string {
+	return html.UnescapeString(strings.ReplaceAll(s, "#quot;", `"`))
+}
+
+// parseMermaidStyle parses properties like fill:#fff,stroke:#333,stroke-width:2px
+func parseMermaidStyle(props string) *decision_tree.NodeStyle {
+	style := &decision_tree.NodeStyle{}
+	for _, prop := range strings.Split(props, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(prop), ":")
+		v = strings.TrimSpace(v)
+		switch strings.TrimSpace(k) {
+		case "fill":
+			style.Fill = v
+		case "stroke":
+			style.Stroke = v
+		case "stroke-width":
+			fmt.Sscanf(v, "%d", &style.StrokeWidth)
+		}
+	}
+	return style
+}
+
+// mergeStyle overrides fields of dst with the set fields of src
+func mergeStyle(dst *decision_tree.NodeStyle, src *decision_tree.NodeStyle) {
+	if src.Fill != "" {
+		dst.Fill = src.Fill
+	}
+	if src.Stroke != "" {
+		dst.Stroke = src.Stroke
+	}
+	if src.StrokeWidth != 0 {
+		dst.StrokeWidth = src.StrokeWidth
+	}
+	if src.Class != "" {
+		dst.Class = src.Class
+	}
+}
+
+// tree applies the styles and returns the root, the only node without parent
+func (p *mermaidParser) tree() (*decision_tree.Node, error) {
+	if len(p.order) == 0 {
+		return nil, fmt.Errorf("flowchart has no nodes")
+	}
+	var roots []string
+	for _, id := range p.order {
+		node := p.nodes[id]
+		if def := p.classDefs[p.classes[id]]; def != nil {
+			if node.Style == nil {
+				node.Style = &decision_tree.NodeStyle{}
+			}
+			mergeStyle(node.Style, def)
+		}
+		if style := p.styles[id]; style != nil {
+			if node.Style == nil {
+				node.Style = &decision_tree.NodeStyle{}
+			}
+			mergeStyle(node.Style, style)
+		}
+		if _, ok := p.parents[id]; !ok {
+			roots = append(roots, id)
+		}
+	}
+	if len(roots) != 1 {
+		if len(roots) == 0 {
+			return nil, fmt.Errorf("flowchart has a cycle, requires a tree")
+		}
+		return nil, fmt.Errorf("flowchart has %d roots %s, requires a tree", len(roots), strings.Join(roots, ", "))
+	}
+	// nodes not reachable from the root are on a cycle
+	reached := 0
+	var visit func(node *decision_tree.Node)
+	visit = func(node *decision_tree.Node) {
+		reached++
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	root := p.nodes[roots[0]]
+	visit(root)
+	if reached != len(p.order) {
+		return nil, fmt.Errorf("flowchart has a cycle, requires a tree")
+	}
+	return root, nil
+}
+
+// splitStatements splits a line at semicolons outside of quotes
+func splitStatements(line string) []string {
+	var stmts []string
+	quoted := false
+	start := 0
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ';' && !quoted:
+			stmts = append(stmts, line[start:i])
+			start = i + 1
+		}
+	}
+	return append(stmts, line[start:])
+}
+
+// splitFirstField splits s into its first field and the rest
+func splitFirstField(s string) (string, string) {
+	if i := strings.IndexFunc(s, unicode.IsSpace); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
@@ -0,0 +1,88 @@
+package spec
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/export"
+)
+
+func TestParseMermaid(t *testing.T) {
+	root, err := ParseMermaid(`flowchart LR
+    %% checkout flow
+    A[Start] -->|logged in| B{Check cart} --> C((Pay))
+    A -- "no #quot;user#quot;" --> D([Login]):::warn
+    B --> E{{Empty}}; E --> F
+    classDef warn fill:#fff8dc,stroke:#d4a017
+    style C stroke:#000,stroke-width:2px
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := &decision_tree.Node{
+		ID: "A", Label: "Start",
+		Children: []*decision_tree.Node{
+			{
+				ID: "B", Label: "Check cart", EdgeLabel: "logged in",
+				Style: &decision_tree.NodeStyle{Shape: decision_tree.ShapeDiamond},
+				Children: []*decision_tree.Node{
+					{ID: "C", Label: "Pay", Style: &decision_tree.NodeStyle{Shape: decision_tree.ShapeEllipse, Stroke: "#000", StrokeWidth: 2}},
+					{
+						ID: "E", Label: "Empty",
+						Style:    &decision_tree.NodeStyle{Shape: decision_tree.ShapeHexagon},
+						Children: []*decision_tree.Node{{ID: "F", Label: "F"}},
+					},
+				},
+			},
+			{ID: "D", Label: "Login", EdgeLabel: `no "user"`, Style: &decision_tree.NodeStyle{Shape: decision_tree.ShapeStadium, Fill: "#fff8dc", Stroke: "#d4a017", Class: "warn"}},
+		},
+	}
+	if !reflect.DeepEqual(root, expect) {
+		t.Errorf("expect %s, got %s", jsonString(expect), jsonString(root))
+	}
+}
+
+func TestParseMermaidRoundTrip(t *testing.T) {
+	tree := &decision_tree.Node{
+		ID: "root", Label: "Root",
+		Style: &decision_tree.NodeStyle{Shape: decision_tree.ShapeDiamond},
+		Children: []*decision_tree.Node{
+			{ID: "a", Label: `Say "hi"`, Conditions: map[string]any{"n": 1, "ok": true, "tags": []string{"x", "y"}}},
+			{ID: "b", Label: "B", EdgeLabel: "b=2", Style: &decision_tree.NodeStyle{Fill: "#eeeeee", Class: "skip"}},
+		},
+	}
+	root, err := ParseMermaid(export.ToMermaid(tree))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(root, tree) {
+		t.Errorf("expect %s, got %s", jsonString(tree), jsonString(root))
+	}
+}
+
+func TestParseMermaidErrors(t *testing.T) {
+	tests := map[string]string{
+		"A --> B":                        "expect graph or flowchart",
+		"graph TD\nA --> C\nB --> C":     "linked from both",
+		"graph TD\nA --> B\nC --> D":     "2 roots",
+		"graph TD\nA --> B\nB --> A":     "cycle",
+		"graph TD\nA --> B & C":          "& is not supported",
+		"graph TD\nA[unterminated":       "unterminated",
+		"graph TD\n":                     "no nodes",
+		"graph TD\nA --> B\nC --> D -->": "expect node",
+	}
+	for src, expect := range tests {
+		_, err := ParseMermaid(src)
+		if err == nil || !strings.Contains(err.Error(), expect) {
+			t.Errorf("%q: expect error containing %q, got %v", src, expect, err)
+		}
+	}
+}
+
+func jsonString(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,82 @@
+// Package spec builds decision trees from the formats product specs
+// are written in: Mermaid flowcharts and nested markdown lists.
+package spec
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+// IsSpecFile tells whether Parse accepts the file, by its extension:
+// .md and .markdown are markdown, .mmd and .mermaid are Mermaid
+func IsSpecFile(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".md", ".markdown", ".mmd", ".mermaid":
+		return true
+	}
+	return false
+}
+
+// Parse builds the tree of a spec file, in the format given by its extension
+func Parse(file string, data []byte) (*decision_tree.Node, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".md", ".markdown":
+		return ParseMarkdown(string(data))
+	case ".mmd", ".mermaid":
+		return ParseMermaid(string(data))
+	}
+	return nil, fmt.Errorf("unsupported spec file %s, requires .md or .mmd", file)
+}
+
+// parseValue parses the value of a key=value condition: booleans,
+// numbers, quoted strings and [a b] or [a, b] lists, anything else
+// is taken as the string it is
+func parseValue(s string) any {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if unquoted, err := strconv.Unquote(s); err == nil {
+			return unquoted
+		}
+	}
+	if len(s) >= 2 && s[0] == '[' && s[len(s)-1] == ']' {
+		list := strings.FieldsFunc(s[1:len(s)-1], func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+		if list == nil {
+			list = []string{}
+		}
+		return list
+	}
+	return s
+}
+
+// isConditionKey tells whether key can be the key of a key=value condition
+func isConditionKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && (r == '.' || r == '-' || ('0' <= r && r <= '9')):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package decision_tree
+
+import "fmt"
+
+// Shapes of NodeStyle.Shape, empty is a rectangle
+const (
+	ShapeRectangle = "rectangle"
+	ShapeDiamond   = "diamond"
+	ShapeEllipse   = "ellipse"
+	ShapeHexagon   = "hexagon"
+	ShapeStadium   = "stadium" // rectangle with semicircular ends
+)
+
+// StyleRule styles the nodes matching all of its criteria, e.g.
+// every node tagged "error" gets a red fill:
+//
+//	StyleRule{Tag: "error", Style: &NodeStyle{Fill: "#ffeef0", Stroke: "#d73a49"}}
+type StyleRule struct {
+	Tag        string         `json:"tag,omitempty"`        // one of conditions.tags
+	Conditions map[string]any `json:"conditions,omitempty"` // conditions compared by their formatted values
+	Style      *NodeStyle     `json:"style"`
+}
+
+// Matches tells whether the rule applies to the node,
+// a rule without criteria matches every node
+func (r *StyleRule) Matches(node *Node) bool {
+	if r.Tag != "" && !node.HasTag(r.Tag) {
+		return false
+	}
+	for k, want := range r.Conditions {
+		got, ok := node.Conditions[k]
+		if !ok || fmt.Sprint(got) != fmt.Sprint(want) {
+			return false
+		}
+	}
+	return true
+}
+
+// Tags returns conditions.tags, as string list or single string
+func (n *Node) Tags() []string {
+	switch tags := n.Conditions["tags"].(type) {
+	case string:
+		return []string{tags}
+	case []string:
+		return tags
+	case []any:
+		var list []string
+		for _, tag := range tags {
+			list = append(list, fmt.Sprint(tag))
+		}
+		return list
+	}
+	return nil
+}
+
+// NodeStyle resolves the style of a node: the set fields of the
+// node's own style override those of matching rules, applied in
+// order, which override DefaultStyle
+func (c *Config) NodeStyle(node *Node) *NodeStyle {
+	style := &NodeStyle{}
+	style.merge(c.DefaultStyle)
+	for i := range c.StyleRules {
+		if c.StyleRules[i].Matches(node) {
+			style.merge(c.StyleRules[i].Style)
+		}
+	}
+	style.merge(node.Style)
+	return style
+}
+
+// merge overrides fields of s with the set fields of other
+func (s *NodeStyle) merge(other *NodeStyle) {
+	if other == nil {
+		return
+	}
+	if other.Shape != "" {
+		s.Shape = other.Shape
+	}
+	if other.Fill != "" {
+		s.Fill = other.Fill
+	}
+	if other.Stroke != "" {
+		s.Stroke = other.Stroke
+	}
+	if other.StrokeWidth != 0 {
+		s.StrokeWidth = other.StrokeWidth
+	}
+	if other.Class != "" {
+		s.Class = other.Class
+	}
+}
@@ -0,0 +1,40 @@
+package decision_tree
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNodeStyle(t *testing.T) {
+	config := DefaultConfig()
+	config.StyleRules = []StyleRule{
+		{Tag: "error", Style: &NodeStyle{Fill: "#ffeef0", Stroke: "#d73a49"}},
+		{Conditions: map[string]any{"user_state": 3}, Style: &NodeStyle{Shape: ShapeDiamond}},
+		{Tag: "error", Conditions: map[string]any{"user_state": 3}, Style: &NodeStyle{StrokeWidth: 3}},
+	}
+
+	var tagged Node
+	if err := json.Unmarshal([]byte(`{"id":"a","conditions":{"tags":["happy_flow","error"],"user_state":3}}`), &tagged); err != nil {
+		t.Fatal(err)
+	}
+	style := config.NodeStyle(&tagged)
+	if *style != (NodeStyle{Shape: ShapeDiamond, Fill: "#ffeef0", Stroke: "#d73a49", StrokeWidth: 3}) {
+		t.Errorf("unexpected style of node matching all rules: %+v", *style)
+	}
+
+	// the node's own style overrides rules field by field
+	tagged.Style = &NodeStyle{Fill: "#ffffff"}
+	style = config.NodeStyle(&tagged)
+	if style.Fill != "#ffffff" || style.Stroke != "#d73a49" || style.Shape != ShapeDiamond {
+		t.Errorf("unexpected style of node with own fill: %+v", *style)
+	}
+
+	plain := &Node{ID: "b", Conditions: map[string]any{"tags": "error", "user_state": 2}}
+	style = config.NodeStyle(plain)
+	if *style != (NodeStyle{Shape: "rectangle", Fill: "#ffeef0", Stroke: "#d73a49", StrokeWidth: 1}) {
+		t.Errorf("unexpected style of node matching the tag only: %+v", *style)
+	}
+	if config.DefaultStyle.Fill != "url(#nodeGradient)" {
+		t.Errorf("resolving styles changed the default style: %+v", *config.DefaultStyle)
+	}
+}
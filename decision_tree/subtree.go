@@ -0,0 +1,102 @@
+package decision_tree
+
+import "fmt"
+
+// Find returns the node with id in the tree, nil if there is none
+func (n *Node) Find(id string) *Node {
+	node, _ := n.findWithParent(id)
+	return node
+}
+
+// Subtree returns a copy of the branch rooted at the node with
+// id, nil if there is none
+func (n *Node) Subtree(id string) *Node {
+	return n.Find(id).Clone()
+}
+
+// Prune returns the tree cut below maxDepth, the root being at depth
+// 0. Nodes at maxDepth with children get a single "+N more" child
+// counting the cut nodes instead. Nodes are copied, the tree is left
+// as it is. A negative maxDepth keeps every level.
+func (n *Node) Prune(maxDepth int) *Node {
+	if n == nil || maxDepth < 0 {
+		return n
+	}
+	var prune func(node *Node, depth int) *Node
+	prune = func(node *Node, depth int) *Node {
+		c := node.shallowCopy()
+		if len(node.Children) == 0 {
+			return c
+		}
+		if depth == maxDepth {
+			c.Children = []*Node{CollapsedNode(node.ID, node.Count()-1)}
+			return c
+		}
+		c.Children = make([]*Node, len(node.Children))
+		for i, child := range node.Children {
+			c.Children[i] = prune(child, depth+1)
+		}
+		return c
+	}
+	return prune(n, 0)
+}
+
+// Filter returns the tree of the nodes matching predicate and their
+// ancestors, nil if no node matches. Nodes are copied, the tree is
+// left as it is.
+func (n *Node) Filter(predicate func(node *Node) bool) *Node {
+	if n == nil {
+		return nil
+	}
+	var children []*Node
+	for _, child := range n.Children {
+		if c := child.Filter(predicate); c != nil {
+			children = append(children, c)
+		}
+	}
+	if len(children) == 0 && !predicate(n) {
+		return nil
+	}
+	c := n.shallowCopy()
+	c.Children = children
+	return c
+}
+
+// HasTag tells whether tag is one of the node's tags
+func (n *Node) HasTag(tag string) bool {
+	for _, t := range n.Tags() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Selection selects the part of a tree to show, zero
+// values of its fields select the whole tree
+type Selection struct {
+	Root  string // ID of the node to show the branch of
+	Tag   string // show nodes with the tag and their ancestors
+	Depth int    // levels shown below the root, 0 shows all
+}
+
+// Apply returns the selected part of the tree, the tree itself if
+// nothing is selected, and an error if the root or tag is not found
+func (s Selection) Apply(root *Node) (*Node, error) {
+	if s.Root != "" {
+		root = root.Find(s.Root)
+		if root == nil {
+			return nil, fmt.Errorf("node not found: %s", s.Root)
+		}
+	}
+	if s.Tag != "" {
+		root = root.Filter(func(node *Node) bool { return node.HasTag(s.Tag) })
+		if root == nil {
+			return nil, fmt.Errorf("no node tagged %s", s.Tag)
+		}
+	}
+	if s.Depth > 0 {
+		root = root.Prune(s.Depth)
+	}
+	return root, nil
+}
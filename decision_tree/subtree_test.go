@@ -0,0 +1,108 @@
+package decision_tree
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func subtreeTestTree() *Node {
+	return &Node{
+		ID: "root",
+		Children: []*Node{
+			{ID: "a", Conditions: map[string]any{"tags": []string{"happy_flow"}}, Children: []*Node{
+				{ID: "a1", Children: []*Node{{ID: "a1x"}, {ID: "a1y"}}},
+				{ID: "a2", Conditions: map[string]any{"tags": "happy_flow"}},
+			}},
+			{ID: "b", Children: []*Node{
+				{ID: "b1", Conditions: map[string]any{"tags": []any{"error", "happy_flow"}}},
+				{ID: "b2"},
+			}},
+		},
+	}
+}
+
+// ids lists the IDs of the tree in pre-order, "+" marks collapsed nodes
+func ids(node *Node) []string {
+	if node == nil {
+		return nil
+	}
+	list := []string{node.ID}
+	if node.Style != nil && node.Style.Class == CollapsedClass {
+		list[0] = node.Label
+	}
+	for _, child := range node.Children {
+		list = append(list, ids(child)...)
+	}
+	return list
+}
+
+func expectIDs(t *testing.T, node *Node, expect string) {
+	t.Helper()
+	data, _ := json.Marshal(ids(node))
+	if string(data) != expect {
+		t.Errorf("expect %s, got %s", expect, data)
+	}
+}
+
+func TestFind(t *testing.T) {
+	tree := subtreeTestTree()
+	if node := tree.Find("a1"); node == nil || node != tree.Children[0].Children[0] {
+		t.Errorf("expect the node in the tree, got %+v", node)
+	}
+	if node := tree.Find("missing"); node != nil {
+		t.Errorf("expect nil, got %+v", node)
+	}
+}
+
+func TestSubtree(t *testing.T) {
+	tree := subtreeTestTree()
+	sub := tree.Subtree("a1")
+	expectIDs(t, sub, `["a1","a1x","a1y"]`)
+	sub.Children = nil
+	if len(tree.Find("a1").Children) != 2 {
+		t.Errorf("expect a copy")
+	}
+	if tree.Subtree("missing") != nil {
+		t.Errorf("expect nil for missing node")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tree := subtreeTestTree()
+	expectIDs(t, tree.Prune(0), `["root","+8 more"]`)
+	expectIDs(t, tree.Prune(1), `["root","a","+4 more","b","+2 more"]`)
+	expectIDs(t, tree.Prune(2), `["root","a","a1","+2 more","a2","b","b1","b2"]`)
+	expectIDs(t, tree.Prune(-1), `["root","a","a1","a1x","a1y","a2","b","b1","b2"]`)
+	if tree.Count() != 9 {
+		t.Errorf("expect the tree to be left as it is")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tree := subtreeTestTree()
+	expectIDs(t, tree.Filter(func(node *Node) bool { return node.HasTag("happy_flow") }), `["root","a","a2","b","b1"]`)
+	expectIDs(t, tree.Filter(func(node *Node) bool { return node.ID == "a1y" }), `["root","a","a1","a1y"]`)
+	if tree.Filter(func(node *Node) bool { return false }) != nil {
+		t.Errorf("expect nil when nothing matches")
+	}
+}
+
+func TestSelection(t *testing.T) {
+	tree := subtreeTestTree()
+	selected, err := Selection{Root: "a", Tag: "happy_flow", Depth: 1}.Apply(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectIDs(t, selected, `["a","a2"]`)
+
+	selected, err = Selection{}.Apply(tree)
+	if err != nil || selected != tree {
+		t.Errorf("expect the tree itself, got %v", err)
+	}
+	if _, err := (Selection{Root: "missing"}).Apply(tree); err == nil {
+		t.Errorf("expect error for missing root")
+	}
+	if _, err := (Selection{Tag: "missing"}).Apply(tree); err == nil {
+		t.Errorf("expect error for missing tag")
+	}
+}
@@ -0,0 +1,9 @@
+package svg
+
+import "embed"
+
+// assetsFS holds the page served around the SVG, embedded
+// so the viewer works offline
+//
+//go:embed assets
+var assetsFS embed.FS
@@ -0,0 +1,529 @@
+(function () {
+  "use strict";
+
+  var state = {
+    items: [], // nodes in pre-order, matching data-index of the svg
+    collapsed: {}, // index -> true
+    selected: -1,
+    editorURL: "",
+    editable: false,
+    viewBox: null,
+    fitViewBox: null
+  };
+
+  var canvas = document.getElementById("canvas");
+  var details = document.getElementById("details");
+  var search = document.getElementById("search");
+  var searchCount = document.getElementById("search-count");
+  var clickSource = document.getElementById("click-source");
+
+  function fetchJSON(url) {
+    return fetch(url).then(function (resp) {
+      if (!resp.ok) {
+        return resp.text().then(function (text) {
+          throw new Error(text);
+        });
+      }
+      return resp.json();
+    });
+  }
+
+  function fetchText(url) {
+    return fetch(url).then(function (resp) {
+      return resp.text().then(function (text) {
+        if (!resp.ok) {
+          throw new Error(text);
+        }
+        return text;
+      });
+    });
+  }
+
+  // flatten numbers nodes in pre-order, the same as the renderer
+  function flatten(root) {
+    var items = [];
+    function visit(node, parent) {
+      var item = { node: node, index: items.length, parent: parent, children: [] };
+      items.push(item);
+      if (parent) {
+        parent.children.push(item);
+      }
+      (node.children || []).forEach(function (child) {
+        visit(child, item);
+      });
+    }
+    if (root) {
+      visit(root, null);
+    }
+    return items;
+  }
+
+  function load() {
+    // the query of the page, e.g. ?root=X&depth=2, selects the part of the tree shown
+    var query = window.location.search;
+    return Promise.all([fetchJSON("/config.json"), fetchJSON("/tree.json" + query), fetchText("/tree.svg" + query)])
+      .then(function (results) {
+        state.editorURL = results[0].editorURL || "";
+        state.editable = !!results[0].editable;
+        state.items = flatten(results[1]);
+        canvas.innerHTML = results[2];
+        var svg = canvas.querySelector("svg");
+        svg.removeAttribute("width");
+        svg.removeAttribute("height");
+        state.fitViewBox = readViewBox(svg);
+        if (!state.viewBox) {
+          state.viewBox = state.fitViewBox.slice();
+        }
+        applyViewBox();
+        if (state.selected >= state.items.length) {
+          state.selected = -1;
+        }
+        refresh();
+      })
+      .catch(function (err) {
+        canvas.innerHTML = "";
+        var div = document.createElement("div");
+        div.className = "error";
+        div.textContent = String(err.message || err);
+        canvas.appendChild(div);
+      });
+  }
+
+  function nodeElement(index) {
+    return canvas.querySelector('g.node[data-index="' + index + '"]');
+  }
+
+  function edgeElement(index) {
+    return canvas.querySelector('path.edge[data-index="' + index + '"]');
+  }
+
+  function edgeLabelElement(index) {
+    return canvas.querySelector('g.edge-label[data-index="' + index + '"]');
+  }
+
+  // refresh applies collapsed, search and selection state to the svg
+  function refresh() {
+    var query = search.value.trim().toLowerCase();
+    var matches = 0;
+    state.items.forEach(function (item) {
+      var hidden = false;
+      for (var p = item.parent; p; p = p.parent) {
+        if (state.collapsed[p.index]) {
+          hidden = true;
+          break;
+        }
+      }
+      var el = nodeElement(item.index);
+      var edge = edgeElement(item.index);
+      var edgeLabel = edgeLabelElement(item.index);
+      var match = query !== "" && matchNode(item.node, query);
+      if (match) {
+        matches++;
+      }
+      if (el) {
+        el.classList.toggle("hidden", hidden);
+        el.classList.toggle("collapsed", !!state.collapsed[item.index] && item.children.length > 0);
+        el.classList.toggle("match", match);
+        el.classList.toggle("selected", item.index === state.selected);
+      }
+      if (edge) {
+        edge.classList.toggle("hidden", hidden);
+      }
+      if (edgeLabel) {
+        edgeLabel.classList.toggle("hidden", hidden);
+      }
+    });
+    searchCount.textContent = query === "" ? "" : matches + " found";
+    renderDetails();
+  }
+
+  function matchNode(node, query) {
+    var texts = [node.id || "", node.label || "", node.edgeLabel || ""];
+    var conditions = node.conditions || {};
+    Object.keys(conditions).forEach(function (key) {
+      var value = conditions[key];
+      if (Array.isArray(value)) {
+        value.forEach(function (v) {
+          texts.push(String(v));
+        });
+      }
+      texts.push(key + "=" + formatValue(value));
+    });
+    return texts.some(function (text) {
+      return text.toLowerCase().indexOf(query) >= 0;
+    });
+  }
+
+  // expandToMatches expands collapsed ancestors of matched nodes
+  function expandToMatches() {
+    var query = search.value.trim().toLowerCase();
+    if (query === "") {
+      return;
+    }
+    state.items.forEach(function (item) {
+      if (matchNode(item.node, query)) {
+        for (var p = item.parent; p; p = p.parent) {
+          delete state.collapsed[p.index];
+        }
+      }
+    });
+  }
+
+  function formatValue(value) {
+    if (typeof value === "string") {
+      return value;
+    }
+    return JSON.stringify(value);
+  }
+
+  function sourceURL(node) {
+    if (!node.source || !node.source.file || !state.editorURL) {
+      return "";
+    }
+    return state.editorURL
+      .split("{file}").join(encodeURI(node.source.file))
+      .split("{line}").join(String(node.source.line || 1));
+  }
+
+  function renderDetails() {
+    details.innerHTML = "";
+    var item = state.items[state.selected];
+    if (!item) {
+      var hint = document.createElement("p");
+      hint.className = "hint";
+      hint.textContent = "Click a node to see its details, double click to collapse or expand its subtree.";
+      details.appendChild(hint);
+      return;
+    }
+    var node = item.node;
+    var title = document.createElement("h3");
+    title.textContent = node.label || node.id || "Node";
+    details.appendChild(title);
+    if (node.id) {
+      var id = document.createElement("div");
+      id.className = "id";
+      id.textContent = node.id;
+      details.appendChild(id);
+    }
+    if (node.edgeLabel) {
+      var edgeLabel = document.createElement("p");
+      edgeLabel.className = "edge-label";
+      edgeLabel.textContent = "Reached when " + node.edgeLabel;
+      details.appendChild(edgeLabel);
+    }
+
+    var conditions = node.conditions || {};
+    var keys = Object.keys(conditions).sort();
+    if (keys.length > 0) {
+      var heading = document.createElement("h4");
+      heading.textContent = "Conditions";
+      details.appendChild(heading);
+      var table = document.createElement("table");
+      keys.forEach(function (key) {
+        var row = table.insertRow();
+        row.insertCell().textContent = key;
+        row.insertCell().textContent = formatValue(conditions[key]);
+      });
+      details.appendChild(table);
+    }
+
+    if (node.source && node.source.file) {
+      var source = document.createElement("p");
+      var location = node.source.file + (node.source.line ? ":" + node.source.line : "");
+      var url = sourceURL(node);
+      if (url) {
+        var link = document.createElement("a");
+        link.href = url;
+        link.textContent = location;
+        source.appendChild(link);
+      } else {
+        source.textContent = location;
+      }
+      details.appendChild(source);
+    }
+
+    // nodes are addressed by ID when editing
+    if (state.editable && node.id) {
+      renderEditor(item);
+    }
+
+    if (item.children.length > 0) {
+      var toggle = document.createElement("button");
+      toggle.type = "button";
+      toggle.textContent = state.collapsed[item.index] ? "Expand subtree" : "Collapse subtree";
+      toggle.addEventListener("click", function () {
+        toggleCollapse(item.index);
+      });
+      details.appendChild(toggle);
+    }
+  }
+
+  // renderEditor renders a form editing the selected node through the REST API
+  function renderEditor(item) {
+    var node = item.node;
+    var form = document.createElement("form");
+    form.className = "editor";
+    var heading = document.createElement("h4");
+    heading.textContent = "Edit";
+    form.appendChild(heading);
+
+    function field(label, value, multiline) {
+      var wrapper = document.createElement("label");
+      wrapper.textContent = label;
+      var input = document.createElement(multiline ? "textarea" : "input");
+      input.value = value;
+      wrapper.appendChild(input);
+      form.appendChild(wrapper);
+      return input;
+    }
+    var style = node.style || {};
+    var idInput = field("ID", node.id || "");
+    var labelInput = field("Label", node.label || "");
+    var edgeLabelInput = item.parent ? field("Edge label", node.edgeLabel || "") : null;
+    var parentInput = item.parent ? field("Parent ID", item.parent.node.id || "") : null;
+    var conditionsInput = field("Conditions (JSON)", JSON.stringify(node.conditions || {}, null, 2), true);
+    var fillInput = field("Fill", style.fill || "");
+    var strokeInput = field("Stroke", style.stroke || "");
+
+    function button(text, onClick) {
+      var btn = document.createElement("button");
+      btn.type = "button";
+      btn.textContent = text;
+      btn.addEventListener("click", onClick);
+      form.appendChild(btn);
+    }
+
+    button("Save", function () {
+      var patch = {};
+      if (idInput.value !== (node.id || "")) {
+        patch.id = idInput.value;
+      }
+      if (labelInput.value !== (node.label || "")) {
+        patch.label = labelInput.value;
+      }
+      if (edgeLabelInput && edgeLabelInput.value !== (node.edgeLabel || "")) {
+        patch.edgeLabel = edgeLabelInput.value;
+      }
+      if (parentInput && parentInput.value !== (item.parent.node.id || "")) {
+        patch.parentID = parentInput.value;
+      }
+      var conditions;
+      try {
+        conditions = JSON.parse(conditionsInput.value || "{}");
+      } catch (err) {
+        alert("Invalid conditions JSON: " + err.message);
+        return;
+      }
+      if (JSON.stringify(conditions) !== JSON.stringify(node.conditions || {})) {
+        patch.conditions = conditions;
+      }
+      if (fillInput.value !== (style.fill || "") || strokeInput.value !== (style.stroke || "")) {
+        patch.style = Object.assign({}, style, { fill: fillInput.value, stroke: strokeInput.value });
+      }
+      api("PATCH", "/api/nodes/" + encodeURIComponent(node.id), patch);
+    });
+    button("Add child", function () {
+      var id = prompt("ID of the new node");
+      if (!id) {
+        return;
+      }
+      var label = prompt("Label of the new node", id);
+      api("POST", "/api/nodes", { parentID: node.id, node: { id: id, label: label || id } });
+    });
+    if (item.parent) {
+      button("Delete", function () {
+        if (confirm("Delete " + node.id + " and its subtree?")) {
+          state.selected = -1;
+          api("DELETE", "/api/nodes/" + encodeURIComponent(node.id));
+        }
+      });
+    }
+    details.appendChild(form);
+  }
+
+  function api(method, url, body) {
+    return fetch(url, {
+      method: method,
+      headers: { "Content-Type": "application/json" },
+      body: body === undefined ? undefined : JSON.stringify(body)
+    })
+      .then(function (resp) {
+        return resp.json().then(function (data) {
+          if (!resp.ok) {
+            throw new Error(data.error || resp.statusText);
+          }
+          return data;
+        });
+      })
+      .then(load)
+      .catch(function (err) {
+        alert(err.message || err);
+      });
+  }
+
+  function toggleCollapse(index) {
+    if (state.collapsed[index]) {
+      delete state.collapsed[index];
+    } else {
+      state.collapsed[index] = true;
+    }
+    refresh();
+  }
+
+  function indexOfEvent(event) {
+    var el = event.target.closest("g.node");
+    if (!el) {
+      return -1;
+    }
+    return parseInt(el.getAttribute("data-index"), 10);
+  }
+
+  // a single click is delayed, so a double click does not open the source
+  var clickTimer = null;
+  canvas.addEventListener("click", function (event) {
+    var index = indexOfEvent(event);
+    if (index < 0) {
+      return;
+    }
+    state.selected = index;
+    refresh();
+    clearTimeout(clickTimer);
+    clickTimer = setTimeout(function () {
+      var item = state.items[index];
+      var url = item && sourceURL(item.node);
+      if (url && clickSource.checked) {
+        window.location.href = url;
+      }
+    }, 250);
+  });
+
+  canvas.addEventListener("dblclick", function (event) {
+    var index = indexOfEvent(event);
+    if (index < 0) {
+      return;
+    }
+    clearTimeout(clickTimer);
+    toggleCollapse(index);
+  });
+
+  search.addEventListener("input", function () {
+    expandToMatches();
+    refresh();
+  });
+
+  document.getElementById("expand-all").addEventListener("click", function () {
+    state.collapsed = {};
+    refresh();
+  });
+
+  document.getElementById("collapse-all").addEventListener("click", function () {
+    state.collapsed = {};
+    state.items.forEach(function (item) {
+      if (item.parent && item.children.length > 0) {
+        state.collapsed[item.index] = true;
+      }
+    });
+    refresh();
+  });
+
+  // zoom and pan by changing the viewBox
+  function readViewBox(svg) {
+    var vb = (svg.getAttribute("viewBox") || "0 0 1000 1000").split(/[\s,]+/).map(Number);
+    return vb;
+  }
+
+  function applyViewBox() {
+    var svg = canvas.querySelector("svg");
+    if (svg && state.viewBox) {
+      svg.setAttribute("viewBox", state.viewBox.join(" "));
+    }
+  }
+
+  function zoom(factor, cx, cy) {
+    var vb = state.viewBox;
+    if (!vb) {
+      return;
+    }
+    if (cx === undefined) {
+      cx = vb[0] + vb[2] / 2;
+      cy = vb[1] + vb[3] / 2;
+    }
+    var w = vb[2] / factor;
+    var h = vb[3] / factor;
+    state.viewBox = [cx - (cx - vb[0]) / factor, cy - (cy - vb[1]) / factor, w, h];
+    applyViewBox();
+  }
+
+  // toSVGPoint converts client coordinates to viewBox coordinates
+  function toSVGPoint(clientX, clientY) {
+    var rect = canvas.getBoundingClientRect();
+    var vb = state.viewBox;
+    var scale = Math.max(vb[2] / rect.width, vb[3] / rect.height);
+    var offsetX = (rect.width * scale - vb[2]) / 2;
+    var offsetY = (rect.height * scale - vb[3]) / 2;
+    return {
+      x: vb[0] - offsetX + (clientX - rect.left) * scale,
+      y: vb[1] - offsetY + (clientY - rect.top) * scale,
+      scale: scale
+    };
+  }
+
+  canvas.addEventListener("wheel", function (event) {
+    if (!state.viewBox) {
+      return;
+    }
+    event.preventDefault();
+    var p = toSVGPoint(event.clientX, event.clientY);
+    zoom(event.deltaY < 0 ? 1.1 : 1 / 1.1, p.x, p.y);
+  }, { passive: false });
+
+  var pan = null;
+  canvas.addEventListener("mousedown", function (event) {
+    if (!state.viewBox || event.button !== 0 || event.target.closest("g.node")) {
+      return;
+    }
+    pan = { x: event.clientX, y: event.clientY, viewBox: state.viewBox.slice() };
+    canvas.classList.add("panning");
+  });
+  window.addEventListener("mousemove", function (event) {
+    if (!pan) {
+      return;
+    }
+    var scale = toSVGPoint(event.clientX, event.clientY).scale;
+    state.viewBox = [
+      pan.viewBox[0] - (event.clientX - pan.x) * scale,
+      pan.viewBox[1] - (event.clientY - pan.y) * scale,
+      pan.viewBox[2],
+      pan.viewBox[3]
+    ];
+    applyViewBox();
+  });
+  window.addEventListener("mouseup", function () {
+    pan = null;
+    canvas.classList.remove("panning");
+  });
+
+  document.getElementById("zoom-in").addEventListener("click", function () {
+    zoom(1.25);
+  });
+  document.getElementById("zoom-out").addEventListener("click", function () {
+    zoom(1 / 1.25);
+  });
+  document.getElementById("zoom-reset").addEventListener("click", function () {
+    if (state.fitViewBox) {
+      state.viewBox = state.fitViewBox.slice();
+      applyViewBox();
+    }
+  });
+
+  // live reload, the server sends an update event when the tree changes
+  if (window.EventSource) {
+    var events = new EventSource("/events");
+    events.addEventListener("update", function () {
+      load();
+    });
+  }
+
+  window.ddtView = { reload: load };
+  load();
+})();
@@ -0,0 +1,115 @@
+package svg
+
+import (
+	"crypto/sha256"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+// renderCache keeps the tree parsed from the served file and the svg
+// of the last rendered tree, so refreshing pages of an unchanged tree
+// skip reading, parsing and layout. A file is taken as unchanged when
+// its modification time and size are, or else when its content hashes
+// the same. It is a cache of whole renders: any change of the tree
+// lays out the whole tree again.
+// Trees are compared by identity, changed trees must be new values or
+// reset the cache.
+type renderCache struct {
+	mu sync.Mutex
+
+	filename string
+	fileStat fileStat
+	fileHash [sha256.Size]byte
+	fileTree *decision_tree.Node
+
+	svgTree      *decision_tree.Node
+	svgSelection decision_tree.Selection
+	svg          string
+}
+
+// fileStat identifies a version of a file without reading it
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFile(filename string) (fileStat, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return fileStat{}, err
+	}
+	return fileStat{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// stated returns the cached tree of the file if it has not been
+// modified since it was parsed, nil otherwise
+func (c *renderCache) stated(filename string, stat fileStat) *decision_tree.Node {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fileTree == nil || c.filename != filename || !c.fileStat.modTime.Equal(stat.modTime) || c.fileStat.size != stat.size {
+		return nil
+	}
+	return c.fileTree
+}
+
+// parsed returns the tree of the file's content, parsing it only if
+// the content changed since the last call. stat is the version of
+// the file data was read from.
+func (c *renderCache) parsed(filename string, stat fileStat, data []byte, parse func(data []byte) (*decision_tree.Node, error)) (*decision_tree.Node, error) {
+	hash := sha256.Sum256(data)
+	c.mu.Lock()
+	if c.fileTree != nil && c.filename == filename && c.fileHash == hash {
+		tree := c.fileTree
+		c.fileStat = stat
+		c.mu.Unlock()
+		return tree, nil
+	}
+	c.mu.Unlock()
+
+	tree, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.filename, c.fileStat, c.fileHash, c.fileTree = filename, stat, hash, tree
+	c.mu.Unlock()
+	return tree, nil
+}
+
+// render returns the svg of the selected part of tree, rendering it
+// only if it is not the selection rendered last. Rendering is
+// serialized, the layout engine of the renderer is not safe for
+// concurrent use.
+func (c *renderCache) render(renderer *Renderer, tree *decision_tree.Node, selection decision_tree.Selection) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.svgTree != tree || c.svgSelection != selection || tree == nil {
+		selected, err := selection.Apply(tree)
+		if err != nil {
+			return "", err
+		}
+		c.svg = renderer.RenderTree(selected)
+		c.svgTree, c.svgSelection = tree, selection
+	}
+	return c.svg, nil
+}
+
+// invalidate makes the next read of the file compare its content,
+// for writes that may keep the modification time, which has a coarse
+// resolution on some file systems
+func (c *renderCache) invalidate() {
+	c.mu.Lock()
+	c.fileStat = fileStat{}
+	c.mu.Unlock()
+}
+
+// reset drops the cached trees and svg
+func (c *renderCache) reset() {
+	c.mu.Lock()
+	c.filename, c.fileStat, c.fileHash, c.fileTree = "", fileStat{}, [sha256.Size]byte{}, nil
+	c.svgTree, c.svgSelection, c.svg = nil, decision_tree.Selection{}, ""
+	c.mu.Unlock()
+}
@@ -0,0 +1,87 @@
+package svg
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+func TestRenderCache(t *testing.T) {
+	var c renderCache
+	parses := 0
+	parse := func(data []byte) (*decision_tree.Node, error) {
+		parses++
+		return parseJSON(data)
+	}
+
+	stat := fileStat{modTime: time.Unix(1, 0), size: 13}
+	first, err := c.parsed("tree.json", stat, []byte(`{"id":"root"}`), parse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.stated("tree.json", stat) != first {
+		t.Errorf("expect the tree of an unmodified file without reading it")
+	}
+	touched := fileStat{modTime: time.Unix(2, 0), size: 13}
+	if c.stated("tree.json", touched) != nil || c.stated("other.json", stat) != nil {
+		t.Errorf("expect modified or other files to be read")
+	}
+	c.invalidate()
+	if c.stated("tree.json", stat) != nil {
+		t.Errorf("expect the file read after invalidate")
+	}
+	again, _ := c.parsed("tree.json", touched, []byte(`{"id":"root"}`), parse)
+	if again != first || parses != 1 {
+		t.Errorf("expect unchanged content parsed once, parsed %d times", parses)
+	}
+	changed, _ := c.parsed("tree.json", fileStat{modTime: time.Unix(3, 0), size: 16}, []byte(`{"id":"changed"}`), parse)
+	if changed == first || changed.ID != "changed" || parses != 2 {
+		t.Errorf("expect changed content parsed again")
+	}
+
+	renderer := NewRenderer(decision_tree.DefaultConfig())
+	render := func(tree *decision_tree.Node, selection decision_tree.Selection) string {
+		svg, err := c.render(renderer, tree, selection)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return svg
+	}
+	svg := render(changed, decision_tree.Selection{})
+	if !strings.Contains(svg, `data-id="changed"`) {
+		t.Errorf("unexpected svg: %s", svg)
+	}
+	// in place changes are only seen after a reset
+	changed.ID = "in_place"
+	if render(changed, decision_tree.Selection{}) != svg {
+		t.Errorf("expect the cached svg")
+	}
+	c.reset()
+	if !strings.Contains(render(changed, decision_tree.Selection{}), `data-id="in_place"`) {
+		t.Errorf("expect the svg rendered again after reset")
+	}
+
+	// other selections of the same tree are rendered
+	changed.Children = []*decision_tree.Node{{ID: "child"}}
+	if svg := render(changed, decision_tree.Selection{Root: "child"}); strings.Contains(svg, `data-id="in_place"`) {
+		t.Errorf("expect only the selected branch: %s", svg)
+	}
+	if _, err := c.render(renderer, changed, decision_tree.Selection{Root: "missing"}); err == nil {
+		t.Errorf("expect error for missing root")
+	}
+}
+
+func TestRenderMaxNodes(t *testing.T) {
+	tree := &decision_tree.Node{ID: "root"}
+	for _, id := range []string{"a", "b", "c"} {
+		tree.Children = append(tree.Children, &decision_tree.Node{ID: id})
+	}
+	config := decision_tree.DefaultConfig()
+	config.MaxNodes = 2
+	svg := NewRenderer(config).RenderTree(tree)
+	if !strings.Contains(svg, "+3 more") || strings.Contains(svg, `data-id="a"`) {
+		t.Errorf("expect the children collapsed: %s", svg)
+	}
+}
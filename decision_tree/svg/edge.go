@@ -0,0 +1,35 @@
+package svg
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree/layout"
+)
+
+// edgePath returns the svg path data of the edge
+func edgePath(e layout.Curve) string {
+	if e.Curved {
+		return fmt.Sprintf("M %f %f C %f %f, %f %f, %f %f", e.Start.X, e.Start.Y, e.Control1.X, e.Control1.Y, e.Control2.X, e.Control2.Y, e.End.X, e.End.Y)
+	}
+	return fmt.Sprintf("M %f %f L %f %f", e.Start.X, e.Start.Y, e.End.X, e.End.Y)
+}
+
+// renderEdgeLabels renders labels of edges on a white background,
+// placed by the layout so they do not cover nodes or each other
+func (r *Renderer) renderEdgeLabels(sb *strings.Builder, root *layout.LayoutNode, indexes map[*layout.LayoutNode]int) {
+	text := layout.NewTextStyle(r.config)
+	const padding = layout.EdgeLabelPadding
+	for _, label := range r.layout.PlaceEdgeLabels(root) {
+		box := label.Box
+		sb.WriteString(fmt.Sprintf(`<g class="edge-label" data-index="%d">`, indexes[label.Child]))
+		sb.WriteString(fmt.Sprintf(`<rect x="%f" y="%f" width="%f" height="%f" fill="white" fill-opacity="0.9" rx="2" ry="2"/>`,
+			box.X, box.Y, box.Width, box.Height))
+		for i, line := range label.Child.EdgeLabelLines {
+			sb.WriteString(fmt.Sprintf(`<text x="%f" y="%f" text-anchor="middle" dominant-baseline="middle" font-size="%g" fill="#333" font-family="%s">%s</text>`,
+				box.X+box.Width/2, box.Y+padding/2+(float64(i)+0.5)*text.ConditionLineHeight, text.ConditionFontSize, html.EscapeString(text.FontFamily), html.EscapeString(line)))
+		}
+		sb.WriteString("</g>")
+	}
+}
@@ -0,0 +1,216 @@
+package svg
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+// SetEditable enables the REST API for editing the tree:
+//
+//	POST   /api/nodes       {"parentID": "root", "index": 0, "node": {"id": "a", "label": "A"}}
+//	PATCH  /api/nodes/{id}  decision_tree.NodePatch, rename, relabel, re-parent, conditions and style
+//	DELETE /api/nodes/{id}
+//
+// In file mode, each change is written back to the file atomically,
+// and open pages re-render through the file watcher. Requests must be
+// application/json, be addressed to 127.0.0.1, localhost or [::1]
+// on the server's port, and come from the page itself, so other sites
+// cannot edit the tree through the browser.
+func (s *Server) SetEditable(editable bool) {
+	s.mu.Lock()
+	s.editable = editable
+	s.mu.Unlock()
+}
+
+// checkAPIRequest rejects requests other sites can make from a
+// browser: cross origin ones, and forms or plain text posts, which
+// browsers send without asking the server first. The Host must be
+// the loopback address the server listens on, so a site whose name
+// resolves to 127.0.0.1 (DNS rebinding) is not taken for the page.
+func checkAPIRequest(r *http.Request) error {
+	if !isLoopbackHost(r) {
+		return fmt.Errorf("unexpected host %q", r.Host)
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return fmt.Errorf("requires Content-Type application/json, got %q", r.Header.Get("Content-Type"))
+	}
+	// browsers set Origin on POST, PATCH and DELETE, other clients may not
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme != "http" || u.Host != r.Host {
+			return fmt.Errorf("cross origin request from %s", origin)
+		}
+	}
+	return nil
+}
+
+// isLoopbackHost reports whether r.Host names the loopback address
+// on the port the request was received on
+func isLoopbackHost(r *http.Request) bool {
+	host, port, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		return false
+	}
+	switch host {
+	case "127.0.0.1", "localhost", "::1":
+	default:
+		return false
+	}
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	if !ok {
+		return false
+	}
+	_, localPort, err := net.SplitHostPort(addr.String())
+	return err == nil && port == localPort
+}
+
+// addNodeRequest is the body of POST /api/nodes
+type addNodeRequest struct {
+	ParentID string              `json:"parentID"`
+	Index    *int                `json:"index,omitempty"`
+	Node     *decision_tree.Node `json:"node"`
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	editable := s.editable
+	s.mu.RUnlock()
+	if !editable {
+		writeError(w, http.StatusForbidden, fmt.Errorf("editing is disabled"))
+		return
+	}
+	if err := checkAPIRequest(r); err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+
+	id, err := url.PathUnescape(strings.TrimPrefix(strings.TrimPrefix(r.URL.EscapedPath(), "/api/nodes"), "/"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var edit func(tree *decision_tree.Node) error
+	switch {
+	case r.Method == http.MethodPost && id == "":
+		var req addNodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("parse body: %v", err))
+			return
+		}
+		index := -1
+		if req.Index != nil {
+			index = *req.Index
+		}
+		edit = func(tree *decision_tree.Node) error {
+			return tree.AddChild(req.ParentID, index, req.Node)
+		}
+	case r.Method == http.MethodPatch && id != "":
+		var patch decision_tree.NodePatch
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("parse body: %v", err))
+			return
+		}
+		edit = func(tree *decision_tree.Node) error {
+			return tree.Update(id, &patch)
+		}
+	case r.Method == http.MethodDelete && id != "":
+		edit = func(tree *decision_tree.Node) error {
+			return tree.Remove(id)
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("unsupported: %s %s", r.Method, r.URL.Path))
+		return
+	}
+
+	tree, status, err := s.editTree(edit)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, tree)
+}
+
+// editTree applies edit to a copy of the current tree and saves it,
+// edits are serialized so concurrent requests do not lose changes
+func (s *Server) editTree(edit func(tree *decision_tree.Node) error) (*decision_tree.Node, int, error) {
+	s.editMu.Lock()
+	defer s.editMu.Unlock()
+
+	tree, status, err := s.currentTree()
+	if err != nil {
+		return nil, status, err
+	}
+	tree = tree.Clone()
+	if err := edit(tree); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	s.mu.RLock()
+	source := s.source
+	filename := s.filename
+	s.mu.RUnlock()
+	if source == sourceFile {
+		data, err := json.MarshalIndent(tree, "", "  ")
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		err = writeFileAtomic(filename, append(data, '\n'))
+		s.cache.invalidate()
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		return tree, http.StatusOK, nil
+	}
+	s.UpdateTree(tree)
+	return tree, http.StatusOK, nil
+}
+
+// writeFileAtomic writes to a temp file in the same directory and
+// renames it over filename, so readers never see a partial file
+func writeFileAtomic(filename string, data []byte) error {
+	perm := os.FileMode(0644)
+	if stat, err := os.Stat(filename); err == nil {
+		perm = stat.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op after rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, filename)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	setNoCache(w)
+	w.WriteHeader(status)
+	w.Write(data)
+}
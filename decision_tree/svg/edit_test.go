@@ -0,0 +1,144 @@
+package svg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+func TestServerEdit(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tree.json")
+	if err := os.WriteFile(file, []byte(`{"id":"root","label":"Root","children":[{"id":"a","label":"A"}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(nil)
+	portCh := make(chan int, 1)
+	server.SetPortNotifier(portCh)
+	go func() {
+		if err := server.ServeFile(file); err != nil && err != http.ErrServerClosed {
+			t.Errorf("server error: %v", err)
+		}
+	}()
+	var port int
+	select {
+	case port = <-portCh:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for server to start")
+	}
+	defer server.Stop()
+
+	requestWithHeaders := func(method string, path string, body string, headers map[string]string) (int, string) {
+		req, err := http.NewRequest(method, fmt.Sprintf("http://127.0.0.1:%d%s", port, path), strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range headers {
+			if k == "Host" {
+				req.Host = v
+				continue
+			}
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(data)
+	}
+	request := func(method string, path string, body string) (int, string) {
+		return requestWithHeaders(method, path, body, map[string]string{
+			"Content-Type": "application/json",
+			"Origin":       fmt.Sprintf("http://127.0.0.1:%d", port),
+		})
+	}
+	readFile := func() *decision_tree.Node {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tree *decision_tree.Node
+		if err := json.Unmarshal(data, &tree); err != nil {
+			t.Fatalf("invalid file: %v", err)
+		}
+		return tree
+	}
+
+	if status, _ := request("DELETE", "/api/nodes/a", ""); status != http.StatusForbidden {
+		t.Errorf("expect editing disabled by default, got status %d", status)
+	}
+	server.SetEditable(true)
+
+	// other sites must not edit the file through the visitor's browser
+	crossOrigin := []map[string]string{
+		{"Content-Type": "text/plain", "Origin": "https://evil.example"},
+		{"Content-Type": "application/json", "Origin": "https://evil.example"},
+		{"Content-Type": "text/plain"},
+		{"Content-Type": "application/x-www-form-urlencoded"},
+		// DNS rebinding: evil resolves to 127.0.0.1, Origin matches Host
+		{"Content-Type": "application/json", "Host": fmt.Sprintf("evil:%d", port), "Origin": fmt.Sprintf("http://evil:%d", port)},
+		{"Content-Type": "application/json", "Host": "127.0.0.1:1", "Origin": "http://127.0.0.1:1"},
+	}
+	for _, headers := range crossOrigin {
+		status, body := requestWithHeaders("POST", "/api/nodes", `{"parentID":"root","node":{"id":"pwned","label":"pwned"}}`, headers)
+		if status != http.StatusForbidden {
+			t.Errorf("expect %v rejected, got: %d %s", headers, status, body)
+		}
+	}
+	if tree := readFile(); tree.Find("pwned") != nil {
+		t.Fatalf("expect file unchanged by rejected requests")
+	}
+	if resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/tree.json", port)); err != nil {
+		t.Fatal(err)
+	} else {
+		resp.Body.Close()
+		if origin := resp.Header.Get("Access-Control-Allow-Origin"); origin != "" {
+			t.Errorf("expect no CORS header, actual: %q", origin)
+		}
+	}
+
+	if status, body := request("POST", "/api/nodes", `{"parentID":"a","node":{"id":"a/1","label":"A1"}}`); status != http.StatusOK {
+		t.Fatalf("add failed: %d %s", status, body)
+	}
+	if status, body := request("PATCH", "/api/nodes/a%2F1", `{"id":"b","label":"B","parentID":"root","conditions":{"k":"v"}}`); status != http.StatusOK {
+		t.Fatalf("update failed: %d %s", status, body)
+	}
+	if status, body := request("DELETE", "/api/nodes/a", ""); status != http.StatusOK {
+		t.Fatalf("remove failed: %d %s", status, body)
+	}
+	if status, body := request("DELETE", "/api/nodes/root", ""); status != http.StatusBadRequest || !strings.Contains(body, "cannot remove root") {
+		t.Errorf("expect error removing root, got: %d %s", status, body)
+	}
+
+	tree := readFile()
+	if len(tree.Children) != 1 {
+		t.Fatalf("expect 1 child, actual: %d", len(tree.Children))
+	}
+	b := tree.Children[0]
+	if b.ID != "b" || b.Label != "B" || b.Conditions["k"] != "v" {
+		t.Errorf("unexpected node: %+v", b)
+	}
+	if stat, err := os.Stat(file); err != nil || stat.Mode().Perm() != 0600 {
+		t.Errorf("expect file mode kept, actual: %v %v", stat.Mode(), err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expect no temp files left, actual: %d entries", len(entries))
+	}
+}
@@ -0,0 +1,146 @@
+package svg
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce merges bursts of file events, editors
+// usually write a file with several events
+const reloadDebounce = 100 * time.Millisecond
+
+// broadcaster notifies subscribed pages that the tree changed
+type broadcaster struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+}
+
+func newBroadcaster() *broadcaster {
+	return &broadcaster{clients: make(map[chan struct{}]bool)}
+}
+
+func (b *broadcaster) subscribe() chan struct{} {
+	// buffered, so pending updates collapse into one
+	ch := make(chan struct{}, 1)
+	b.mu.Lock()
+	b.clients[ch] = true
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *broadcaster) unsubscribe(ch chan struct{}) {
+	b.mu.Lock()
+	delete(b.clients, ch)
+	b.mu.Unlock()
+}
+
+func (b *broadcaster) notify() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// serveEvents streams an `update` Server-Sent Event whenever the tree changes
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	setNoCache(w)
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := s.updates.subscribe()
+	defer s.updates.unsubscribe(ch)
+
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+	for {
+		select {
+		case <-ch:
+			fmt.Fprint(w, "event: update\ndata: {}\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-s.shutdownCh:
+			// let Shutdown finish without waiting for the stream
+			return
+		}
+	}
+}
+
+// watchFile notifies pages when the served file changes, the directory
+// is watched so that files replaced by rename are noticed as well
+func (s *Server) watchFile() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "live reload disabled: %v\n", err)
+		return
+	}
+	defer watcher.Close()
+
+	var watchedDir string
+	watch := func() string {
+		s.mu.RLock()
+		filename := s.filename
+		s.mu.RUnlock()
+		absFile, err := filepath.Abs(filename)
+		if err != nil {
+			return filename
+		}
+		dir := filepath.Dir(absFile)
+		if dir != watchedDir {
+			if watchedDir != "" {
+				watcher.Remove(watchedDir)
+			}
+			if err := watcher.Add(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "live reload disabled: %v\n", err)
+			}
+			watchedDir = dir
+		}
+		return absFile
+	}
+	absFile := watch()
+
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != absFile {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				s.cache.invalidate()
+				debounce.Reset(reloadDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "watcher error: %v\n", err)
+		case <-s.fileChangedCh:
+			absFile = watch()
+			s.updates.notify()
+		case <-debounce.C:
+			s.updates.notify()
+		case <-s.shutdownCh:
+			return
+		}
+	}
+}
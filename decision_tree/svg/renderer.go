@@ -2,6 +2,7 @@ package svg
 
 import (
 	"fmt"
+	"html"
 	"strings"
 
 	"github.com/xhd2015/data-driven-testing/decision_tree"
@@ -33,7 +34,9 @@ func (r *Renderer) RenderTree(root *decision_tree.Node) string {
 	layoutRoot := r.layout.CalculateLayout(root)
 
 	// Find bounds
-	minX, minY, maxX, maxY := r.findBounds(layoutRoot)
+	bounds := r.layout.Bounds(layoutRoot)
+	minX, minY := bounds.X, bounds.Y
+	maxX, maxY := bounds.X+bounds.Width, bounds.Y+bounds.Height
 
 	// Add asymmetric padding for left alignment
 	leftPadding := 20.0   // smaller padding on left
@@ -49,8 +52,15 @@ func (r *Renderer) RenderTree(root *decision_tree.Node) string {
 	width := maxX - minX
 	height := maxY - minY
 
-	// Generate SVG with viewBox for proper scaling
+	// Index nodes in pre-order, so the page script
+	// can match elements with nodes of the tree
+	indexes := make(map[*layout.LayoutNode]int)
+	indexLayoutNodes(layoutRoot, indexes)
+
+	// Generate SVG with viewBox for proper scaling, growing the
+	// builder once instead of copying large trees many times
 	var sb strings.Builder
+	sb.Grow(svgHeaderSize + len(indexes)*svgNodeSize)
 	sb.WriteString(fmt.Sprintf(`<svg width="%f" height="%f" viewBox="%f %f %f %f" xmlns="http://www.w3.org/2000/svg">`,
 		width, height, minX, minY, width, height))
 
@@ -67,167 +77,97 @@ func (r *Renderer) RenderTree(root *decision_tree.Node) string {
 		minX, minY, width, height))
 
 	// Render all edges first (so they appear behind nodes)
-	r.renderEdges(&sb, layoutRoot)
+	r.renderEdges(&sb, layoutRoot, indexes)
+	r.renderEdgeLabels(&sb, layoutRoot, indexes)
 
 	// Render all nodes
-	r.renderNodes(&sb, layoutRoot)
+	r.renderNodes(&sb, layoutRoot, indexes)
 
 	sb.WriteString("</svg>")
 	return sb.String()
 }
 
-// findBounds calculates the bounding box of the tree
-func (r *Renderer) findBounds(node *layout.LayoutNode) (minX, minY, maxX, maxY float64) {
-	if node == nil {
-		return 0, 0, 0, 0
-	}
-
-	minX = node.X - node.Width/2
-	maxX = node.X + node.Width/2
-	minY = node.Y
-	maxY = node.Y + node.Height
-
-	// Include space for conditions text
-	if len(node.Node.Conditions) > 0 {
-		if len(node.Children) == 0 {
-			// For terminal nodes, add space below
-			maxY += 30 // Space for conditions text
-		} else {
-			// For non-terminal nodes, add space above
-			minY -= 20
-		}
-	}
-
-	for _, child := range node.Children {
-		childMinX, childMinY, childMaxX, childMaxY := r.findBounds(child)
-		if childMinX < minX {
-			minX = childMinX
-		}
-		if childMinY < minY {
-			minY = childMinY
-		}
-		if childMaxX > maxX {
-			maxX = childMaxX
-		}
-		if childMaxY > maxY {
-			maxY = childMaxY
-		}
-	}
-
-	return minX, minY, maxX, maxY
-}
-
-// wrapText splits text into lines of maximum length
-func (r *Renderer) wrapText(text string, maxChars int) []string {
-	if len(text) <= maxChars {
-		return []string{text}
-	}
-
-	var lines []string
-	words := strings.Fields(text)
-	currentLine := words[0]
-
-	for _, word := range words[1:] {
-		if len(currentLine)+1+len(word) <= maxChars {
-			currentLine += " " + word
-		} else {
-			lines = append(lines, currentLine)
-			currentLine = word
-		}
-	}
-	lines = append(lines, currentLine)
-	return lines
-}
+// svgHeaderSize and svgNodeSize estimate the output size,
+// a node with its edge, label and conditions takes about 1KB
+const (
+	svgHeaderSize = 512
+	svgNodeSize   = 1024
+)
 
 // renderNodes renders all nodes in the tree
-func (r *Renderer) renderNodes(sb *strings.Builder, node *layout.LayoutNode) {
+func (r *Renderer) renderNodes(sb *strings.Builder, node *layout.LayoutNode, indexes map[*layout.LayoutNode]int) {
 	if node == nil {
 		return
 	}
+	sb.WriteString(fmt.Sprintf(`<g class="node" data-index="%d" data-id="%s">`, indexes[node], html.EscapeString(node.Node.ID)))
 
-	// Get node style
+	// Get node style, resolved by the layout
 	style := node.Style
 	if style == nil {
-		style = r.config.DefaultStyle
+		style = r.config.NodeStyle(node.Node)
 	}
+	sb.WriteString(shapeElement(node, style))
 
-	// Render node
-	x := node.X - node.Width/2
-	y := node.Y
-
-	// Node shape - use rounded corners only for terminal nodes
-	if len(node.Children) == 0 {
-		// Terminal nodes get rounded corners
-		sb.WriteString(fmt.Sprintf(`<rect x="%f" y="%f" width="%f" height="%f" 
-			fill="%s" stroke="%s" stroke-width="%d" rx="3" ry="3"/>`,
-			x, y, node.Width, node.Height,
-			style.Fill, style.Stroke, style.StrokeWidth))
-	} else {
-		// Non-terminal nodes get sharp corners
-		sb.WriteString(fmt.Sprintf(`<rect x="%f" y="%f" width="%f" height="%f" 
-			fill="%s" stroke="%s" stroke-width="%d"/>`,
-			x, y, node.Width, node.Height,
-			style.Fill, style.Stroke, style.StrokeWidth))
-	}
-
-	// Wrap and render node text
-	lines := r.wrapText(node.Node.Label, 20)
-	lineHeight := 15.0 // Reduced line height
+	// Render node text, wrapped by the layout
+	text := layout.NewTextStyle(r.config)
+	fontFamily := html.EscapeString(text.FontFamily)
+	lines := node.LabelLines
+	lineHeight := text.LineHeight
 	startY := node.Y + (node.Height-float64(len(lines))*lineHeight)/2 + lineHeight/2
 	for i, line := range lines {
-		sb.WriteString(fmt.Sprintf(`<text x="%f" y="%f" text-anchor="middle" dominant-baseline="middle" font-family="Arial" font-size="12">%s</text>`,
-			node.X, startY+float64(i)*lineHeight, line))
+		sb.WriteString(fmt.Sprintf(`<text x="%f" y="%f" text-anchor="middle" dominant-baseline="middle" font-family="%s" font-size="%g">%s</text>`,
+			node.X, startY+float64(i)*lineHeight, fontFamily, text.FontSize, html.EscapeString(line)))
 	}
 
 	// Render conditions if any
-	if len(node.Node.Conditions) > 0 {
-		conditions := make([]string, 0, len(node.Node.Conditions))
-		for k, v := range node.Node.Conditions {
-			conditions = append(conditions, fmt.Sprintf("%s=%v", k, v))
-		}
-		condText := strings.Join(conditions, ", ")
-		// Wrap conditions text
-		condLines := r.wrapText(condText, 20)
-
+	if condLines := node.ConditionLines; len(condLines) > 0 {
+		condLineHeight := text.ConditionLineHeight
 		if len(node.Children) == 0 {
 			// For terminal nodes, render conditions below the node
 			for i, line := range condLines {
-				sb.WriteString(fmt.Sprintf(`<text x="%f" y="%f" text-anchor="middle" font-size="10" fill="#666" font-family="Arial">%s</text>`,
-					node.X, node.Y+node.Height+12+float64(i)*12, line))
+				sb.WriteString(fmt.Sprintf(`<text x="%f" y="%f" text-anchor="middle" font-size="%g" fill="#666" font-family="%s">%s</text>`,
+					node.X, node.Y+node.Height+condLineHeight+float64(i)*condLineHeight, text.ConditionFontSize, fontFamily, html.EscapeString(line)))
 			}
 		} else {
 			// For non-terminal nodes, render conditions above the node
 			for i, line := range condLines {
-				sb.WriteString(fmt.Sprintf(`<text x="%f" y="%f" text-anchor="middle" font-size="10" fill="#666" font-family="Arial">%s</text>`,
-					node.X, node.Y-3-float64(len(condLines)-1-i)*12, line))
+				sb.WriteString(fmt.Sprintf(`<text x="%f" y="%f" text-anchor="middle" font-size="%g" fill="#666" font-family="%s">%s</text>`,
+					node.X, node.Y-3-float64(len(condLines)-1-i)*condLineHeight, text.ConditionFontSize, fontFamily, html.EscapeString(line)))
 			}
 		}
 	}
 
+	sb.WriteString("</g>")
+
 	// Render children
 	for _, child := range node.Children {
-		r.renderNodes(sb, child)
+		r.renderNodes(sb, child, indexes)
+	}
+}
+
+// indexLayoutNodes numbers nodes in pre-order
+func indexLayoutNodes(node *layout.LayoutNode, indexes map[*layout.LayoutNode]int) {
+	if node == nil {
+		return
+	}
+	indexes[node] = len(indexes)
+	for _, child := range node.Children {
+		indexLayoutNodes(child, indexes)
 	}
 }
 
 // renderEdges renders all edges in the tree
-func (r *Renderer) renderEdges(sb *strings.Builder, node *layout.LayoutNode) {
+func (r *Renderer) renderEdges(sb *strings.Builder, node *layout.LayoutNode, indexes map[*layout.LayoutNode]int) {
 	if node == nil {
 		return
 	}
 
 	for _, child := range node.Children {
-		// Calculate edge points
-		startX := node.X
-		startY := node.Y + node.Height
-		endX := child.X
-		endY := child.Y
-
-		// Draw straight edge with arrow
-		sb.WriteString(fmt.Sprintf(`<path d="M %f %f L %f %f" stroke="black" stroke-width="1" 
+		// Draw edge with arrow, marked with the child index
+		sb.WriteString(fmt.Sprintf(`<path class="edge" data-index="%d" d="%s" stroke="black" stroke-width="1" 
 			fill="none" marker-end="url(#arrowhead)"/>`,
-			startX, startY, endX, endY))
+			indexes[child], edgePath(r.layout.Edge(node, child))))
 
-		r.renderEdges(sb, child)
+		r.renderEdges(sb, child, indexes)
 	}
 }
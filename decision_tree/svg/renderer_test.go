@@ -0,0 +1,163 @@
+package svg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/layout"
+)
+
+func TestRenderOrientation(t *testing.T) {
+	tree := &decision_tree.Node{
+		ID:    "root",
+		Label: "Root",
+		Children: []*decision_tree.Node{
+			{ID: "a", Label: "A"},
+			{ID: "b", Label: "B"},
+		},
+	}
+	edgePath := regexp.MustCompile(`class="edge" data-index="\d+" d="([^"]*)"`)
+	tests := []struct {
+		orientation decision_tree.Orientation
+		edge        *regexp.Regexp
+	}{
+		{decision_tree.TopDown, regexp.MustCompile(`^M \S+ \S+ L \S+ \S+$`)},
+		{decision_tree.LeftRight, regexp.MustCompile(`^M \S+ \S+ C \S+ \S+, \S+ \S+, \S+ \S+$`)},
+		{decision_tree.Radial, regexp.MustCompile(`^M \S+ \S+ L \S+ \S+$`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.orientation.String(), func(t *testing.T) {
+			config := decision_tree.DefaultConfig()
+			config.Orientation = tt.orientation
+			svg := NewRenderer(config).RenderTree(tree)
+			edges := edgePath.FindAllStringSubmatch(svg, -1)
+			if len(edges) != 2 {
+				t.Fatalf("expected 2 edges, got %d", len(edges))
+			}
+			for _, edge := range edges {
+				if !tt.edge.MatchString(edge[1]) {
+					t.Errorf("unexpected edge path %q", edge[1])
+				}
+			}
+			if !strings.Contains(svg, ">Root</text>") {
+				t.Error("missing root node")
+			}
+		})
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	tree := &decision_tree.Node{
+		ID:         "root",
+		Label:      "A & B",
+		Conditions: map[string]any{"b": 2, "a": 1},
+	}
+	config := decision_tree.DefaultConfig()
+	config.FontFamily = "DejaVu Sans"
+	config.FontSize = 14
+	config.ConditionFontSize = 11
+	svg := NewRenderer(config).RenderTree(tree)
+	for _, want := range []string{
+		`font-family="DejaVu Sans" font-size="14">A &amp; B</text>`,
+		`font-size="11" fill="#666" font-family="DejaVu Sans">a=1, b=2</text>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("expected %s in:\n%s", want, svg)
+		}
+	}
+}
+
+func TestRenderEdgeLabels(t *testing.T) {
+	tree := &decision_tree.Node{
+		ID:    "root",
+		Label: "Feature Status Check",
+		Children: []*decision_tree.Node{
+			{ID: "frozen", Label: "Feature Frozen", EdgeLabel: "feature_state=2"},
+			{ID: "inactive", Label: "Feature Inactive", EdgeLabel: "feature_state=4"},
+			{ID: "active", Label: "Feature Active", EdgeLabel: "feature_state=1 & user_state=3"},
+		},
+	}
+	label := regexp.MustCompile(`<g class="edge-label" data-index="(\d+)"><rect x="(\S+)" y="(\S+)" width="(\S+)" height="(\S+)"`)
+	node := regexp.MustCompile(`<g class="node" data-index="\d+" data-id="[^"]*"><rect x="(\S+)" y="(\S+)" width="(\S+)" height="(\S+)"`)
+	parse := func(m []string) layout.Rect {
+		var values [4]float64
+		for i := range values {
+			if _, err := fmt.Sscan(m[len(m)-4+i], &values[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return layout.Rect{X: values[0], Y: values[1], Width: values[2], Height: values[3]}
+	}
+	for _, orientation := range []decision_tree.Orientation{decision_tree.TopDown, decision_tree.LeftRight, decision_tree.Radial} {
+		t.Run(orientation.String(), func(t *testing.T) {
+			config := decision_tree.DefaultConfig()
+			config.Orientation = orientation
+			svg := NewRenderer(config).RenderTree(tree)
+			if !strings.Contains(svg, "feature_state=1 &amp; user_state=3</text>") {
+				t.Errorf("expected escaped edge label in:\n%s", svg)
+			}
+
+			var boxes []layout.Rect
+			for _, m := range node.FindAllStringSubmatch(svg, -1) {
+				boxes = append(boxes, parse(m))
+			}
+			labels := label.FindAllStringSubmatch(svg, -1)
+			if len(labels) != 3 {
+				t.Fatalf("expected 3 edge labels, got %d", len(labels))
+			}
+			for _, m := range labels {
+				if m[1] == "0" {
+					t.Error("edge labels are marked with the index of the child")
+				}
+				box := parse(m)
+				for _, other := range boxes {
+					if box.Overlaps(other) {
+						t.Errorf("edge label %v overlaps %v", box, other)
+					}
+				}
+				boxes = append(boxes, box)
+			}
+		})
+	}
+}
+
+func TestRenderShapes(t *testing.T) {
+	tree := &decision_tree.Node{
+		ID:    "root",
+		Label: "Check",
+		Style: &decision_tree.NodeStyle{Shape: decision_tree.ShapeDiamond},
+		Children: []*decision_tree.Node{
+			{ID: "ellipse", Label: "Ellipse", Style: &decision_tree.NodeStyle{Shape: decision_tree.ShapeEllipse, Fill: "#eeeeee"}},
+			{ID: "hexagon", Label: "Hexagon", Style: &decision_tree.NodeStyle{Shape: decision_tree.ShapeHexagon}},
+			{ID: "stadium", Label: "Stadium", Conditions: map[string]any{"tags": []string{"error"}}},
+		},
+	}
+	config := decision_tree.DefaultConfig()
+	config.StyleRules = []decision_tree.StyleRule{
+		{Tag: "error", Style: &decision_tree.NodeStyle{Shape: decision_tree.ShapeStadium, Fill: "#ffeef0"}},
+	}
+	svg := NewRenderer(config).RenderTree(tree)
+	for _, want := range []*regexp.Regexp{
+		regexp.MustCompile(`data-id="root"><polygon points="(\S+ ){3}\S+" fill="url\(#nodeGradient\)" stroke="#666666"`),
+		regexp.MustCompile(`data-id="ellipse"><ellipse [^>]*fill="#eeeeee" stroke="#666666"`),
+		regexp.MustCompile(`data-id="hexagon"><polygon points="(\S+ ){5}\S+" fill="url\(#nodeGradient\)"`),
+		regexp.MustCompile(`data-id="stadium"><rect [^>]*rx="27\.50+" ry="27\.50+" fill="#ffeef0"`),
+	} {
+		if !want.MatchString(svg) {
+			t.Errorf("expected %s in:\n%s", want, svg)
+		}
+	}
+
+	// styles can come from edits over http, they must not break out of attributes
+	tree.Children[1].Style.Fill = `"/><img src=x onerror=alert(1)>`
+	svg = NewRenderer(config).RenderTree(tree)
+	if strings.Contains(svg, "<img") {
+		t.Errorf("expected fill escaped in:\n%s", svg)
+	}
+	if !strings.Contains(svg, `fill="&#34;/&gt;&lt;img src=x onerror=alert(1)&gt;"`) {
+		t.Errorf("expected escaped fill attribute in:\n%s", svg)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -24,7 +26,24 @@ type Server struct {
 	source   serverSource        // indicates whether serving from memory or file
 	tree     *decision_tree.Node // in-memory tree
 	filename string              // file path when serving from file
-	mu       sync.RWMutex        // protects tree and filename
+	mu       sync.RWMutex        // protects tree, filename, parse, selection, editorURL and editable
+
+	// parse parses the file, nil for JSON
+	parse func(data []byte) (*decision_tree.Node, error)
+	// selection is the part of the tree shown by default
+	selection decision_tree.Selection
+
+	cache renderCache
+
+	// editorURL is the template of links to node sources,
+	// {file} and {line} are replaced
+	editorURL string
+	editable  bool
+	editMu    sync.Mutex // serializes edits
+
+	// live reload
+	updates       *broadcaster
+	fileChangedCh chan struct{} // signals watchFile that filename changed
 
 	// shutdown
 	shutdownCh chan struct{}
@@ -47,21 +66,54 @@ func NewServer(renderer *Renderer) *Server {
 		renderer = NewRenderer(decision_tree.DefaultConfig())
 	}
 	return &Server{
-		renderer:   renderer,
-		shutdownCh: make(chan struct{}),
-		doneCh:     make(chan struct{}),
+		renderer:      renderer,
+		editorURL:     DefaultEditorURL,
+		updates:       newBroadcaster(),
+		fileChangedCh: make(chan struct{}, 1),
+		shutdownCh:    make(chan struct{}),
+		doneCh:        make(chan struct{}),
 	}
 }
 
+// DefaultEditorURL opens node sources in VS Code
+const DefaultEditorURL = "vscode://file/{file}:{line}"
+
+// SetEditorURL sets the URL template used to open the source of a node,
+// {file} and {line} are replaced, e.g. "idea://open?file={file}&line={line}".
+// An empty template disables click-to-source.
+func (s *Server) SetEditorURL(template string) {
+	s.mu.Lock()
+	s.editorURL = template
+	s.mu.Unlock()
+}
+
+// SetFileParser sets the parser of the file served by ServeFile,
+// for files in other formats than JSON such as markdown specs
+func (s *Server) SetFileParser(parse func(data []byte) (*decision_tree.Node, error)) {
+	s.mu.Lock()
+	s.parse = parse
+	s.mu.Unlock()
+	s.cache.reset()
+}
+
+// SetSelection sets the part of the tree shown, query parameters
+// of requests override it, e.g. /?root=X&depth=2
+func (s *Server) SetSelection(selection decision_tree.Selection) {
+	s.mu.Lock()
+	s.selection = selection
+	s.mu.Unlock()
+}
+
 // SetPortNotifier sets a channel to receive the port number when the server starts.
 // This is primarily used for testing.
 func (s *Server) SetPortNotifier(ch chan<- int) {
 	s.portCh = ch
 }
 
-// tryListen attempts to listen on the given port
+// tryListen attempts to listen on the given port of the loopback
+// interface, the server edits local files and is not for other hosts
 func tryListen(port int) (net.Listener, error) {
-	return net.Listen("tcp", fmt.Sprintf(":%d", port))
+	return net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
 }
 
 // Serve starts serving the SVG content for the given tree.
@@ -74,13 +126,15 @@ func (s *Server) Serve(tree *decision_tree.Node) error {
 	s.tree = tree
 	s.filename = "" // clear filename if any
 	s.mu.Unlock()
+	s.cache.reset()
 
 	return s.startServer()
 }
 
 // ServeFile starts serving SVG content from the given JSON file.
 // The file should contain a JSON representation of a decision_tree.Node.
-// The server will read the file on each request to ensure latest content.
+// The server will read the file on each request to ensure latest content,
+// and watches the file to notify open pages when it changes.
 func (s *Server) ServeFile(filename string) error {
 	// Verify file exists and is readable
 	if _, err := os.Stat(filename); err != nil {
@@ -93,6 +147,7 @@ func (s *Server) ServeFile(filename string) error {
 	s.tree = nil // clear any in-memory tree
 	s.mu.Unlock()
 
+	go s.watchFile()
 	return s.startServer()
 }
 
@@ -125,7 +180,7 @@ func (s *Server) startServer() error {
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 
 	// Print server URL
-	fmt.Printf("SVG server running at: http://localhost:%d\n", port)
+	fmt.Printf("SVG server running at: http://127.0.0.1:%d\n", port)
 
 	// Notify port for testing if channel is set
 	if s.portCh != nil {
@@ -155,16 +210,21 @@ func (s *Server) startServer() error {
 	return s.srv.Shutdown(ctx)
 }
 
-// UpdateTree updates the tree being served (when in memory mode)
+// UpdateTree updates the tree being served (when in memory mode),
+// open pages are notified to re-render. The svg of a tree is cached,
+// call it also after changing the served tree in place.
 func (s *Server) UpdateTree(tree *decision_tree.Node) {
 	s.mu.Lock()
 	if s.source == sourceMemory {
 		s.tree = tree
 	}
 	s.mu.Unlock()
+	s.cache.reset()
+	s.updates.notify()
 }
 
-// UpdateFile updates the file path being served (when in file mode)
+// UpdateFile updates the file path being served (when in file mode),
+// open pages are notified to re-render
 func (s *Server) UpdateFile(filename string) error {
 	if _, err := os.Stat(filename); err != nil {
 		return fmt.Errorf("check file: %v", err)
@@ -174,6 +234,10 @@ func (s *Server) UpdateFile(filename string) error {
 		s.filename = filename
 	}
 	s.mu.Unlock()
+	select {
+	case s.fileChangedCh <- struct{}{}:
+	default:
+	}
 	return nil
 }
 
@@ -187,48 +251,187 @@ func (s *Server) Stop() error {
 	return nil
 }
 
-// handler creates the HTTP handler for serving SVG content
+// handler creates the HTTP handler, `/` serves the interactive
+// page to browsers and the plain SVG to other clients
 func (s *Server) handler() http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		s.mu.RLock()
-		source := s.source
-		tree := s.tree
-		filename := s.filename
-		s.mu.RUnlock()
-
-		if source == sourceFile {
-			if filename == "" {
-				http.Error(w, "No file configured", http.StatusNotFound)
-				return
-			}
-
-			// Read and parse file
-			jsonData, err := os.ReadFile(filename)
-			if err != nil {
-				http.Error(w, fmt.Sprintf("read file: %v", err), http.StatusInternalServerError)
-				return
-			}
-
-			if err := json.Unmarshal(jsonData, &tree); err != nil {
-				http.Error(w, fmt.Sprintf("parse JSON: %v", err), http.StatusInternalServerError)
-				return
-			}
-		} else if tree == nil {
-			http.Error(w, "No tree available", http.StatusNotFound)
+	mux := http.NewServeMux()
+	mux.Handle("/assets/", http.FileServer(http.FS(assetsFS)))
+	mux.HandleFunc("/tree.svg", s.serveSVG)
+	mux.HandleFunc("/tree.json", s.serveJSON)
+	mux.HandleFunc("/config.json", s.serveConfig)
+	mux.HandleFunc("/events", s.serveEvents)
+	mux.HandleFunc("/api/nodes", s.serveAPI)
+	mux.HandleFunc("/api/nodes/", s.serveAPI)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
 			return
 		}
+		if strings.Contains(r.Header.Get("Accept"), "text/html") {
+			s.serveIndex(w, r)
+			return
+		}
+		s.serveSVG(w, r)
+	})
+	return mux
+}
 
-		// Set headers
-		w.Header().Set("Content-Type", "image/svg+xml")
-		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+// currentTree returns the tree being served, re-reading the file in file mode
+func (s *Server) currentTree() (*decision_tree.Node, int, error) {
+	s.mu.RLock()
+	source := s.source
+	tree := s.tree
+	filename := s.filename
+	parse := s.parse
+	s.mu.RUnlock()
+
+	if source == sourceFile {
+		if filename == "" {
+			return nil, http.StatusNotFound, fmt.Errorf("No file configured")
+		}
 
-		// Generate and write SVG
-		svg := s.renderer.RenderTree(tree)
-		if _, err := w.Write([]byte(svg)); err != nil {
-			fmt.Fprintf(os.Stderr, "error writing response: %v\n", err)
+		// Read and parse file, unless unmodified since last time
+		stat, err := statFile(filename)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("read file: %v", err)
 		}
-	})
+		if tree = s.cache.stated(filename, stat); tree != nil {
+			return tree, http.StatusOK, nil
+		}
+		jsonData, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("read file: %v", err)
+		}
+
+		if parse == nil {
+			parse = parseJSON
+		}
+		tree, err = s.cache.parsed(filename, stat, jsonData, parse)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("parse file: %v", err)
+		}
+	} else if tree == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("No tree available")
+	}
+	return tree, http.StatusOK, nil
+}
+
+// parseSelection returns the part of the tree to show, the query
+// overrides the default, e.g. /?root=login&depth=2&tag=happy_flow
+func (s *Server) parseSelection(r *http.Request) (decision_tree.Selection, error) {
+	s.mu.RLock()
+	selection := s.selection
+	s.mu.RUnlock()
+	query := r.URL.Query()
+	if query.Has("root") {
+		selection.Root = query.Get("root")
+	}
+	if query.Has("tag") {
+		selection.Tag = query.Get("tag")
+	}
+	if depth := query.Get("depth"); depth != "" {
+		n, err := strconv.Atoi(depth)
+		if err != nil || n < 0 {
+			return selection, fmt.Errorf("invalid depth: %s", depth)
+		}
+		selection.Depth = n
+	}
+	return selection, nil
+}
+
+// parseJSON parses the JSON representation of a tree
+func parseJSON(data []byte) (*decision_tree.Node, error) {
+	var tree *decision_tree.Node
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("parse JSON: %v", err)
+	}
+	return tree, nil
+}
+
+func (s *Server) serveSVG(w http.ResponseWriter, r *http.Request) {
+	tree, status, err := s.currentTree()
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	selection, err := s.parseSelection(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	svg, err := s.cache.render(s.renderer, tree, selection)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Set headers
+	w.Header().Set("Content-Type", "image/svg+xml")
+	setNoCache(w)
+
+	// Write SVG
+	if _, err := w.Write([]byte(svg)); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing response: %v\n", err)
+	}
+}
+
+func (s *Server) serveJSON(w http.ResponseWriter, r *http.Request) {
+	tree, status, err := s.currentTree()
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	selection, err := s.parseSelection(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tree, err = selection.Apply(tree)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	// the same nodes as the svg, collapsed as the layout does
+	writeJSON(w, tree.Collapse(s.renderer.config.MaxNodes))
+}
+
+func (s *Server) serveConfig(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	editorURL := s.editorURL
+	editable := s.editable
+	s.mu.RUnlock()
+	writeJSON(w, map[string]any{"editorURL": editorURL, "editable": editable})
+}
+
+func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	index, err := assetsFS.ReadFile("assets/index.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	setNoCache(w)
+	if _, err := w.Write(index); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing response: %v\n", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	setNoCache(w)
+	if _, err := w.Write(data); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing response: %v\n", err)
+	}
+}
+
+func setNoCache(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
 }
 
 // defaultShutdownTimeout is the time to wait for server to shutdown gracefully
@@ -5,6 +5,9 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -224,6 +227,49 @@ func TestServer(t *testing.T) {
 		}
 	})
 
+	t.Run("ServesPage", func(t *testing.T) {
+		get := func(path string, accept string) (string, string) {
+			req, err := http.NewRequest("GET", fmt.Sprintf("http://localhost:%d%s", port, path), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if accept != "" {
+				req.Header.Set("Accept", accept)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("%s: expected status 200, got %d", path, resp.StatusCode)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response: %v", err)
+			}
+			return resp.Header.Get("Content-Type"), string(body)
+		}
+
+		contentType, body := get("/", "text/html,application/xhtml+xml")
+		if !strings.HasPrefix(contentType, "text/html") || !strings.Contains(body, "/assets/app.js") {
+			t.Errorf("expected html page for browsers, got %q", contentType)
+		}
+		if _, body := get("/assets/app.js", ""); !strings.Contains(body, "tree.json") {
+			t.Error("app.js is not served")
+		}
+		if _, body := get("/tree.json", ""); !strings.Contains(body, `"id":"new_root"`) {
+			t.Errorf("unexpected tree json: %s", body)
+		}
+		if _, body := get("/tree.svg", ""); !strings.Contains(body, `data-id="new_root"`) {
+			t.Errorf("expected svg nodes to carry their ID: %s", body)
+		}
+		server.SetEditorURL("editor://open?file={file}&line={line}")
+		if _, body := get("/config.json", ""); !strings.Contains(body, `editor://open?file={file}\u0026line={line}`) {
+			t.Errorf("unexpected config: %s", body)
+		}
+	})
+
 	// Stop server
 	if err := server.Stop(); err != nil {
 		t.Errorf("failed to stop server: %v", err)
@@ -258,6 +304,154 @@ func ExampleServer_Serve() {
 	server.Stop()
 
 	// Output will be something like:
-	// SVG server running at: http://localhost:12137
+	// SVG server running at: http://127.0.0.1:12137
 	// Server stop requested...
 }
+
+func TestServerLiveReload(t *testing.T) {
+	startServer := func(t *testing.T, serve func(server *Server) error) (*Server, int) {
+		server := NewServer(nil)
+		portCh := make(chan int, 1)
+		server.SetPortNotifier(portCh)
+		go func() {
+			if err := serve(server); err != nil && err != http.ErrServerClosed {
+				t.Errorf("server error: %v", err)
+			}
+		}()
+		select {
+		case port := <-portCh:
+			return server, port
+		case <-time.After(2 * time.Second):
+			t.Fatal("timeout waiting for server to start")
+		}
+		return nil, 0
+	}
+
+	// subscribe returns a channel receiving event names
+	subscribe := func(t *testing.T, port int) <-chan string {
+		resp, err := http.Get(fmt.Sprintf("http://localhost:%d/events", port))
+		if err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Errorf("expected text/event-stream, got %q", ct)
+		}
+		events := make(chan string, 10)
+		go func() {
+			defer resp.Body.Close()
+			buf := make([]byte, 1024)
+			for {
+				n, err := resp.Body.Read(buf)
+				for _, line := range strings.Split(string(buf[:n]), "\n") {
+					if strings.HasPrefix(line, "event: ") {
+						events <- strings.TrimPrefix(line, "event: ")
+					}
+				}
+				if err != nil {
+					close(events)
+					return
+				}
+			}
+		}()
+		return events
+	}
+
+	expectUpdate := func(t *testing.T, events <-chan string) {
+		select {
+		case event := <-events:
+			if event != "update" {
+				t.Errorf("expected update event, got %q", event)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout waiting for update event")
+		}
+	}
+
+	t.Run("UpdateTree", func(t *testing.T) {
+		server, port := startServer(t, func(server *Server) error {
+			return server.Serve(&decision_tree.Node{ID: "root"})
+		})
+		defer server.Stop()
+
+		events := subscribe(t, port)
+		server.UpdateTree(&decision_tree.Node{ID: "new_root"})
+		expectUpdate(t, events)
+	})
+
+	t.Run("WatchFile", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "tree.json")
+		if err := os.WriteFile(file, []byte(`{"id":"root"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		server, port := startServer(t, func(server *Server) error {
+			return server.ServeFile(file)
+		})
+		defer server.Stop()
+
+		events := subscribe(t, port)
+		// give the watcher time to start
+		time.Sleep(100 * time.Millisecond)
+		if err := os.WriteFile(file, []byte(`{"id":"changed"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		expectUpdate(t, events)
+	})
+}
+
+func TestServerFileParser(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tree.txt")
+	if err := os.WriteFile(file, []byte("root"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(nil)
+	server.SetFileParser(func(data []byte) (*decision_tree.Node, error) {
+		return &decision_tree.Node{ID: string(data)}, nil
+	})
+	server.source = sourceFile
+	server.filename = file
+
+	tree, _, err := server.currentTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.ID != "root" {
+		t.Errorf("expect the parsed tree, got %+v", tree)
+	}
+}
+
+func TestServerSelection(t *testing.T) {
+	server := NewServer(nil)
+	server.source = sourceMemory
+	server.tree = &decision_tree.Node{
+		ID: "root",
+		Children: []*decision_tree.Node{
+			{ID: "a", Children: []*decision_tree.Node{
+				{ID: "a1", Children: []*decision_tree.Node{{ID: "a1x"}}},
+			}},
+			{ID: "b"},
+		},
+	}
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.handler().ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		return rec
+	}
+
+	rec := get("/tree.json?root=a&depth=1")
+	expect := `{"id":"a","label":"","children":[{"id":"a1","label":"","children":[{"id":"a1/more","label":"+1 more","style":{"shape":"stadium","fill":"#f6f8fa","stroke":"#999999","strokeWidth":1,"class":"collapsed"}}]}]}`
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != expect {
+		t.Errorf("expect %s, got %d %s", expect, rec.Code, rec.Body.String())
+	}
+
+	rec = get("/tree.svg?root=a&depth=1")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), `data-id="b"`) || !strings.Contains(rec.Body.String(), "+1 more") {
+		t.Errorf("expect the selected branch, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := get("/tree.svg?root=missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("expect 404 for a missing root, got %d", rec.Code)
+	}
+	if rec := get("/tree.json?depth=x"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expect 400 for an invalid depth, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,38 @@
+package svg
+
+import (
+	"fmt"
+	"html"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/layout"
+)
+
+// shapeElement returns the svg element outlining the node in its shape.
+// The shapes fill the node's box, touching the centers of all four sides,
+// so edges attached there need no adjustment.
+func shapeElement(node *layout.LayoutNode, style *decision_tree.NodeStyle) string {
+	x, y := node.X-node.Width/2, node.Y
+	w, h := node.Width, node.Height
+	cx, cy := node.X, node.Y+h/2
+	paint := fmt.Sprintf(`fill="%s" stroke="%s" stroke-width="%d" class="shape"`, html.EscapeString(style.Fill), html.EscapeString(style.Stroke), style.StrokeWidth)
+
+	switch style.Shape {
+	case decision_tree.ShapeDiamond:
+		return fmt.Sprintf(`<polygon points="%f,%f %f,%f %f,%f %f,%f" %s/>`,
+			cx, y, x+w, cy, cx, y+h, x, cy, paint)
+	case decision_tree.ShapeEllipse:
+		return fmt.Sprintf(`<ellipse cx="%f" cy="%f" rx="%f" ry="%f" %s/>`, cx, cy, w/2, h/2, paint)
+	case decision_tree.ShapeHexagon:
+		inset := layout.HexagonInset(node)
+		return fmt.Sprintf(`<polygon points="%f,%f %f,%f %f,%f %f,%f %f,%f %f,%f" %s/>`,
+			x+inset, y, x+w-inset, y, x+w, cy, x+w-inset, y+h, x+inset, y+h, x, cy, paint)
+	case decision_tree.ShapeStadium:
+		return fmt.Sprintf(`<rect x="%f" y="%f" width="%f" height="%f" rx="%f" ry="%f" %s/>`, x, y, w, h, h/2, h/2, paint)
+	}
+	// Terminal nodes get rounded corners
+	if len(node.Children) == 0 {
+		return fmt.Sprintf(`<rect x="%f" y="%f" width="%f" height="%f" rx="3" ry="3" %s/>`, x, y, w, h, paint)
+	}
+	return fmt.Sprintf(`<rect x="%f" y="%f" width="%f" height="%f" %s/>`, x, y, w, h, paint)
+}
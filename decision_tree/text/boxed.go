@@ -0,0 +1,328 @@
+package text
+
+import (
+	"math"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/layout"
+	"github.com/xhd2015/data-driven-testing/decision_tree/metrics"
+)
+
+// charWidth is the width in pixels of one terminal column, layout.Engine
+// measures text with monospace metrics so node widths translate to whole
+// columns, wide characters taking two
+const charWidth = 9
+
+// boxedConfig lays out nodes in units of charWidth: each box gets a
+// border and a space on both sides of its label, and boxes are
+// separated by at least two columns. Labels wrap at 20 columns.
+func boxedConfig() *decision_tree.Config {
+	config := decision_tree.DefaultConfig()
+	config.FontFamily = "monospace"
+	config.FontSize = 2 * charWidth
+	config.ConditionFontSize = 2 * charWidth
+	config.MaxLabelWidth = 20 * charWidth
+	config.NodePadding = 2 * charWidth
+	config.BaseNodeWidth = 5 * charWidth
+	config.NodeSpacing = 2 * charWidth
+	config.LeafNodeSpacing = 2 * charWidth
+	return config
+}
+
+type boxSymbols struct {
+	horizontal, vertical                            rune
+	topLeft, topRight, bottomLeft, bottomRight, tee rune
+}
+
+var (
+	unicodeBox = boxSymbols{horizontal: '─', vertical: '│', topLeft: '┌', topRight: '┐', bottomLeft: '└', bottomRight: '┘', tee: '┴'}
+	asciiBox   = boxSymbols{horizontal: '-', vertical: '|', topLeft: '+', topRight: '+', bottomLeft: '+', bottomRight: '+', tee: '+'}
+)
+
+// junction returns the character connecting lines in the given directions
+func (s boxSymbols) junction(up, down, left, right bool) rune {
+	if s.horizontal == '-' {
+		switch {
+		case (up || down) && !left && !right:
+			return '|'
+		case !up && !down:
+			return '-'
+		}
+		return '+'
+	}
+	switch {
+	case up && down && left && right:
+		return '┼'
+	case up && down && left:
+		return '┤'
+	case up && down && right:
+		return '├'
+	case up && down:
+		return '│'
+	case up && left && right:
+		return '┴'
+	case down && left && right:
+		return '┬'
+	case up && left:
+		return '┘'
+	case up && right:
+		return '└'
+	case down && left:
+		return '┐'
+	case down && right:
+		return '┌'
+	}
+	return '─'
+}
+
+// box is a node placed on the canvas
+type box struct {
+	node       *decision_tree.Node
+	col, row   int // top left corner
+	width      int
+	lines      []string
+	conditions []string
+	children   []*box
+	level      int
+	tee        int // column the connector from the parent enters at
+}
+
+func (b *box) height() int {
+	return len(b.lines) + len(b.conditions) + 2
+}
+
+func (b *box) center() int {
+	return b.col + b.width/2
+}
+
+// canvas is a grid of characters, each with an optional color.
+// A wide character is followed by a 0 cell it extends over.
+type canvas struct {
+	cells  [][]rune
+	colors [][]string
+}
+
+func newCanvas(width, height int) *canvas {
+	c := &canvas{
+		cells:  make([][]rune, height),
+		colors: make([][]string, height),
+	}
+	for i := range c.cells {
+		c.cells[i] = []rune(strings.Repeat(" ", width))
+		c.colors[i] = make([]string, width)
+	}
+	return c
+}
+
+func (c *canvas) set(col, row int, r rune, color string) {
+	if row < 0 || row >= len(c.cells) || col < 0 || col >= len(c.cells[row]) {
+		return
+	}
+	c.cells[row][col] = r
+	c.colors[row][col] = color
+}
+
+func (c *canvas) write(col, row int, s string, color string) {
+	for _, r := range s {
+		if metrics.IsZeroWidth(r) {
+			continue
+		}
+		c.set(col, row, r, color)
+		col++
+		if metrics.IsWide(r) {
+			c.set(col, row, 0, color)
+			col++
+		}
+	}
+}
+
+func (c *canvas) String(options Options) string {
+	var sb strings.Builder
+	for i, row := range c.cells {
+		end := len(row)
+		for end > 0 && row[end-1] == ' ' {
+			end--
+		}
+		color := ""
+		for j := 0; j < end; j++ {
+			cellColor := ""
+			if options.UseColors {
+				cellColor = c.colors[i][j]
+			}
+			if cellColor != color {
+				if color != "" {
+					sb.WriteString(ColorReset)
+				}
+				sb.WriteString(cellColor)
+				color = cellColor
+			}
+			if row[j] != 0 {
+				sb.WriteRune(row[j])
+			}
+		}
+		if color != "" {
+			sb.WriteString(ColorReset)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// renderBoxed draws each node as a box, positioned horizontally by
+// layout.Engine and connected to its children:
+//
+//	   ┌──────┐
+//	   │ Root │
+//	   └──────┘
+//	       │
+//	   ┌───┴────┐
+//	┌──┴──┐  ┌──┴─┐
+//	│ Yes │  │ No │
+//	└─────┘  └────┘
+func renderBoxed(root *decision_tree.Node, options Options) string {
+	symbols := unicodeBox
+	if !options.UseUnicode {
+		symbols = asciiBox
+	}
+
+	// the engine sizes and wraps the text of nodes, give
+	// it what is displayed
+	labelled := root.Clone()
+	var fillLabels func(node *decision_tree.Node)
+	fillLabels = func(node *decision_tree.Node) {
+		node.Label = node.DisplayLabel()
+		if !options.ShowConditions {
+			node.Conditions = nil
+		}
+		for _, child := range node.Children {
+			fillLabels(child)
+		}
+	}
+	fillLabels(labelled)
+	layoutRoot := layout.NewEngine(boxedConfig()).CalculateLayout(labelled)
+
+	// build boxes, keeping the original nodes for styles
+	var levels [][]*box
+	minCol := math.MaxInt
+	var build func(node *decision_tree.Node, ln *layout.LayoutNode, level int) *box
+	build = func(node *decision_tree.Node, ln *layout.LayoutNode, level int) *box {
+		width := int(math.Round(ln.Width / charWidth))
+		b := &box{
+			node:  node,
+			col:   int(math.Round((ln.X - ln.Width/2) / charWidth)),
+			width: width,
+			level: level,
+		}
+		b.lines = ln.LabelLines
+		b.conditions = ln.ConditionLines
+		if b.col < minCol {
+			minCol = b.col
+		}
+		if len(levels) <= level {
+			levels = append(levels, nil)
+		}
+		levels[level] = append(levels[level], b)
+		for i, child := range node.Children {
+			b.children = append(b.children, build(child, ln.Children[i], level+1))
+		}
+		return b
+	}
+	build(root, layoutRoot, 0)
+
+	// each level starts below the tallest box of the previous
+	// level, plus two rows for the connectors
+	row := 0
+	levelRows := make([]int, len(levels))
+	canvasWidth := 0
+	for i, level := range levels {
+		levelRows[i] = row
+		maxHeight := 0
+		for _, b := range level {
+			b.col -= minCol
+			b.row = row
+			b.tee = b.center()
+			if h := b.height(); h > maxHeight {
+				maxHeight = h
+			}
+			if end := b.col + b.width; end > canvasWidth {
+				canvasWidth = end
+			}
+		}
+		row += maxHeight + 2
+	}
+	c := newCanvas(canvasWidth, row-2)
+
+	for i, level := range levels {
+		for _, b := range level {
+			drawBox(c, b, symbols)
+			if len(b.children) > 0 {
+				drawConnectors(c, b, levelRows[i+1]-2, symbols)
+			}
+		}
+	}
+	return c.String(options)
+}
+
+func drawBox(c *canvas, b *box, symbols boxSymbols) {
+	color := styleColor(b.node.Style)
+	right := b.col + b.width - 1
+	bottom := b.row + b.height() - 1
+
+	c.set(b.col, b.row, symbols.topLeft, color)
+	c.set(right, b.row, symbols.topRight, color)
+	c.set(b.col, bottom, symbols.bottomLeft, color)
+	c.set(right, bottom, symbols.bottomRight, color)
+	for col := b.col + 1; col < right; col++ {
+		c.set(col, b.row, symbols.horizontal, color)
+		c.set(col, bottom, symbols.horizontal, color)
+	}
+	for row := b.row + 1; row < bottom; row++ {
+		c.set(b.col, row, symbols.vertical, color)
+		c.set(right, row, symbols.vertical, color)
+	}
+	row := b.row + 1
+	for _, line := range b.lines {
+		c.write(b.col+2, row, line, color)
+		row++
+	}
+	for _, line := range b.conditions {
+		c.write(b.col+2, row, line, ColorGray)
+		row++
+	}
+	if b.level > 0 {
+		c.set(b.tee, b.row, symbols.tee, color)
+	}
+}
+
+// drawConnectors draws a vertical line from the bottom of b down to
+// gapRow, and a horizontal line on the next row spanning the centers
+// of its children
+func drawConnectors(c *canvas, b *box, gapRow int, symbols boxSymbols) {
+	center := b.center()
+	for row := b.row + b.height(); row <= gapRow; row++ {
+		c.set(center, row, symbols.vertical, "")
+	}
+	lineRow := gapRow + 1
+
+	down := make(map[int]bool, len(b.children))
+	lo, hi := center, center
+	for _, child := range b.children {
+		// rounding may leave a child off by one column,
+		// join it straight instead of with a kink
+		if d := child.tee - center; d >= -1 && d <= 1 {
+			child.tee = center
+		}
+		col := child.tee
+		down[col] = true
+		if col < lo {
+			lo = col
+		}
+		if col > hi {
+			hi = col
+		}
+	}
+	for col := lo; col <= hi; col++ {
+		c.set(col, lineRow, symbols.junction(col == center, down[col], col > lo, col < hi), "")
+	}
+}
@@ -0,0 +1,203 @@
+// Package text renders decision trees for terminals, either as an indented
+// tree drawn with box-drawing characters, or as boxes laid out top-down
+// by layout.Engine.
+package text
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/metrics"
+)
+
+// Color constants for terminal output
+const (
+	ColorReset  = "\033[0m"
+	ColorGreen  = "\033[32m"
+	ColorRed    = "\033[31m"
+	ColorGray   = "\033[90m"
+	ColorYellow = "\033[33m"
+	ColorBlue   = "\033[34m"
+)
+
+// Options contains configuration options for text rendering
+type Options struct {
+	UseUnicode     bool // Whether to use box-drawing characters (true) or ASCII (false)
+	UseColors      bool // Whether to use ANSI color codes, nodes are colored by their style
+	ShowConditions bool // Whether to show node conditions
+	Boxed          bool // Whether to draw boxes laid out top-down instead of an indented tree
+	Width          int  // Maximum line width of the indented tree, labels are wrapped, 0 for no limit
+}
+
+// DefaultOptions returns the default rendering options
+func DefaultOptions() Options {
+	return Options{
+		UseUnicode:     true,
+		UseColors:      true,
+		ShowConditions: true,
+	}
+}
+
+// Render renders the tree as text
+func Render(root *decision_tree.Node, options Options) string {
+	if root == nil {
+		return ""
+	}
+	if options.Boxed {
+		return renderBoxed(root, options)
+	}
+	return renderTree(root, options)
+}
+
+// minLabelWidth is the minimum width labels are wrapped to,
+// even if the indentation leaves less space
+const minLabelWidth = 10
+
+type treeSymbols struct {
+	branch, last, pipe, space string
+}
+
+var (
+	unicodeTree = treeSymbols{branch: "├── ", last: "└── ", pipe: "│   ", space: "    "}
+	asciiTree   = treeSymbols{branch: "|-- ", last: "`-- ", pipe: "|   ", space: "    "}
+)
+
+// renderTree renders an indented tree:
+//
+//	Root
+//	├── Child 1
+//	│     a=1
+//	│   └── Grandchild
+//	└── Child 2
+func renderTree(root *decision_tree.Node, options Options) string {
+	symbols := unicodeTree
+	if !options.UseUnicode {
+		symbols = asciiTree
+	}
+	var sb strings.Builder
+	var visit func(node *decision_tree.Node, prefix string, connector string, childPrefix string)
+	visit = func(node *decision_tree.Node, prefix string, connector string, childPrefix string) {
+		// continuation lines keep the children's pipe under the label
+		cont := prefix + childPrefix
+		if len(node.Children) > 0 {
+			cont += strings.TrimRight(symbols.pipe, " ") + " "
+		} else {
+			cont += "  "
+		}
+
+		labelWidth := 0
+		if options.Width > 0 {
+			// continuation lines are indented the most
+			labelWidth = options.Width - utf8.RuneCountInString(cont)
+			if labelWidth < minLabelWidth {
+				labelWidth = minLabelWidth
+			}
+		}
+		lines := wrap(node.DisplayLabel(), labelWidth)
+		color := styleColor(node.Style)
+		for i, line := range lines {
+			if i == 0 {
+				sb.WriteString(prefix + connector)
+			} else {
+				sb.WriteString(cont)
+			}
+			sb.WriteString(colorize(line, color, options))
+			if i == len(lines)-1 && node.Label != "" && node.ID != "" && node.ID != node.Label {
+				sb.WriteString(colorize(" ("+node.ID+")", ColorGray, options))
+			}
+			sb.WriteString("\n")
+		}
+		if options.ShowConditions && len(node.Conditions) > 0 {
+			condWidth := 0
+			if options.Width > 0 {
+				condWidth = options.Width - utf8.RuneCountInString(cont) - 2
+				if condWidth < minLabelWidth {
+					condWidth = minLabelWidth
+				}
+			}
+			for _, line := range wrap(strings.Join(decision_tree.FormatConditions(node.Conditions), ", "), condWidth) {
+				sb.WriteString(cont + "  " + colorize(line, ColorGray, options) + "\n")
+			}
+		}
+
+		for i, child := range node.Children {
+			if i == len(node.Children)-1 {
+				visit(child, prefix+childPrefix, symbols.last, symbols.space)
+			} else {
+				visit(child, prefix+childPrefix, symbols.branch, symbols.pipe)
+			}
+		}
+	}
+	visit(root, "", "", "")
+	return sb.String()
+}
+
+// columns measures text in terminal columns
+var columns = &metrics.Metrics{Font: metrics.Monospace, Size: 2}
+
+// wrap wraps text at word boundaries to lines of at most width
+// columns, words longer than width are split, 0 disables wrapping
+func wrap(text string, width int) []string {
+	return columns.Wrap(text, float64(width))
+}
+
+func colorize(s string, color string, options Options) string {
+	if !options.UseColors || color == "" || s == "" {
+		return s
+	}
+	return color + s + ColorReset
+}
+
+// styleColor picks the terminal color closest to the stroke of the style,
+// so that e.g. diff styles keep their meaning
+func styleColor(style *decision_tree.NodeStyle) string {
+	if style == nil {
+		return ""
+	}
+	r, g, b, ok := parseHexColor(style.Stroke)
+	if !ok {
+		return ""
+	}
+	max, min := r, r
+	for _, c := range []int{g, b} {
+		if c > max {
+			max = c
+		}
+		if c < min {
+			min = c
+		}
+	}
+	switch {
+	case max-min < 48:
+		return ColorGray
+	case r == max && g > 2*b && g > r/2:
+		return ColorYellow
+	case r == max:
+		return ColorRed
+	case g == max:
+		return ColorGreen
+	default:
+		return ColorBlue
+	}
+}
+
+// parseHexColor parses #rgb and #rrggbb colors
+func parseHexColor(color string) (r, g, b int, ok bool) {
+	if !strings.HasPrefix(color, "#") {
+		return 0, 0, 0, false
+	}
+	hex := color[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff), true
+}
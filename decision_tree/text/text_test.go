@@ -0,0 +1,148 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+func testTree() *decision_tree.Node {
+	return &decision_tree.Node{
+		ID:         "root",
+		Label:      "Root",
+		Conditions: map[string]any{"b": 2, "a": 1},
+		Children: []*decision_tree.Node{
+			{
+				ID:    "yes",
+				Label: "Yes",
+				Children: []*decision_tree.Node{
+					{ID: "long", Label: "A label that needs wrapping"},
+					{ID: "y2"},
+				},
+			},
+			{ID: "no", Label: "No", Style: &decision_tree.NodeStyle{Stroke: "#ff0000"}},
+		},
+	}
+}
+
+func TestRenderTree(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		want    string
+	}{
+		{
+			name:    "Unicode",
+			options: Options{UseUnicode: true, ShowConditions: true},
+			want: `
+Root (root)
+│   a=1, b=2
+├── Yes (yes)
+│   ├── A label that needs wrapping (long)
+│   └── y2
+└── No (no)
+`,
+		},
+		{
+			name:    "ASCII",
+			options: Options{},
+			want: "\n" +
+				"Root (root)\n" +
+				"|-- Yes (yes)\n" +
+				"|   |-- A label that needs wrapping (long)\n" +
+				"|   `-- y2\n" +
+				"`-- No (no)\n",
+		},
+		{
+			name:    "Width",
+			options: Options{UseUnicode: true, Width: 20},
+			want: `
+Root (root)
+├── Yes (yes)
+│   ├── A label
+│   │     that needs
+│   │     wrapping (long)
+│   └── y2
+└── No (no)
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Render(testTree(), tt.options)
+			want := strings.TrimPrefix(tt.want, "\n")
+			if got != want {
+				t.Errorf("unexpected output:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestRenderColors(t *testing.T) {
+	got := Render(testTree(), DefaultOptions())
+	if !strings.Contains(got, ColorRed+"No"+ColorReset) {
+		t.Errorf("expected node styled with red stroke to be red:\n%q", got)
+	}
+	if !strings.Contains(got, ColorGray+"a=1, b=2"+ColorReset) {
+		t.Errorf("expected conditions to be gray:\n%q", got)
+	}
+}
+
+func TestRenderBoxed(t *testing.T) {
+	got := Render(testTree(), Options{UseUnicode: true, ShowConditions: true, Boxed: true})
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+
+	// boxes of a level share their rows, and must not overlap
+	find := func(label string) (row, col int) {
+		for i, line := range lines {
+			if j := strings.Index(line, "│ "+label); j >= 0 {
+				return i, len([]rune(line[:j]))
+			}
+		}
+		t.Fatalf("%q not found in:\n%s", label, got)
+		return 0, 0
+	}
+	rootRow, _ := find("Root")
+	yesRow, yesCol := find("Yes")
+	noRow, noCol := find("No")
+	longRow, longCol := find("A label")
+	y2Row, y2Col := find("y2")
+	if !(rootRow < yesRow && yesRow == noRow && yesRow < longRow && longRow == y2Row) {
+		t.Errorf("unexpected rows:\n%s", got)
+	}
+	if !(yesCol < noCol && longCol < y2Col) {
+		t.Errorf("unexpected order of boxes:\n%s", got)
+	}
+	if !strings.Contains(got, "a=1,") || !strings.Contains(got, "b=2") {
+		t.Errorf("expected conditions in box:\n%s", got)
+	}
+	if !strings.Contains(got, "┴") || !strings.Contains(got, "┌") {
+		t.Errorf("expected boxes connected:\n%s", got)
+	}
+	// labels are wrapped like the svg renderer does, at 20 characters
+	for _, line := range lines {
+		if strings.Contains(line, "A label that needs wrapping") {
+			t.Errorf("expected long label to be wrapped:\n%s", got)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  []string
+	}{
+		{"short", 10, []string{"short"}},
+		{"no limit at all", 0, []string{"no limit at all"}},
+		{"hello big world", 9, []string{"hello big", "world"}},
+		{"abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+	}
+	for _, tt := range tests {
+		got := wrap(tt.text, tt.width)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("wrap(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
@@ -1,35 +1,126 @@
 package decision_tree
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 // Node represents a single node in the decision tree
 type Node struct {
 	ID         string         `json:"id"`
 	Label      string         `json:"label"`
 	Conditions map[string]any `json:"conditions,omitempty"`
+	EdgeLabel  string         `json:"edgeLabel,omitempty"` // label of the edge from the parent, e.g. the branch condition
 	Style      *NodeStyle     `json:"style,omitempty"`
+	Source     *Source        `json:"source,omitempty"`
 	Children   []*Node        `json:"children,omitempty"`
 }
 
+// Source is the location where a node is defined
+type Source struct {
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+}
+
 // NodeStyle defines visual properties for a node
 type NodeStyle struct {
-	Shape       string `json:"shape,omitempty"`       // rectangle, diamond
+	Shape       string `json:"shape,omitempty"`       // rectangle, diamond, ellipse, hexagon or stadium
 	Fill        string `json:"fill,omitempty"`        // CSS color
 	Stroke      string `json:"stroke,omitempty"`      // CSS color
 	StrokeWidth int    `json:"strokeWidth,omitempty"` // line width
+	Class       string `json:"class,omitempty"`       // name of the style, exported as a class shared by its nodes
 }
 
 // Config holds configuration for tree rendering
 type Config struct {
 	// Layout configuration
-	LevelHeight        float64 // Vertical distance between levels
-	NodeSpacing        float64 // Minimum horizontal space between nodes
-	NodePadding        float64 // Text padding inside nodes
-	BaseNodeWidth      float64 // Minimum node width
-	LeafNodeSpacing    float64 // Horizontal spacing between leaf nodes (default: 1.5 * NodeSpacing)
-	ParentChildSpacing float64 // Minimum vertical space between parent and child nodes
-	VerticalSpanCoeff  float64 // Coefficient for vertical spacing scaling with children span (0.0-1.0)
+	LevelHeight        float64         // Vertical distance between levels
+	NodeSpacing        float64         // Minimum horizontal space between nodes
+	NodePadding        float64         // Text padding inside nodes
+	BaseNodeWidth      float64         // Minimum node width
+	LeafNodeSpacing    float64         // Horizontal spacing between leaf nodes (default: 1.5 * NodeSpacing)
+	ParentChildSpacing float64         // Minimum vertical space between parent and child nodes
+	VerticalSpanCoeff  float64         // Coefficient for vertical spacing scaling with children span (0.0-1.0)
+	Orientation        Orientation     // Direction the tree grows in, default TopDown
+	Algorithm          LayoutAlgorithm // How nodes of a level are spread, default LeafOrder
+
+	// Text configuration, text is measured with the bundled
+	// width tables of package metrics
+	FontFamily        string  // Font family of labels and conditions, Arial or DejaVu Sans
+	FontSize          float64 // Font size of labels in pixels
+	ConditionFontSize float64 // Font size of conditions in pixels
+	MaxLabelWidth     float64 // Width in pixels labels and conditions are wrapped at
 
 	// Default styles
 	DefaultStyle *NodeStyle
+	StyleRules   []StyleRule // Styles of nodes matching conditions or tags, see NodeStyle
+
+	// Level of detail, trees with more nodes are laid out
+	// collapsed, see Node.Collapse. 0 shows every node.
+	MaxNodes int
+}
+
+// Orientation is the direction a tree grows in
+type Orientation int
+
+const (
+	TopDown   Orientation = iota // root at the top, levels grow downwards
+	LeftRight                    // root at the left, levels grow rightwards
+	Radial                       // root at the center, levels on rings around it
+)
+
+func (o Orientation) String() string {
+	switch o {
+	case LeftRight:
+		return "left-right"
+	case Radial:
+		return "radial"
+	}
+	return "top-down"
+}
+
+// ParseOrientation parses orientations like "td", "lr" and "radial"
+func ParseOrientation(s string) (Orientation, error) {
+	switch strings.ToLower(s) {
+	case "td", "tb", "top-down":
+		return TopDown, nil
+	case "lr", "left-right":
+		return LeftRight, nil
+	case "radial":
+		return Radial, nil
+	}
+	return TopDown, fmt.Errorf("unknown orientation %q, requires td, lr or radial", s)
+}
+
+// LayoutAlgorithm decides how nodes are spread along a level
+type LayoutAlgorithm int
+
+const (
+	// LeafOrder gives every leaf its own slot, in order, and centers
+	// parents over their children
+	LeafOrder LayoutAlgorithm = iota
+	// Tidy packs subtrees as close as their contours allow
+	// (Reingold-Tilford), giving compact asymmetric trees
+	Tidy
+)
+
+func (a LayoutAlgorithm) String() string {
+	if a == Tidy {
+		return "tidy"
+	}
+	return "leaf-order"
+}
+
+// ParseLayoutAlgorithm parses "leaf-order" and "tidy"
+func ParseLayoutAlgorithm(s string) (LayoutAlgorithm, error) {
+	switch strings.ToLower(s) {
+	case "leaf-order", "leaf":
+		return LeafOrder, nil
+	case "tidy":
+		return Tidy, nil
+	}
+	return LeafOrder, fmt.Errorf("unknown layout %q, requires leaf-order or tidy", s)
 }
 
 // layout algorithm
@@ -42,11 +133,15 @@ func DefaultConfig() *Config {
 	return &Config{
 		LevelHeight:        20,     // Base height between levels
 		NodeSpacing:        20,     // Increased horizontal spacing for better readability
-		NodePadding:        2,      // Slightly reduced padding
+		NodePadding:        8,      // Padding around the measured text
 		BaseNodeWidth:      120,    // Reduced base width for better compactness
 		LeafNodeSpacing:    10,     // 1.5 * NodeSpacing for leaf nodes
 		ParentChildSpacing: 10,     // Minimum space between parent and child nodes
 		VerticalSpanCoeff:  0.0009, // 15% of children's horizontal span added to vertical spacing
+		FontFamily:         "Arial",
+		FontSize:           12,
+		ConditionFontSize:  10,
+		MaxLabelWidth:      150,
 		DefaultStyle: &NodeStyle{
 			Shape:       "rectangle",
 			Fill:        "url(#nodeGradient)",
@@ -56,6 +151,32 @@ func DefaultConfig() *Config {
 	}
 }
 
+// DisplayLabel returns the text shown for the node: its label,
+// falling back to its ID, or "Node" if it has neither
+func (n *Node) DisplayLabel() string {
+	if n.Label != "" {
+		return n.Label
+	}
+	if n.ID != "" {
+		return n.ID
+	}
+	return "Node"
+}
+
+// FormatConditions formats conditions as key=value pairs sorted by key
+func FormatConditions(conditions map[string]any) []string {
+	keys := make([]string, 0, len(conditions))
+	for k := range conditions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, conditions[k]))
+	}
+	return pairs
+}
+
 // Clone creates a deep copy of the node and its children
 func (n *Node) Clone() *Node {
 	if n == nil {
@@ -65,6 +186,7 @@ func (n *Node) Clone() *Node {
 	clone := &Node{
 		ID:         n.ID,
 		Label:      n.Label,
+		EdgeLabel:  n.EdgeLabel,
 		Conditions: make(map[string]any, len(n.Conditions)),
 	}
 
@@ -80,9 +202,15 @@ func (n *Node) Clone() *Node {
 			Fill:        n.Style.Fill,
 			Stroke:      n.Style.Stroke,
 			StrokeWidth: n.Style.StrokeWidth,
+			Class:       n.Style.Class,
 		}
 	}
 
+	if n.Source != nil {
+		source := *n.Source
+		clone.Source = &source
+	}
+
 	// Clone children
 	if len(n.Children) > 0 {
 		clone.Children = make([]*Node, len(n.Children))
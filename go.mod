@@ -5,6 +5,11 @@ go 1.18
 require (
 	github.com/fsnotify/fsnotify v1.8.0
 	github.com/xhd2015/xgo v1.0.52
+	golang.org/x/image v0.18.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require golang.org/x/sys v0.13.0 // indirect
+require (
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+)
@@ -0,0 +1,72 @@
+package t_tree
+
+import (
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/testing_ctx"
+)
+
+// ExpandOptions configures ExpandDimensions
+type ExpandOptions[Q any, R any, TC any] struct {
+	Dimensions []decision_tree.Dimension
+	Coverage   decision_tree.Coverage
+
+	// Setup builds the request for the chosen values of a leaf
+	Setup func(t testing_ctx.T, tctx *TC, req *Q, values map[string]any) (*TC, *Q)
+	// Assert checks the response for the chosen values of a leaf
+	Assert func(t testing_ctx.T, tctx *TC, req *Q, res *R, err error, values map[string]any)
+}
+
+// ExpandDimensions returns a copy of root whose subtree splits on
+// the dimensions level by level, see decision_tree.ExpandDimensions.
+// Each node is tagged with its `name=value`, which is also its
+// Condition shown on the edge from the parent, leaves are tagged with
+// all chosen values and bound to Setup and Assert, while Run is
+// shared from root.
+func ExpandDimensions[Q any, R any, TC any](root *Node[Q, R, TC], opts ExpandOptions[Q, R, TC]) *Node[Q, R, TC] {
+	dims := opts.Dimensions
+	expanded := *root
+	expanded.Children = append([]*Node[Q, R, TC](nil), root.Children...)
+
+	idToNode := make(map[string]*Node[Q, R, TC])
+	for _, combination := range decision_tree.Combinations(dims, opts.Coverage) {
+		parent := &expanded
+		for level, valueIndex := range combination {
+			childID := decision_tree.CombinationID(root.ID, dims, combination[:level+1])
+			child := idToNode[childID]
+			if child == nil {
+				value := decision_tree.FormatDimensionValue(dims[level], valueIndex)
+				child = &Node[Q, R, TC]{
+					ID:          childID,
+					Description: value,
+					Condition:   value,
+					Tags:        []string{value},
+				}
+				idToNode[childID] = child
+				parent.Children = append(parent.Children, child)
+			}
+			parent = child
+		}
+
+		leaf := parent
+		values := make(map[string]any, len(dims))
+		tags := make([]string, 0, len(dims))
+		for level, valueIndex := range combination {
+			values[dims[level].Name] = dims[level].Values[valueIndex]
+			tags = append(tags, decision_tree.FormatDimensionValue(dims[level], valueIndex))
+		}
+		leaf.Tags = tags
+		if opts.Setup != nil {
+			setup := opts.Setup
+			leaf.Setup = func(t testing_ctx.T, tctx *TC, req *Q) (*TC, *Q) {
+				return setup(t, tctx, req, values)
+			}
+		}
+		if opts.Assert != nil {
+			assert := opts.Assert
+			leaf.Assert = func(t testing_ctx.T, tctx *TC, req *Q, res *R, err error) {
+				assert(t, tctx, req, res, err, values)
+			}
+		}
+	}
+	return &expanded
+}
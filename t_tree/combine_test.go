@@ -0,0 +1,60 @@
+package t_tree
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/testing_ctx"
+	"github.com/xhd2015/data-driven-testing/testing_ctx/integration"
+)
+
+func TestExpandDimensions(t *testing.T) {
+	type Req struct{ A, B int }
+	var asserted []string
+	root := ExpandDimensions(&Node[Req, int, struct{}]{
+		ID: "Root",
+		Run: func(t testing_ctx.T, tctx *struct{}, req *Req) (*int, error) {
+			res := req.A * req.B
+			return &res, nil
+		},
+	}, ExpandOptions[Req, int, struct{}]{
+		Dimensions: []decision_tree.Dimension{
+			{Name: "a", Values: []any{1, 2}},
+			{Name: "b", Values: []any{3, 4}},
+		},
+		Setup: func(t testing_ctx.T, tctx *struct{}, req *Req, values map[string]any) (*struct{}, *Req) {
+			return tctx, &Req{A: values["a"].(int), B: values["b"].(int)}
+		},
+		Assert: func(t testing_ctx.T, tctx *struct{}, req *Req, res *int, err error, values map[string]any) {
+			asserted = append(asserted, fmt.Sprintf("%v*%v=%d", values["a"], values["b"], *res))
+		},
+	})
+
+	tree := MustBuild(root, nil)
+	leaf := tree.FindNode("Root,a=2,b=3")
+	if leaf == nil {
+		t.Fatalf("expect leaf node")
+	}
+	if fmt.Sprint(leaf.Tags) != "[a=2 b=3]" {
+		t.Errorf("expect leaf tags, actual: %v", leaf.Tags)
+	}
+	if leaf.Condition != "b=3" {
+		t.Errorf("expect leaf condition b=3, actual: %q", leaf.Condition)
+	}
+	if mid := tree.FindNode("Root,a=2"); mid == nil || mid.Condition != "a=2" {
+		t.Errorf("expect condition a=2 on the first level")
+	}
+	if dt := tree.ToDecisionTree(); dt.Children[0].EdgeLabel != "a=1" {
+		t.Errorf("expect the edge label in the decision tree, actual: %q", dt.Children[0].EdgeLabel)
+	}
+
+	var buf bytes.Buffer
+	tree.Run(integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf}))
+	sort.Strings(asserted)
+	if fmt.Sprint(asserted) != "[1*3=3 1*4=4 2*3=6 2*4=8]" {
+		t.Errorf("unexpected asserted: %v", asserted)
+	}
+}
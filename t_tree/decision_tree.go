@@ -13,24 +13,28 @@ func (t *Tree[Q, R, TC]) ToDecisionTree() *decision_tree.Node {
 	if t == nil || t.Root == nil {
 		return nil
 	}
-	return convertNode(t.Root)
+	return convertNode(t.Root, nodeLabel[Q, R, TC])
 }
 
-// convertNode converts a t_tree.Node to a decision_tree.Node
-func convertNode[Q, R, TC any](node *Node[Q, R, TC]) *decision_tree.Node {
-	if node == nil {
-		return nil
+// nodeLabel chooses the best label, fallback to ID if description is empty
+func nodeLabel[Q, R, TC any](node *Node[Q, R, TC]) string {
+	if node.Description == "" {
+		return node.ID
 	}
+	return node.Description
+}
 
-	// Choose the best label, fallback to ID if description is empty
-	label := node.Description
-	if label == "" {
-		label = node.ID
+// convertNode converts a t_tree.Node to a decision_tree.Node,
+// labelled by label
+func convertNode[Q, R, TC any](node *Node[Q, R, TC], label func(node *Node[Q, R, TC]) string) *decision_tree.Node {
+	if node == nil {
+		return nil
 	}
 
 	dt := &decision_tree.Node{
-		ID:    node.ID,
-		Label: label,
+		ID:        node.ID,
+		Label:     label(node),
+		EdgeLabel: node.Condition,
 	}
 
 	// Build structured conditions
@@ -38,6 +42,19 @@ func convertNode[Q, R, TC any](node *Node[Q, R, TC]) *decision_tree.Node {
 	if len(node.Tags) > 0 {
 		conditions["tags"] = node.Tags
 	}
+	if node.Expect != nil {
+		conditions["expect"] = node.Expect.String()
+	}
+	if node.Skip != "" {
+		conditions["skip"] = node.Skip
+	}
+	if node.Todo {
+		conditions["todo"] = true
+	}
+	if node.Focus {
+		conditions["focus"] = true
+	}
+	dt.Style = markerStyle(node)
 	// Add any additional node metadata if present
 	if len(conditions) > 0 {
 		dt.Conditions = conditions
@@ -47,7 +64,7 @@ func convertNode[Q, R, TC any](node *Node[Q, R, TC]) *decision_tree.Node {
 	if len(node.Children) > 0 {
 		children := make([]*decision_tree.Node, 0, len(node.Children))
 		for _, child := range node.Children {
-			if dtChild := convertNode(child); dtChild != nil {
+			if dtChild := convertNode(child, label); dtChild != nil {
 				children = append(children, dtChild)
 			}
 		}
@@ -59,6 +76,29 @@ func convertNode[Q, R, TC any](node *Node[Q, R, TC]) *decision_tree.Node {
 	return dt
 }
 
+// markerStyle returns the style for skipped, todo and focused nodes,
+// or nil if the node is not marked
+func markerStyle[Q, R, TC any](node *Node[Q, R, TC]) *decision_tree.NodeStyle {
+	var style decision_tree.NodeStyle
+	switch {
+	case node.Skip != "":
+		style = skipStyle
+	case node.Todo:
+		style = todoStyle
+	case node.Focus:
+		style = focusStyle
+	default:
+		return nil
+	}
+	return &style
+}
+
+var (
+	skipStyle  = decision_tree.NodeStyle{Shape: "rectangle", Fill: "#eeeeee", Stroke: "#999999", StrokeWidth: 1, Class: "skip"}
+	todoStyle  = decision_tree.NodeStyle{Shape: "rectangle", Fill: "#fff8dc", Stroke: "#d4a017", StrokeWidth: 1, Class: "todo"}
+	focusStyle = decision_tree.NodeStyle{Shape: "rectangle", Fill: "#e6f4ff", Stroke: "#1e90ff", StrokeWidth: 3, Class: "focus"}
+)
+
 // ToSVG generates an SVG representation of the tree
 func (t *Tree[Q, R, TC]) ToSVG() string {
 	dt := t.ToDecisionTree()
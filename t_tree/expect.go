@@ -0,0 +1,331 @@
+package t_tree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Expect declares the expected outcome of a node, as an alternative to Assert.
+//
+// Fields maps a path to the expected value, a path is a list of
+// struct field names (or json names), map keys and slice indexes
+// separated by ".", and "#" means the length of a slice, map or string:
+//
+//	Expect: &Expect{Fields: map[string]any{"Code": 3, "Data.Items.#": 2}}
+//
+// Expects are merged down the path: fields of descendants override
+// the same fields of ancestors, and Err of the nearest Expect that
+// sets Err or NoErr applies, so an Expect with only Fields keeps the
+// inherited error.
+type Expect struct {
+	Err    error          // expected error, nil means no error unless inherited
+	NoErr  bool           // expects no error, overrides an inherited Err
+	Fields map[string]any // expected values of the response by path
+}
+
+type expectJSON struct {
+	Err    string         `json:"err,omitempty" yaml:"err,omitempty"`
+	NoErr  bool           `json:"noErr,omitempty" yaml:"noErr,omitempty"`
+	Fields map[string]any `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// MarshalJSON serializes Err as its message
+func (c Expect) MarshalJSON() ([]byte, error) {
+	e := expectJSON{NoErr: c.NoErr, Fields: c.Fields}
+	if c.Err != nil {
+		e.Err = c.Err.Error()
+	}
+	return json.Marshal(e)
+}
+
+// UnmarshalJSON restores Err from its message,
+// so it is matched by message
+func (c *Expect) UnmarshalJSON(data []byte) error {
+	var e expectJSON
+	if err := json.Unmarshal(data, &e); err != nil {
+		return err
+	}
+	c.Fields = e.Fields
+	c.NoErr = e.NoErr
+	c.Err = nil
+	if e.Err != "" {
+		c.Err = errors.New(e.Err)
+	}
+	return nil
+}
+
+// UnmarshalYAML decodes the same structure as UnmarshalJSON
+func (c *Expect) UnmarshalYAML(value *yaml.Node) error {
+	var e expectJSON
+	if err := value.Decode(&e); err != nil {
+		return err
+	}
+	c.Fields = e.Fields
+	c.NoErr = e.NoErr
+	c.Err = nil
+	if e.Err != "" {
+		c.Err = errors.New(e.Err)
+	}
+	return nil
+}
+
+// String formats the expectation as sorted `path=value` pairs
+func (c *Expect) String() string {
+	if c == nil {
+		return ""
+	}
+	keys := c.sortedKeys()
+	pairs := make([]string, 0, len(keys)+1)
+	if c.Err != nil {
+		pairs = append(pairs, fmt.Sprintf("err=%v", c.Err))
+	}
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, c.Fields[k]))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func (c *Expect) sortedKeys() []string {
+	keys := make([]string, 0, len(c.Fields))
+	for k := range c.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Diff evaluates the expectation against the response and error,
+// returns one message for each mismatch
+func (c *Expect) Diff(resp any, err error) []string {
+	if c == nil {
+		return nil
+	}
+	var diffs []string
+	if c.Err == nil {
+		if err != nil {
+			diffs = append(diffs, fmt.Sprintf("err: expect nil, actual: %v", err))
+		}
+	} else if err == nil {
+		diffs = append(diffs, fmt.Sprintf("err: expect %v, actual: nil", c.Err))
+	} else if !errors.Is(err, c.Err) && err.Error() != c.Err.Error() {
+		diffs = append(diffs, fmt.Sprintf("err: expect %v, actual: %v", c.Err, err))
+	}
+
+	root := reflect.ValueOf(resp)
+	for _, path := range c.sortedKeys() {
+		expected := c.Fields[path]
+		actual, lookupErr := lookupPath(root, path)
+		if lookupErr != nil {
+			diffs = append(diffs, fmt.Sprintf("%s: expect %v, %v", path, formatValue(expected), lookupErr))
+			continue
+		}
+		if !matchValue(expected, actual) {
+			diffs = append(diffs, fmt.Sprintf("%s: expect %v, actual: %v", path, formatValue(expected), formatReflectValue(actual)))
+		}
+	}
+	return diffs
+}
+
+// mergeExpect merges child into parent, child fields take precedence,
+// the child's error expectation applies only when it sets Err or NoErr
+func mergeExpect(parent *Expect, child *Expect) *Expect {
+	if parent == nil {
+		return child
+	}
+	if child == nil {
+		return parent
+	}
+	merged := &Expect{
+		Err:    parent.Err,
+		NoErr:  parent.NoErr,
+		Fields: make(map[string]any, len(parent.Fields)+len(child.Fields)),
+	}
+	if child.Err != nil || child.NoErr {
+		merged.Err = child.Err
+		merged.NoErr = child.NoErr
+	}
+	for k, v := range parent.Fields {
+		merged.Fields[k] = v
+	}
+	for k, v := range child.Fields {
+		merged.Fields[k] = v
+	}
+	return merged
+}
+
+// lookupPath resolves a dot separated path against v
+func lookupPath(v reflect.Value, path string) (reflect.Value, error) {
+	if path == "" {
+		return v, nil
+	}
+	segments := strings.Split(path, ".")
+	for i, seg := range segments {
+		v = indirect(v)
+		if !v.IsValid() {
+			return reflect.Value{}, fmt.Errorf("actual: nil at %s", strings.Join(segments[:i], "."))
+		}
+		if seg == "#" {
+			switch v.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
+				v = reflect.ValueOf(v.Len())
+				continue
+			default:
+				return reflect.Value{}, fmt.Errorf("actual: %s has no length", v.Type())
+			}
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			field, ok := findField(v, seg)
+			if !ok {
+				return reflect.Value{}, fmt.Errorf("actual: no field %s in %s", seg, v.Type())
+			}
+			v = field
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, fmt.Errorf("actual: unsupported map key %s", v.Type().Key())
+			}
+			val := v.MapIndex(reflect.ValueOf(seg).Convert(v.Type().Key()))
+			if !val.IsValid() {
+				return reflect.Value{}, fmt.Errorf("actual: missing key %s", seg)
+			}
+			v = val
+		case reflect.Slice, reflect.Array:
+			idx, err := strconv.Atoi(seg)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("actual: invalid index %s", seg)
+			}
+			if idx < 0 || idx >= v.Len() {
+				return reflect.Value{}, fmt.Errorf("actual: index %d out of range %d", idx, v.Len())
+			}
+			v = v.Index(idx)
+		default:
+			return reflect.Value{}, fmt.Errorf("actual: cannot access %s in %s", seg, v.Type())
+		}
+	}
+	return v, nil
+}
+
+// findField finds the field by name, or by json tag name
+func findField(v reflect.Value, name string) (reflect.Value, bool) {
+	if field := v.FieldByName(name); field.IsValid() {
+		return field, true
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		if tag == "" {
+			continue
+		}
+		if strings.Split(tag, ",")[0] == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// matchValue compares expected against actual, numbers
+// are compared by value regardless of their types
+func matchValue(expected any, actual reflect.Value) bool {
+	actual = indirect(actual)
+	if expected == nil {
+		if !actual.IsValid() {
+			return true
+		}
+		switch actual.Kind() {
+		case reflect.Slice, reflect.Map:
+			return actual.IsNil()
+		}
+		return false
+	}
+	if !actual.IsValid() {
+		return false
+	}
+	ev := reflect.ValueOf(expected)
+	if isNumber(ev.Kind()) && isNumber(actual.Kind()) {
+		return compareNumber(ev, actual)
+	}
+	if ev.Kind() == reflect.String && actual.Kind() == reflect.String {
+		return ev.String() == actual.String()
+	}
+	if ev.Kind() == reflect.Bool && actual.Kind() == reflect.Bool {
+		return ev.Bool() == actual.Bool()
+	}
+	if !actual.CanInterface() {
+		return false
+	}
+	return reflect.DeepEqual(expected, actual.Interface())
+}
+
+func isNumber(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func compareNumber(a reflect.Value, b reflect.Value) bool {
+	isFloat := func(k reflect.Kind) bool { return k == reflect.Float32 || k == reflect.Float64 }
+	isUint := func(k reflect.Kind) bool { return k >= reflect.Uint && k <= reflect.Uintptr }
+	if isFloat(a.Kind()) || isFloat(b.Kind()) {
+		return toFloat(a) == toFloat(b)
+	}
+	if isUint(a.Kind()) && isUint(b.Kind()) {
+		return a.Uint() == b.Uint()
+	}
+	if isUint(a.Kind()) {
+		return b.Int() >= 0 && uint64(b.Int()) == a.Uint()
+	}
+	if isUint(b.Kind()) {
+		return a.Int() >= 0 && uint64(a.Int()) == b.Uint()
+	}
+	return a.Int() == b.Int()
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch {
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		return v.Float()
+	case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uintptr:
+		return float64(v.Uint())
+	default:
+		return float64(v.Int())
+	}
+}
+
+func formatValue(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func formatReflectValue(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return "nil"
+	}
+	if !v.CanInterface() {
+		return v.String()
+	}
+	return formatValue(v.Interface())
+}
@@ -0,0 +1,148 @@
+package t_tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/testing_ctx"
+	"github.com/xhd2015/data-driven-testing/testing_ctx/integration"
+)
+
+type expectTestResp struct {
+	Code int64
+	Msg  string `json:"msg"`
+	Data *struct {
+		Items []string
+		Extra map[string]int
+	}
+}
+
+func TestExpectDiff(t *testing.T) {
+	resp := &expectTestResp{Code: 3, Msg: "bad"}
+	resp.Data = &struct {
+		Items []string
+		Extra map[string]int
+	}{Items: []string{"a", "b"}, Extra: map[string]int{"k": 1}}
+
+	errBad := errors.New("bad request")
+	tests := []struct {
+		name   string
+		expect *Expect
+		err    error
+		diffs  []string
+	}{
+		{
+			name:   "match",
+			expect: &Expect{Fields: map[string]any{"Code": 3, "msg": "bad", "Data.Items.#": 2, "Data.Items.1": "b", "Data.Extra.k": 1.0}},
+		},
+		{
+			name:   "mismatch",
+			expect: &Expect{Fields: map[string]any{"Code": 2, "Data.Items.#": 3}},
+			diffs:  []string{"Code: expect 2, actual: 3", "Data.Items.#: expect 3, actual: 2"},
+		},
+		{
+			name:   "missing field",
+			expect: &Expect{Fields: map[string]any{"Data.Missing": 1}},
+			diffs:  []string{"Data.Missing: expect 1, actual: no field Missing in struct { Items []string; Extra map[string]int }"},
+		},
+		{
+			name:   "unexpected error",
+			expect: &Expect{},
+			err:    errBad,
+			diffs:  []string{"err: expect nil, actual: bad request"},
+		},
+		{
+			name:   "expected error",
+			expect: &Expect{Err: errBad},
+			err:    errBad,
+		},
+		{
+			name:   "expected error by message",
+			expect: &Expect{Err: errors.New("bad request")},
+			err:    errBad,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs := tt.expect.Diff(resp, tt.err)
+			if !reflect.DeepEqual(diffs, tt.diffs) {
+				t.Errorf("Diff() = %q, want %q", diffs, tt.diffs)
+			}
+		})
+	}
+}
+
+func TestExpectMergeAndSerialize(t *testing.T) {
+	type N = Node[int, expectTestResp, int]
+	tree := MustBuild(&N{
+		ID:     "root",
+		Expect: &Expect{Fields: map[string]any{"Code": 0, "msg": "ok"}},
+		Run: func(t testing_ctx.T, tctx *int, req *int) (*expectTestResp, error) {
+			return &expectTestResp{Code: 3, Msg: "ok"}, nil
+		},
+	}, []*N{
+		{ID: "pass", InheritAssert: true, Expect: &Expect{Fields: map[string]any{"Code": 3}}},
+		{ID: "fail", InheritAssert: true, Expect: &Expect{Fields: map[string]any{"msg": "not ok"}}},
+		// without InheritAssert, root's Code=0 is not checked
+		{ID: "own", Expect: &Expect{Fields: map[string]any{"msg": "ok"}}},
+	})
+
+	var buf bytes.Buffer
+	tree.Run(integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf}))
+	output := buf.String()
+	if !strings.Contains(output, "PASS pass") {
+		t.Errorf("expect pass to pass: %s", output)
+	}
+	if !strings.Contains(output, "PASS own") {
+		t.Errorf("expect own to pass: %s", output)
+	}
+	if !strings.Contains(output, `Code: expect 0, actual: 3`) || !strings.Contains(output, `msg: expect "not ok", actual: "ok"`) {
+		t.Errorf("expect merged diffs for fail: %s", output)
+	}
+
+	errBoom := errors.New("boom")
+	errTree := MustBuild(&N{
+		ID:     "root",
+		Expect: &Expect{Err: errBoom},
+		Run: func(t testing_ctx.T, tctx *int, req *int) (*expectTestResp, error) {
+			return &expectTestResp{Code: 3}, errBoom
+		},
+	}, []*N{
+		// only Fields, the inherited Err still applies
+		{ID: "fields_only", InheritAssert: true, Expect: &Expect{Fields: map[string]any{"Code": 3}}},
+		{ID: "no_err", InheritAssert: true, Expect: &Expect{NoErr: true}},
+	})
+	buf.Reset()
+	errTree.Run(integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf}))
+	output = buf.String()
+	if !strings.Contains(output, "PASS fields_only") {
+		t.Errorf("expect fields_only to keep the inherited error: %s", output)
+	}
+	if !strings.Contains(output, "err: expect nil, actual: boom") {
+		t.Errorf("expect no_err to override the inherited error: %s", output)
+	}
+
+	data, err := json.Marshal(&Expect{Err: errors.New("boom"), Fields: map[string]any{"Code": 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"err":"boom","fields":{"Code":3}}` {
+		t.Errorf("unexpected json: %s", data)
+	}
+	var decoded Expect
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.String() != "err=boom, Code=3" {
+		t.Errorf("unexpected decoded: %s", decoded.String())
+	}
+
+	dt := tree.ToDecisionTree()
+	if dt.Conditions["expect"] != "Code=0, msg=ok" {
+		t.Errorf("expect condition in decision tree, actual: %v", dt.Conditions)
+	}
+}
@@ -0,0 +1,58 @@
+package t_tree
+
+import (
+	"os"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/testing_ctx"
+)
+
+// EnvAllowFocus allows focused nodes to run in CI.
+// By default, when the CI env is set, a tree containing
+// focused nodes fails, so that a focus is not committed by accident.
+const EnvAllowFocus = "DDT_ALLOW_FOCUS"
+
+// HasFocus reports whether any node in the tree is focused
+func (c *Tree[Q, R, TC]) HasFocus() bool {
+	return len(c.focusedIDs) > 0
+}
+
+// IsFocused reports whether the path runs when the tree has focused nodes,
+// i.e. any node on the path is focused
+func (c NodePath[Q, R, TC]) IsFocused() bool {
+	for _, node := range c {
+		if node.Focus {
+			return true
+		}
+	}
+	return false
+}
+
+// SkipReason returns the first non-empty Skip reason along the path
+func (c NodePath[Q, R, TC]) SkipReason() string {
+	for _, node := range c {
+		if node.Skip != "" {
+			return node.Skip
+		}
+	}
+	return ""
+}
+
+// checkFocus fails the test if focused nodes are found in CI
+func (c *Tree[Q, R, TC]) checkFocus(t testing_ctx.T) bool {
+	if !c.HasFocus() || !FocusForbidden() {
+		return true
+	}
+	t.Errorf("focused nodes are not allowed in CI: %s, set %s=true to override", strings.Join(c.focusedIDs, ","), EnvAllowFocus)
+	return false
+}
+
+// FocusForbidden reports whether focused nodes should fail the run,
+// which is true in CI unless EnvAllowFocus is set
+func FocusForbidden() bool {
+	if os.Getenv("CI") == "" {
+		return false
+	}
+	allow := os.Getenv(EnvAllowFocus)
+	return allow == "" || allow == "false" || allow == "0"
+}
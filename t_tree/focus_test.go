@@ -0,0 +1,103 @@
+package t_tree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/testing_ctx"
+	"github.com/xhd2015/data-driven-testing/testing_ctx/integration"
+)
+
+func TestSkipFocusTodo(t *testing.T) {
+	type N = Node[int, int, int]
+	var ran []string
+	run := func(t testing_ctx.T, tctx *int, req *int) (*int, error) {
+		return req, nil
+	}
+	assertRan := func(id string) func(t testing_ctx.T, tctx *int, req *int, res *int, err error) {
+		return func(t testing_ctx.T, tctx *int, req *int, res *int, err error) {
+			ran = append(ran, id)
+		}
+	}
+	newTree := func(focus bool) *Tree[int, int, int] {
+		return MustBuild(&N{ID: "root", Run: run}, []*N{
+			{ID: "a", Assert: assertRan("a")},
+			{ID: "b", Skip: "flaky", Assert: assertRan("b"), Children: []*N{
+				{ID: "b1", Assert: assertRan("b1")},
+			}},
+			{ID: "c", Todo: true},
+			{ID: "d", Focus: focus, Children: []*N{
+				{ID: "d1", Assert: assertRan("d1")},
+			}},
+		})
+	}
+
+	runTree := func(tree *Tree[int, int, int]) string {
+		var buf bytes.Buffer
+		tctx := integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf})
+		tree.Run(tctx)
+		return buf.String()
+	}
+
+	t.Run("NoFocus", func(t *testing.T) {
+		t.Setenv("CI", "")
+		ran = nil
+		output := runTree(newTree(false))
+		if strings.Join(ran, ",") != "a,d1" {
+			t.Errorf("expect ran a,d1, actual: %v", ran)
+		}
+		if !strings.Contains(output, "SKIP flaky") {
+			t.Errorf("expect skip reason in output: %s", output)
+		}
+		if !strings.Contains(output, "SKIP TODO") {
+			t.Errorf("expect TODO in output: %s", output)
+		}
+		if strings.Contains(output, "FAIL") {
+			t.Errorf("expect no failure: %s", output)
+		}
+	})
+
+	t.Run("Focus", func(t *testing.T) {
+		t.Setenv("CI", "")
+		ran = nil
+		runTree(newTree(true))
+		if strings.Join(ran, ",") != "d1" {
+			t.Errorf("expect ran d1, actual: %v", ran)
+		}
+	})
+
+	t.Run("RunNodeNotFocused", func(t *testing.T) {
+		t.Setenv("CI", "")
+		ran = nil
+		tree := newTree(true)
+		var buf bytes.Buffer
+		tctx := integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf})
+		tctx.Run("a", func(t testing_ctx.T) {
+			tree.RunNode(t, tree.FindNode("a"))
+		})
+		if len(ran) != 0 {
+			t.Errorf("expect unfocused node not run, actual: %v", ran)
+		}
+	})
+
+	t.Run("FocusInCI", func(t *testing.T) {
+		t.Setenv("CI", "true")
+		t.Setenv(EnvAllowFocus, "")
+		ran = nil
+		output := runTree(newTree(true))
+		if len(ran) != 0 {
+			t.Errorf("expect nothing run in CI, actual: %v", ran)
+		}
+		if !strings.Contains(output, "focused nodes are not allowed in CI: d") {
+			t.Errorf("expect focus error: %s", output)
+		}
+
+		t.Setenv(EnvAllowFocus, "true")
+		ran = nil
+		runTree(newTree(true))
+		if strings.Join(ran, ",") != "d1" {
+			t.Errorf("expect ran d1 with override, actual: %v", ran)
+		}
+	})
+}
@@ -0,0 +1,350 @@
+package t_tree
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/xhd2015/data-driven-testing/testing_ctx"
+)
+
+// EnvSeed reproduces a single generated run with the given seed
+const EnvSeed = "DDT_SEED"
+
+// DefaultGenerateCount is the number of generated runs when Node.GenerateCount is not set
+const DefaultGenerateCount = 100
+
+// maxShrinkSteps limits the number of candidates tried when shrinking
+const maxShrinkSteps = 1000
+
+// Generator returns the nearest Generate along the path and its count
+func (c NodePath[Q, R, TC]) Generator() (func(r *rand.Rand, req *Q) *Q, int) {
+	for i := len(c) - 1; i >= 0; i-- {
+		if c[i].Generate != nil {
+			count := c[i].GenerateCount
+			if count <= 0 {
+				count = DefaultGenerateCount
+			}
+			return c[i].Generate, count
+		}
+	}
+	return nil, 0
+}
+
+// Fuzz runs the generated path of node under go's native fuzzing,
+// the fuzz input is the seed of the generator:
+//
+//	func FuzzBoundary(f *testing.F) {
+//		tree.Fuzz(f, boundaryNode)
+//	}
+//
+// Fuzz is the only reason this package imports "testing". Unlike Run,
+// it only applies the middlewares added by Use: runs are neither
+// recorded to EnvRecordDir nor replayed from EnvReplay.
+func (c *Tree[Q, R, TC]) Fuzz(f *testing.F, node *Node[Q, R, TC]) {
+	nodePath := c.GetNodePath(node)
+	if generate, _ := nodePath.Generator(); generate == nil {
+		f.Fatalf("missing Generate: %s", node.ID)
+		return
+	}
+	for i := int64(0); i < 3; i++ {
+		f.Add(i)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		nodePath.runWith(testing_ctx.Std(t), []int64{seed}, c.middlewares)
+	})
+}
+
+// generateSeeds returns the seed from EnvSeed if set,
+// otherwise count seeds derived from current time
+func generateSeeds(count int) ([]int64, error) {
+	if s := os.Getenv(EnvSeed); s != "" {
+		seed, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", EnvSeed, err)
+		}
+		return []int64{seed}, nil
+	}
+	base := time.Now().UnixNano()
+	seeds := make([]int64, count)
+	for i := range seeds {
+		seeds[i] = base + int64(i)
+	}
+	return seeds, nil
+}
+
+// runGenerated runs one attempt for each seed silently, and stops
+// at the first failure: the failing request is shrunk toward
+// the request produced by Setup, and the minimal case is run
+// again with t to report the failure. result holds the run of
+// the minimal case, or the last attempt if all of them passed.
+func runGenerated[Q any, R any, TC any](t testing_ctx.T, generate func(r *rand.Rand, req *Q) *Q, seeds []int64, setup SetupFunc[Q, R, TC], run RunFunc[Q, R, TC], assert AssertFunc[Q, R, TC], result *PathResult[Q, R, TC]) {
+	fails := func(result *PathResult[Q, R, TC], mutate func(req *Q) *Q) bool {
+		return probe(func(t testing_ctx.T) {
+			execute(t, setup, run, assert, mutate, result)
+		})
+	}
+	for _, seed := range seeds {
+		var base, generated *Q
+		attempt := &PathResult[Q, R, TC]{}
+		failed := fails(attempt, func(req *Q) *Q {
+			base = deepCopy(req)
+			generated = generate(rand.New(rand.NewSource(seed)), req)
+			return generated
+		})
+		if !failed {
+			*result = *attempt
+			continue
+		}
+		minimal := shrink(generated, base, func(candidate *Q) bool {
+			return fails(&PathResult[Q, R, TC]{}, func(*Q) *Q {
+				return deepCopy(candidate)
+			})
+		})
+		t.Errorf("generated request failed, seed: %d, reproduce with %s=%d", seed, EnvSeed, seed)
+		t.Logf("generated request: %s", formatJSON(generated))
+		t.Logf("minimal request: %s", formatJSON(minimal))
+		execute(t, setup, run, assert, func(*Q) *Q {
+			return deepCopy(minimal)
+		}, result)
+		return
+	}
+}
+
+var errProbeStop = errors.New("probe stop")
+
+// probeT is a silent T that only tracks whether the test failed
+type probeT struct {
+	failed  bool
+	skipped bool
+}
+
+var _ testing_ctx.T = (*probeT)(nil)
+
+// probe runs f with a silent T, reports whether it failed
+func probe(f func(t testing_ctx.T)) bool {
+	p := &probeT{}
+	p.Run("", f)
+	return p.failed
+}
+
+func (c *probeT) Run(name string, f func(t testing_ctx.T)) {
+	defer func() {
+		if e := recover(); e != nil && e != errProbeStop {
+			c.failed = true
+		}
+	}()
+	f(c)
+}
+func (c *probeT) Logf(format string, args ...interface{}) {}
+func (c *probeT) Log(args ...interface{})                 {}
+func (c *probeT) Errorf(format string, args ...interface{}) {
+	c.failed = true
+}
+func (c *probeT) Error(args ...interface{}) {
+	c.failed = true
+}
+func (c *probeT) Fatalf(format string, args ...interface{}) {
+	c.failed = true
+	panic(errProbeStop)
+}
+func (c *probeT) Fatal(args ...interface{}) {
+	c.failed = true
+	panic(errProbeStop)
+}
+func (c *probeT) Skip(args ...interface{}) {
+	c.skipped = true
+	panic(errProbeStop)
+}
+func (c *probeT) Status() testing_ctx.Status {
+	if c.failed {
+		return testing_ctx.StatusFail
+	}
+	if c.skipped {
+		return testing_ctx.StatusSkip
+	}
+	return testing_ctx.StatusRunning
+}
+
+// shrink repeatedly replaces req with a simpler candidate that still fails,
+// candidates move fields toward base, see shrinkValue
+func shrink[Q any](req *Q, base *Q, fails func(candidate *Q) bool) *Q {
+	if req == nil {
+		return nil
+	}
+	var baseValue reflect.Value
+	if base != nil {
+		baseValue = reflect.ValueOf(base).Elem()
+	} else {
+		baseValue = reflect.Zero(reflect.TypeOf(req).Elem())
+	}
+	current := reflect.ValueOf(req).Elem()
+	steps := 0
+	for progress := true; progress && steps < maxShrinkSteps; {
+		progress = false
+		for _, candidate := range shrinkValue(current, baseValue) {
+			steps++
+			ptr := reflect.New(current.Type())
+			ptr.Elem().Set(candidate)
+			if fails(ptr.Interface().(*Q)) {
+				current = candidate
+				progress = true
+				break
+			}
+			if steps >= maxShrinkSteps {
+				break
+			}
+		}
+	}
+	ptr := reflect.New(current.Type())
+	ptr.Elem().Set(current)
+	return ptr.Interface().(*Q)
+}
+
+// midInt returns the midpoint of x and b rounded toward zero,
+// without overflowing when they are far apart
+func midInt(x, b int64) int64 {
+	return b/2 + x/2 + (b%2+x%2)/2
+}
+
+// shrinkValue returns simpler variants of v, moving toward base
+func shrinkValue(v reflect.Value, base reflect.Value) []reflect.Value {
+	if !base.IsValid() {
+		base = reflect.Zero(v.Type())
+	}
+	if reflect.DeepEqual(v.Interface(), base.Interface()) {
+		return nil
+	}
+	var candidates []reflect.Value
+	add := func(set func(c reflect.Value)) {
+		c := reflect.New(v.Type()).Elem()
+		set(c)
+		candidates = append(candidates, c)
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			for _, fieldCandidate := range shrinkValue(v.Field(i), base.Field(i)) {
+				i, fieldCandidate := i, fieldCandidate
+				add(func(c reflect.Value) {
+					c.Set(v)
+					c.Field(i).Set(fieldCandidate)
+				})
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, b := v.Int(), base.Int()
+		add(func(c reflect.Value) { c.SetInt(b) })
+		if mid := midInt(x, b); mid != b && mid != x {
+			add(func(c reflect.Value) { c.SetInt(mid) })
+		}
+		if x > b {
+			add(func(c reflect.Value) { c.SetInt(x - 1) })
+		} else {
+			add(func(c reflect.Value) { c.SetInt(x + 1) })
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x, b := v.Uint(), base.Uint()
+		add(func(c reflect.Value) { c.SetUint(b) })
+		var mid uint64
+		if x > b {
+			mid = b + (x-b)/2
+		} else {
+			mid = x + (b-x)/2
+		}
+		if mid != b && mid != x {
+			add(func(c reflect.Value) { c.SetUint(mid) })
+		}
+	case reflect.Float32, reflect.Float64:
+		x, b := v.Float(), base.Float()
+		add(func(c reflect.Value) { c.SetFloat(b) })
+		if mid := b/2 + x/2; mid != b && mid != x {
+			add(func(c reflect.Value) { c.SetFloat(mid) })
+		}
+	case reflect.String:
+		add(func(c reflect.Value) { c.SetString(base.String()) })
+		runes := []rune(v.String())
+		if len(runes) > 1 {
+			add(func(c reflect.Value) { c.SetString(string(runes[:len(runes)/2])) })
+			add(func(c reflect.Value) { c.SetString(string(runes[:len(runes)-1])) })
+		}
+	case reflect.Slice:
+		add(func(c reflect.Value) { c.Set(base) })
+		if n := v.Len(); n > 1 {
+			add(func(c reflect.Value) { c.Set(v.Slice(0, n/2)) })
+			add(func(c reflect.Value) { c.Set(v.Slice(0, n-1)) })
+		}
+	case reflect.Ptr:
+		add(func(c reflect.Value) { c.Set(base) })
+		if !v.IsNil() && !base.IsNil() {
+			for _, elemCandidate := range shrinkValue(v.Elem(), base.Elem()) {
+				elemCandidate := elemCandidate
+				add(func(c reflect.Value) {
+					c.Set(reflect.New(v.Type().Elem()))
+					c.Elem().Set(elemCandidate)
+				})
+			}
+		}
+	default:
+		add(func(c reflect.Value) { c.Set(base) })
+	}
+	return candidates
+}
+
+// deepCopy copies exported data reachable from v, so
+// that mutation of the copy does not affect the original
+func deepCopy[Q any](v *Q) *Q {
+	if v == nil {
+		return nil
+	}
+	cp := reflect.New(reflect.TypeOf(v).Elem())
+	copyValue(cp.Elem(), reflect.ValueOf(v).Elem())
+	return cp.Interface().(*Q)
+}
+
+func copyValue(dst reflect.Value, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.New(src.Type().Elem()))
+		copyValue(dst.Elem(), src.Elem())
+	case reflect.Struct:
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if src.Type().Field(i).IsExported() {
+				copyValue(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			copyValue(dst.Index(i), src.Index(i))
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		iter := src.MapRange()
+		for iter.Next() {
+			val := reflect.New(src.Type().Elem()).Elem()
+			copyValue(val, iter.Value())
+			dst.SetMapIndex(iter.Key(), val)
+		}
+	default:
+		dst.Set(src)
+	}
+}
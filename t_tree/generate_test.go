@@ -0,0 +1,160 @@
+package t_tree
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/testing_ctx"
+	"github.com/xhd2015/data-driven-testing/testing_ctx/integration"
+)
+
+type generateTestReq struct {
+	N    int
+	Name string
+	List []int
+}
+
+func newGenerateTestTree(limit int) *Tree[generateTestReq, int, struct{}] {
+	type N = Node[generateTestReq, int, struct{}]
+	return MustBuild(&N{
+		ID: "root",
+		Setup: func(t testing_ctx.T, tctx *struct{}, req *generateTestReq) (*struct{}, *generateTestReq) {
+			return tctx, &generateTestReq{Name: "base"}
+		},
+		Run: func(t testing_ctx.T, tctx *struct{}, req *generateTestReq) (*int, error) {
+			return &req.N, nil
+		},
+	}, []*N{
+		{
+			ID:            "boundary",
+			GenerateCount: 50,
+			Generate: func(r *rand.Rand, req *generateTestReq) *generateTestReq {
+				req.N = r.Intn(1000)
+				req.Name = "generated-name"
+				req.List = []int{r.Int(), r.Int(), r.Int()}
+				return req
+			},
+			Assert: func(t testing_ctx.T, tctx *struct{}, req *generateTestReq, res *int, err error) {
+				if *res >= limit {
+					t.Errorf("expect N < %d, actual: %d", limit, *res)
+				}
+			},
+		},
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("Pass", func(t *testing.T) {
+		var buf bytes.Buffer
+		newGenerateTestTree(1000).Run(integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf}))
+		if strings.Contains(buf.String(), "FAIL") {
+			t.Errorf("expect pass: %s", buf.String())
+		}
+	})
+
+	t.Run("ShrinkOnFailure", func(t *testing.T) {
+		var buf bytes.Buffer
+		newGenerateTestTree(10).Run(integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf}))
+		output := buf.String()
+		if !strings.Contains(output, "reproduce with "+EnvSeed+"=") {
+			t.Errorf("expect seed in output: %s", output)
+		}
+		// other fields are shrunk back to the Setup-produced request
+		if !strings.Contains(output, `minimal request: {"N":10,"Name":"base","List":null}`) {
+			t.Errorf("expect minimal request: %s", output)
+		}
+		if !strings.Contains(output, "expect N < 10, actual: 10") {
+			t.Errorf("expect assert reported on minimal request: %s", output)
+		}
+	})
+
+	t.Run("Seed", func(t *testing.T) {
+		t.Setenv(EnvSeed, "42")
+		var n int
+		tree := newGenerateTestTree(1000)
+		tree.Use(Middleware[generateTestReq, int, struct{}]{
+			Run: func(next RunFunc[generateTestReq, int, struct{}]) RunFunc[generateTestReq, int, struct{}] {
+				return func(t testing_ctx.T, tctx *struct{}, req *generateTestReq) (*int, error) {
+					n++
+					return next(t, tctx, req)
+				}
+			},
+		})
+		var buf bytes.Buffer
+		tree.Run(integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf}))
+		if n != 1 {
+			t.Errorf("expect single run with seed, actual: %d", n)
+		}
+	})
+
+	t.Run("InvalidSeed", func(t *testing.T) {
+		t.Setenv(EnvSeed, "abc")
+		var buf bytes.Buffer
+		newGenerateTestTree(1000).Run(integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf}))
+		output := buf.String()
+		if !strings.Contains(output, "invalid "+EnvSeed) {
+			t.Errorf("expect invalid seed reported: %s", output)
+		}
+	})
+
+	t.Run("ResultOnPass", func(t *testing.T) {
+		var result *PathResult[generateTestReq, int, struct{}]
+		tree := newGenerateTestTree(1000)
+		tree.Use(Middleware[generateTestReq, int, struct{}]{
+			AfterPath: func(t testing_ctx.T, path NodePath[generateTestReq, int, struct{}], res *PathResult[generateTestReq, int, struct{}]) {
+				if path[len(path)-1].ID == "boundary" {
+					result = res
+				}
+			},
+		})
+		var buf bytes.Buffer
+		tree.Run(integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf}))
+		if result == nil || result.Req == nil || result.Resp == nil || result.Req.Name != "generated-name" {
+			t.Errorf("expect result of the last generated run, actual: %+v", result)
+		}
+	})
+}
+
+func TestShrinkIntLimits(t *testing.T) {
+	pairs := [][2]int64{
+		{math.MaxInt64, math.MinInt64},
+		{math.MinInt64, math.MaxInt64},
+		{math.MaxInt64, -1},
+		{math.MinInt64, 1},
+	}
+	for _, pair := range pairs {
+		x, b := pair[0], pair[1]
+		lo, hi := b, x
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		for _, c := range shrinkValue(reflect.ValueOf(x), reflect.ValueOf(b)) {
+			if v := c.Int(); v < lo || v > hi {
+				t.Errorf("shrink %d toward %d: candidate %d out of range", x, b, v)
+			}
+		}
+	}
+	for _, c := range shrinkValue(reflect.ValueOf(math.MaxFloat64), reflect.ValueOf(-math.MaxFloat64)) {
+		if math.IsInf(c.Float(), 0) {
+			t.Errorf("shrink float: unexpected candidate %v", c.Float())
+		}
+	}
+
+	req := int64(math.MaxInt64)
+	base := int64(math.MinInt64)
+	minimal := shrink(&req, &base, func(candidate *int64) bool {
+		return *candidate >= -10
+	})
+	if *minimal != -10 {
+		t.Errorf("expect shrunk to -10, actual: %d", *minimal)
+	}
+}
+
+func FuzzGenerate(f *testing.F) {
+	tree := newGenerateTestTree(1000)
+	tree.Fuzz(f, tree.FindNode("boundary"))
+}
@@ -0,0 +1,258 @@
+package t_tree
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/testing_ctx"
+	"gopkg.in/yaml.v3"
+)
+
+// NodeDef is the data file representation of a Node.
+// Behaviors are referenced by name and bound from a Registry.
+type NodeDef struct {
+	ID            string   `json:"id" yaml:"id"`
+	ParentID      string   `json:"parentID,omitempty" yaml:"parentID,omitempty"`
+	Description   string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags          []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Condition     string   `json:"condition,omitempty" yaml:"condition,omitempty"`
+	InheritAssert bool     `json:"inheritAssert,omitempty" yaml:"inheritAssert,omitempty"`
+
+	Skip  string `json:"skip,omitempty" yaml:"skip,omitempty"`
+	Focus bool   `json:"focus,omitempty" yaml:"focus,omitempty"`
+	Todo  bool   `json:"todo,omitempty" yaml:"todo,omitempty"`
+
+	Run    string `json:"run,omitempty" yaml:"run,omitempty"`       // name in Registry.Runs
+	Setup  string `json:"setup,omitempty" yaml:"setup,omitempty"`   // name in Registry.Setups
+	Assert string `json:"assert,omitempty" yaml:"assert,omitempty"` // name in Registry.Asserts
+
+	// Request is merged into the request built by parent nodes,
+	// before the named Setup is called
+	Request any `json:"request,omitempty" yaml:"request,omitempty"`
+	// Response is the expected response, only fields present
+	// are compared against the actual response
+	Response any `json:"response,omitempty" yaml:"response,omitempty"`
+	// Expect is the declarative expectation, see Expect
+	Expect *Expect `json:"expect,omitempty" yaml:"expect,omitempty"`
+
+	Children []*NodeDef `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// Registry holds named behaviors referenced by data files
+type Registry[Q any, R any, TC any] struct {
+	Runs    map[string]func(t testing_ctx.T, tctx *TC, req *Q) (*R, error)
+	Setups  map[string]func(t testing_ctx.T, tctx *TC, req *Q) (*TC, *Q)
+	Asserts map[string]func(t testing_ctx.T, tctx *TC, req *Q, res *R, err error)
+}
+
+// LoadNodes reads node definitions from a YAML or JSON file,
+// the format is determined by the file extension.
+// The file contains a list of nodes, nodes without parent
+// are attached to the root when passed to Build.
+func LoadNodes[Q any, R any, TC any](file string, registry *Registry[Q, R, TC]) ([]*Node[Q, R, TC], error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var format string
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		format = "yaml"
+	case ".json":
+		format = "json"
+	default:
+		return nil, fmt.Errorf("unsupported file: %s, requires .yaml, .yml or .json", file)
+	}
+	nodes, err := ParseNodes(data, format, registry)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return nodes, nil
+}
+
+// ParseNodes parses node definitions in the given format, either yaml or json
+func ParseNodes[Q any, R any, TC any](data []byte, format string, registry *Registry[Q, R, TC]) ([]*Node[Q, R, TC], error) {
+	var defs []*NodeDef
+	switch format {
+	case "yaml":
+		if err := yaml.Unmarshal(data, &defs); err != nil {
+			return nil, err
+		}
+	case "json":
+		if err := json.Unmarshal(data, &defs); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	if registry == nil {
+		registry = &Registry[Q, R, TC]{}
+	}
+	nodes := make([]*Node[Q, R, TC], 0, len(defs))
+	for _, def := range defs {
+		node, err := registry.bind(def)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// bind converts a NodeDef to a Node, resolving behaviors by name
+func (c *Registry[Q, R, TC]) bind(def *NodeDef) (*Node[Q, R, TC], error) {
+	if def == nil {
+		return nil, fmt.Errorf("empty node")
+	}
+	node := &Node[Q, R, TC]{
+		ID:            def.ID,
+		ParentID:      def.ParentID,
+		Description:   def.Description,
+		Tags:          def.Tags,
+		Condition:     def.Condition,
+		InheritAssert: def.InheritAssert,
+		Skip:          def.Skip,
+		Focus:         def.Focus,
+		Todo:          def.Todo,
+		Expect:        def.Expect,
+	}
+	if def.Run != "" {
+		node.Run = c.Runs[def.Run]
+		if node.Run == nil {
+			return nil, fmt.Errorf("%s: run not registered: %s", def.ID, def.Run)
+		}
+	}
+
+	var setup func(t testing_ctx.T, tctx *TC, req *Q) (*TC, *Q)
+	if def.Setup != "" {
+		setup = c.Setups[def.Setup]
+		if setup == nil {
+			return nil, fmt.Errorf("%s: setup not registered: %s", def.ID, def.Setup)
+		}
+	}
+	node.Setup = setup
+	if def.Request != nil {
+		payload, err := json.Marshal(def.Request)
+		if err != nil {
+			return nil, fmt.Errorf("%s: request: %w", def.ID, err)
+		}
+		node.Setup = func(t testing_ctx.T, tctx *TC, req *Q) (*TC, *Q) {
+			if req == nil {
+				req = new(Q)
+			}
+			if err := json.Unmarshal(payload, req); err != nil {
+				t.Fatalf("%s: decode request: %v", def.ID, err)
+			}
+			if setup != nil {
+				return setup(t, tctx, req)
+			}
+			return tctx, req
+		}
+	}
+
+	var assert func(t testing_ctx.T, tctx *TC, req *Q, res *R, err error)
+	if def.Assert != "" {
+		assert = c.Asserts[def.Assert]
+		if assert == nil {
+			return nil, fmt.Errorf("%s: assert not registered: %s", def.ID, def.Assert)
+		}
+	}
+	node.Assert = assert
+	if def.Response != nil {
+		expected, err := normalizeJSON(def.Response)
+		if err != nil {
+			return nil, fmt.Errorf("%s: response: %w", def.ID, err)
+		}
+		node.Assert = func(t testing_ctx.T, tctx *TC, req *Q, res *R, err error) {
+			actual, marshalErr := normalizeJSON(res)
+			if marshalErr != nil {
+				t.Errorf("encode response: %v", marshalErr)
+			} else {
+				for _, diff := range diffSubset("response", expected, actual) {
+					t.Errorf("%s", diff)
+				}
+			}
+			if assert != nil {
+				assert(t, tctx, req, res, err)
+			}
+		}
+	}
+
+	for _, childDef := range def.Children {
+		child, err := c.bind(childDef)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+	}
+	return node, nil
+}
+
+// normalizeJSON converts v to its generic JSON form,
+// i.e. map[string]any, []any, float64, string, bool or nil
+func normalizeJSON(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var res any
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// diffSubset compares expected against actual, keys
+// absent from expected objects are ignored
+func diffSubset(path string, expected any, actual any) []string {
+	switch expected := expected.(type) {
+	case map[string]any:
+		actualMap, ok := actual.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expect object, actual: %s", path, formatJSON(actual))}
+		}
+		keys := make([]string, 0, len(expected))
+		for k := range expected {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var diffs []string
+		for _, k := range keys {
+			actualVal, ok := actualMap[k]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("%s.%s: missing, expect: %s", path, k, formatJSON(expected[k])))
+				continue
+			}
+			diffs = append(diffs, diffSubset(path+"."+k, expected[k], actualVal)...)
+		}
+		return diffs
+	case []any:
+		actualList, ok := actual.([]any)
+		if !ok || len(actualList) != len(expected) {
+			return []string{fmt.Sprintf("%s: expect %s, actual: %s", path, formatJSON(expected), formatJSON(actual))}
+		}
+		var diffs []string
+		for i := range expected {
+			diffs = append(diffs, diffSubset(fmt.Sprintf("%s[%d]", path, i), expected[i], actualList[i])...)
+		}
+		return diffs
+	default:
+		if !reflect.DeepEqual(expected, actual) {
+			return []string{fmt.Sprintf("%s: expect %s, actual: %s", path, formatJSON(expected), formatJSON(actual))}
+		}
+		return nil
+	}
+}
+
+func formatJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
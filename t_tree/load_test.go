@@ -0,0 +1,82 @@
+package t_tree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/testing_ctx"
+	"github.com/xhd2015/data-driven-testing/testing_ctx/integration"
+)
+
+func TestLoadNodes(t *testing.T) {
+	type Req struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	type Resp struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	var logged []string
+	registry := &Registry[Req, Resp, struct{}]{
+		Asserts: map[string]func(t testing_ctx.T, tctx *struct{}, req *Req, res *Resp, err error){
+			"logMessage": func(t testing_ctx.T, tctx *struct{}, req *Req, res *Resp, err error) {
+				logged = append(logged, req.Name+":"+res.Message)
+			},
+		},
+	}
+	nodes, err := LoadNodes("testdata/nodes.yaml", registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := MustBuild(&Node[Req, Resp, struct{}]{
+		ID: "Root",
+		Run: func(t testing_ctx.T, tctx *struct{}, req *Req) (*Resp, error) {
+			if req.Age < 18 {
+				return &Resp{Code: 3, Message: "too young"}, nil
+			}
+			// intentionally wrong message to verify unspecified fields are ignored
+			return &Resp{Code: 0, Message: "ok"}, nil
+		},
+	}, nodes)
+
+	if tree.FindNode("Minor") == nil || tree.FindNode("Empty") == nil {
+		t.Fatalf("expect nodes loaded")
+	}
+
+	var buf bytes.Buffer
+	tree.Run(integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf}))
+	output := buf.String()
+	if strings.Contains(output, "FAIL") {
+		t.Errorf("expect all pass: %s", output)
+	}
+	if len(logged) != 1 || logged[0] != "alice:too young" {
+		t.Errorf("expect request merged along the path, actual: %v", logged)
+	}
+
+	t.Run("Mismatch", func(t *testing.T) {
+		nodes, err := ParseNodes([]byte(`[{"id":"A","response":{"code":1}}]`), "json", registry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree := MustBuild(&Node[Req, Resp, struct{}]{
+			ID: "Root",
+			Run: func(t testing_ctx.T, tctx *struct{}, req *Req) (*Resp, error) {
+				return &Resp{Code: 2}, nil
+			},
+		}, nodes)
+		var buf bytes.Buffer
+		tree.Run(integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf}))
+		if !strings.Contains(buf.String(), "response.code: expect 1, actual: 2") {
+			t.Errorf("expect diff in output: %s", buf.String())
+		}
+	})
+
+	t.Run("Unregistered", func(t *testing.T) {
+		_, err := ParseNodes([]byte(`[{"id":"A","run":"missing"}]`), "json", registry)
+		if err == nil || !strings.Contains(err.Error(), "run not registered: missing") {
+			t.Errorf("expect unregistered error, actual: %v", err)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package t_tree
+
+import "github.com/xhd2015/data-driven-testing/testing_ctx"
+
+// RunFunc runs the request, see Node.Run
+type RunFunc[Q any, R any, TC any] func(t testing_ctx.T, tctx *TC, req *Q) (*R, error)
+
+// SetupFunc builds the request and testing context of a path, see NodePath.Setup
+type SetupFunc[Q any, R any, TC any] func(t testing_ctx.T) (*Q, *TC)
+
+// AssertFunc checks the response of a path, see NodePath.Assert
+type AssertFunc[Q any, R any, TC any] func(t testing_ctx.T, tctx *TC, req *Q, resp *R, err error)
+
+// PathResult holds the outcome of running a NodePath
+type PathResult[Q any, R any, TC any] struct {
+	TC   *TC
+	Req  *Q
+	Resp *R
+	Err  error
+}
+
+// Middleware adds cross-cutting behavior to every NodePath run by a tree.
+// All fields are optional.
+// When multiple middlewares are used, the first one is the outermost.
+type Middleware[Q any, R any, TC any] struct {
+	Run    func(next RunFunc[Q, R, TC]) RunFunc[Q, R, TC]
+	Setup  func(next SetupFunc[Q, R, TC]) SetupFunc[Q, R, TC]
+	Assert func(next AssertFunc[Q, R, TC]) AssertFunc[Q, R, TC]
+
+	// BeforePath is called before the path's Setup
+	BeforePath func(t testing_ctx.T, path NodePath[Q, R, TC])
+	// AfterPath is called after the path's Assert,
+	// even if the assert fails fatally
+	AfterPath func(t testing_ctx.T, path NodePath[Q, R, TC], result *PathResult[Q, R, TC])
+}
+
+// Use adds middlewares applied to every path run by Run and RunNode
+func (c *Tree[Q, R, TC]) Use(middlewares ...Middleware[Q, R, TC]) {
+	c.middlewares = append(c.middlewares, middlewares...)
+}
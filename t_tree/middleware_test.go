@@ -0,0 +1,72 @@
+package t_tree
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/testing_ctx"
+	"github.com/xhd2015/data-driven-testing/testing_ctx/integration"
+)
+
+func TestMiddleware(t *testing.T) {
+	type N = Node[int, int, int]
+	var events []string
+	tree := MustBuild(&N{
+		ID: "root",
+		Setup: func(t testing_ctx.T, tctx *int, req *int) (*int, *int) {
+			v := 1
+			return tctx, &v
+		},
+		Run: func(t testing_ctx.T, tctx *int, req *int) (*int, error) {
+			events = append(events, "run")
+			res := *req * 10
+			return &res, nil
+		},
+	}, []*N{
+		{ID: "a", Assert: func(t testing_ctx.T, tctx *int, req *int, res *int, err error) {
+			events = append(events, fmt.Sprintf("assert:%d", *res))
+		}},
+	})
+
+	trace := func(name string) Middleware[int, int, int] {
+		return Middleware[int, int, int]{
+			Run: func(next RunFunc[int, int, int]) RunFunc[int, int, int] {
+				return func(t testing_ctx.T, tctx *int, req *int) (*int, error) {
+					events = append(events, name+":run:before")
+					defer func() {
+						events = append(events, name+":run:after")
+					}()
+					return next(t, tctx, req)
+				}
+			},
+			BeforePath: func(t testing_ctx.T, path NodePath[int, int, int]) {
+				events = append(events, name+":before:"+path[len(path)-1].ID)
+			},
+			AfterPath: func(t testing_ctx.T, path NodePath[int, int, int], result *PathResult[int, int, int]) {
+				events = append(events, fmt.Sprintf("%s:after:%d", name, *result.Resp))
+			},
+		}
+	}
+	tree.Use(trace("m1"), trace("m2"))
+
+	var buf bytes.Buffer
+	tree.Run(integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf}))
+
+	expected := []string{
+		"m1:before:a",
+		"m2:before:a",
+		"m1:run:before",
+		"m2:run:before",
+		"run",
+		"m2:run:after",
+		"m1:run:after",
+		"assert:10",
+		"m2:after:10",
+		"m1:after:10",
+	}
+	if strings.Join(events, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expect events:\n%s\nactual:\n%s", strings.Join(expected, "\n"), strings.Join(events, "\n"))
+	}
+}
@@ -1,6 +1,10 @@
 package t_tree
 
-import "github.com/xhd2015/data-driven-testing/testing_ctx"
+import (
+	"math/rand"
+
+	"github.com/xhd2015/data-driven-testing/testing_ctx"
+)
 
 // Node defines a node in the tree of testing cases
 // Q: request
@@ -13,6 +17,18 @@ type Node[Q any, R any, TC any] struct {
 	InheritAssert bool            // by default assert is not inherited
 	Description   string
 	Tags          []string // for grouping
+	Condition     string   // branch condition from the parent, e.g. "user_state=3", shown on the edge
+
+	Skip  string // if not empty, the node and its subtree are skipped with this reason
+	Focus bool   // if any node is focused, only focused subtrees run
+	Todo  bool   // the node is reported as TODO instead of being run
+
+	Expect *Expect // declarative expectation, evaluated before Assert
+
+	// Generate mutates the request produced by Setup randomly,
+	// the path runs GenerateCount times, see NodePath.Generator
+	Generate      func(r *rand.Rand, req *Q) *Q
+	GenerateCount int // defaults to DefaultGenerateCount
 
 	Run    func(t testing_ctx.T, tctx *TC, req *Q) (*R, error)
 	Setup  func(t testing_ctx.T, tctx *TC, req *Q) (*TC, *Q)
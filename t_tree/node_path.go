@@ -31,16 +31,89 @@ func (c *PanicError) Unwrap() error {
 type NodePath[Q any, R any, TC any] []*Node[Q, R, TC]
 
 func (c NodePath[Q, R, TC]) Run(t testing_ctx.T) {
+	c.RunWith(t)
+}
+
+// RunWith runs the path with the given middlewares
+func (c NodePath[Q, R, TC]) RunWith(t testing_ctx.T, middlewares ...Middleware[Q, R, TC]) {
+	c.runWith(t, nil, middlewares)
+}
+
+// runWith runs the path, if the path has a generator, it runs
+// once for each seed, seeds default to the generator's count
+func (c NodePath[Q, R, TC]) runWith(t testing_ctx.T, seeds []int64, middlewares []Middleware[Q, R, TC]) {
 	if len(c) == 0 {
 		t.Error("node path is empty")
 		return
 	}
+	if reason := c.SkipReason(); reason != "" {
+		t.Skip(reason)
+		return
+	}
+	if c[len(c)-1].Todo {
+		t.Skip("TODO")
+		return
+	}
 	runner := c.Runner()
 	if runner == nil {
 		t.Errorf("missing runner: %s", c[len(c)-1].ID)
 		return
 	}
-	req, tctx := c.Setup(t)
+
+	var run RunFunc[Q, R, TC] = runner
+	var setup SetupFunc[Q, R, TC] = c.Setup
+	var assert AssertFunc[Q, R, TC] = c.Assert
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		mw := middlewares[i]
+		if mw.Run != nil {
+			run = mw.Run(run)
+		}
+		if mw.Setup != nil {
+			setup = mw.Setup(setup)
+		}
+		if mw.Assert != nil {
+			assert = mw.Assert(assert)
+		}
+	}
+
+	result := &PathResult[Q, R, TC]{}
+	for _, mw := range middlewares {
+		if mw.BeforePath != nil {
+			mw.BeforePath(t, c)
+		}
+	}
+	defer func() {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			if middlewares[i].AfterPath != nil {
+				middlewares[i].AfterPath(t, c, result)
+			}
+		}
+	}()
+
+	if generate, count := c.Generator(); generate != nil {
+		if seeds == nil {
+			var err error
+			seeds, err = generateSeeds(count)
+			if err != nil {
+				t.Fatalf("%v", err)
+				return
+			}
+		}
+		runGenerated(t, generate, seeds, setup, run, assert, result)
+		return
+	}
+	execute(t, setup, run, assert, nil, result)
+}
+
+// execute runs setup, run and assert once, mutate optionally
+// replaces the request produced by setup
+func execute[Q any, R any, TC any](t testing_ctx.T, setup SetupFunc[Q, R, TC], run RunFunc[Q, R, TC], assert AssertFunc[Q, R, TC], mutate func(req *Q) *Q, result *PathResult[Q, R, TC]) {
+	req, tctx := setup(t)
+	if mutate != nil {
+		req = mutate(req)
+	}
+	result.Req = req
+	result.TC = tctx
 
 	var resp *R
 	var err error
@@ -53,10 +126,12 @@ func (c NodePath[Q, R, TC]) Run(t testing_ctx.T) {
 				}
 			}
 		}()
-		resp, err = runner(t, tctx, req)
+		resp, err = run(t, tctx, req)
 	}()
+	result.Resp = resp
+	result.Err = err
 
-	c.Assert(t, tctx, req, resp, err)
+	assert(t, tctx, req, resp, err)
 }
 
 func (c NodePath[Q, R, TC]) Runner() func(t testing_ctx.T, tctx *TC, req *Q) (*R, error) {
@@ -102,16 +177,38 @@ func (c NodePath[Q, R, TC]) Assert(t testing_ctx.T, tctx *TC, req *Q, resp *R, e
 		}
 	}
 
-	if len(asserts) == 0 {
+	expect := c.Expect()
+	if len(asserts) == 0 && expect == nil {
 		t.Skip("no assert")
 		return
 	}
+	for _, diff := range expect.Diff(resp, err) {
+		t.Errorf("%s", diff)
+	}
 
 	for i := len(asserts) - 1; i >= 0; i-- {
 		asserts[i](t, tctx, req, resp, err)
 	}
 }
 
+// Expect returns the expectation of the leaf merged with its ancestors',
+// nil if none. Like Assert, the chain stops at the first node from the
+// leaf up that does not set InheritAssert.
+func (c NodePath[Q, R, TC]) Expect() *Expect {
+	if len(c) == 0 {
+		return nil
+	}
+	start := len(c) - 1
+	for start > 0 && c[start].InheritAssert {
+		start--
+	}
+	var expect *Expect
+	for _, nd := range c[start:] {
+		expect = mergeExpect(expect, nd.Expect)
+	}
+	return expect
+}
+
 func (c NodePath[Q, R, TC]) Parent() NodePath[Q, R, TC] {
 	n := len(c)
 	if n == 0 {
@@ -0,0 +1,244 @@
+package t_tree
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/xhd2015/data-driven-testing/testing_ctx"
+)
+
+// EnvRecordDir enables recording of every path run by a tree into the given directory
+const EnvRecordDir = "DDT_RECORD_DIR"
+
+// EnvReplay points to a recorded artifact, when set, trees
+// only run the recorded path, with Setup and Run replaced
+// by the captured request and response.
+// The captured error is only kept as its message, and is replayed
+// as errors.New(message): Asserts checking it with errors.Is or
+// errors.As do not match, compare err.Error() instead.
+const EnvReplay = "DDT_REPLAY"
+
+// Record is the artifact of a single NodePath run
+type Record struct {
+	Path       []string        `json:"path"`               // IDs from root to leaf
+	TestName   string          `json:"testName,omitempty"` // full name of the test, if known
+	Dir        string          `json:"dir,omitempty"`      // working directory of the test process
+	RecordedAt time.Time       `json:"recordedAt"`
+	Req        json.RawMessage `json:"req,omitempty"`
+	Resp       json.RawMessage `json:"resp,omitempty"`
+	Err        string          `json:"err,omitempty"`
+	TC         json.RawMessage `json:"tc,omitempty"`
+
+	// MarshalErrors collects values that cannot be serialized
+	MarshalErrors []string `json:"marshalErrors,omitempty"`
+}
+
+// ReadRecord reads a record from the artifact file
+func ReadRecord(file string) (*Record, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("parse record %s: %w", file, err)
+	}
+	if len(record.Path) == 0 {
+		return nil, fmt.Errorf("invalid record %s: empty path", file)
+	}
+	return &record, nil
+}
+
+// RecordFileName returns the artifact file name of the path.
+// IDs are joined by "." with characters other than letters, digits,
+// "_" and "-" replaced by "_". When that changes an ID, a short hash
+// of the path is appended after "~", so that different paths such
+// as a.b and a_b never share a file.
+func RecordFileName(path []string) string {
+	names := make([]string, 0, len(path))
+	changed := false
+	for _, id := range path {
+		name := sanitizeFileName(id)
+		if name == "" {
+			name = "_"
+		}
+		if name != id {
+			changed = true
+		}
+		names = append(names, name)
+	}
+	fileName := strings.Join(names, ".")
+	if changed {
+		sum := sha256.Sum256([]byte(strings.Join(path, "\x00")))
+		fileName += "~" + hex.EncodeToString(sum[:4])
+	}
+	return fileName + ".json"
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// NewRecorder creates a middleware that writes the final request, response,
+// error and testing context of every path into dir, one file per path.
+// Failure to record is logged, but does not fail the test.
+func NewRecorder[Q any, R any, TC any](dir string) Middleware[Q, R, TC] {
+	return Middleware[Q, R, TC]{
+		AfterPath: func(t testing_ctx.T, path NodePath[Q, R, TC], result *PathResult[Q, R, TC]) {
+			record := newRecord(t, path, result)
+			file, err := writeRecord(dir, record)
+			if err != nil {
+				t.Logf("record %s: %v", strings.Join(record.Path, "/"), err)
+				return
+			}
+			t.Logf("recorded: %s", file)
+		},
+	}
+}
+
+func newRecord[Q any, R any, TC any](t testing_ctx.T, path NodePath[Q, R, TC], result *PathResult[Q, R, TC]) *Record {
+	record := &Record{
+		Path:       path.IDs(),
+		RecordedAt: time.Now(),
+	}
+	if named, ok := t.(interface{ Name() string }); ok {
+		record.TestName = named.Name()
+	}
+	if wd, err := os.Getwd(); err == nil {
+		record.Dir = wd
+	}
+	marshal := func(name string, v interface{}) json.RawMessage {
+		data, err := json.Marshal(v)
+		if err != nil {
+			record.MarshalErrors = append(record.MarshalErrors, fmt.Sprintf("%s: %v", name, err))
+			return nil
+		}
+		return data
+	}
+	if result.Req != nil {
+		record.Req = marshal("req", result.Req)
+	}
+	if result.Resp != nil {
+		record.Resp = marshal("resp", result.Resp)
+	}
+	if result.TC != nil {
+		record.TC = marshal("tc", result.TC)
+	}
+	if result.Err != nil {
+		record.Err = result.Err.Error()
+	}
+	return record
+}
+
+func writeRecord(dir string, record *Record) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(record, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, RecordFileName(record.Path))
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// newReplayer creates a middleware that replaces Setup and Run
+// with the captured values of the record, so that only Assert is
+// actually executed. The error is rebuilt from its message, see EnvReplay.
+func newReplayer[Q any, R any, TC any](record *Record) Middleware[Q, R, TC] {
+	return Middleware[Q, R, TC]{
+		Setup: func(next SetupFunc[Q, R, TC]) SetupFunc[Q, R, TC] {
+			return func(t testing_ctx.T) (*Q, *TC) {
+				var tc TC
+				tctx := &tc
+				if t != nil {
+					var itctx interface{} = tctx
+					if tctx, ok := itctx.(ITestingAware); ok {
+						tctx.OnTestingInit(t)
+					}
+				}
+				if len(record.TC) > 0 {
+					if err := json.Unmarshal(record.TC, tctx); err != nil {
+						t.Errorf("replay tc: %v", err)
+					}
+				}
+				var req *Q
+				if len(record.Req) > 0 {
+					req = new(Q)
+					if err := json.Unmarshal(record.Req, req); err != nil {
+						t.Errorf("replay req: %v", err)
+					}
+				}
+				return req, tctx
+			}
+		},
+		Run: func(next RunFunc[Q, R, TC]) RunFunc[Q, R, TC] {
+			return func(t testing_ctx.T, tctx *TC, req *Q) (*R, error) {
+				var resp *R
+				if len(record.Resp) > 0 {
+					resp = new(R)
+					if err := json.Unmarshal(record.Resp, resp); err != nil {
+						t.Errorf("replay resp: %v", err)
+					}
+				}
+				var err error
+				if record.Err != "" {
+					err = errors.New(record.Err)
+				}
+				return resp, err
+			}
+		},
+	}
+}
+
+// loadReplay loads the record pointed by EnvReplay, returns nil if not set
+func loadReplay(t testing_ctx.T) (*Record, bool) {
+	file := os.Getenv(EnvReplay)
+	if file == "" {
+		return nil, true
+	}
+	record, err := ReadRecord(file)
+	if err != nil {
+		t.Errorf("replay: %v", err)
+		return nil, false
+	}
+	return record, true
+}
+
+// IDs returns the IDs of nodes in the path
+func (c NodePath[Q, R, TC]) IDs() []string {
+	ids := make([]string, 0, len(c))
+	for _, node := range c {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+// matchRecord reports whether the path is a prefix of the recorded path,
+// and whether it is exactly the recorded path
+func (c NodePath[Q, R, TC]) matchRecord(record *Record) (prefix bool, exact bool) {
+	if len(c) > len(record.Path) {
+		return false, false
+	}
+	for i, node := range c {
+		if node.ID != record.Path[i] {
+			return false, false
+		}
+	}
+	return true, len(c) == len(record.Path)
+}
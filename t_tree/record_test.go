@@ -0,0 +1,122 @@
+package t_tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/testing_ctx"
+	"github.com/xhd2015/data-driven-testing/testing_ctx/integration"
+)
+
+func TestRecordReplay(t *testing.T) {
+	type Req struct{ A int }
+	type Resp struct{ B int }
+	type N = Node[Req, Resp, struct{}]
+
+	var runCount int
+	var asserted []string
+	newTree := func() *Tree[Req, Resp, struct{}] {
+		return MustBuild(&N{
+			ID: "root",
+			Setup: func(t testing_ctx.T, tctx *struct{}, req *Req) (*struct{}, *Req) {
+				return tctx, &Req{A: 1}
+			},
+			Run: func(t testing_ctx.T, tctx *struct{}, req *Req) (*Resp, error) {
+				runCount++
+				return &Resp{B: req.A * 10}, errors.New("partial")
+			},
+		}, []*N{
+			{ID: "a", Setup: func(t testing_ctx.T, tctx *struct{}, req *Req) (*struct{}, *Req) {
+				req.A = 2
+				return tctx, req
+			}, Assert: func(t testing_ctx.T, tctx *struct{}, req *Req, res *Resp, err error) {
+				asserted = append(asserted, "a")
+				if req.A != 2 || res.B != 20 || err == nil || err.Error() != "partial" {
+					t.Errorf("unexpected: req=%v res=%v err=%v", req, res, err)
+				}
+			}},
+			{ID: "b", Assert: func(t testing_ctx.T, tctx *struct{}, req *Req, res *Resp, err error) {
+				asserted = append(asserted, "b")
+			}},
+		})
+	}
+	runTree := func() string {
+		var buf bytes.Buffer
+		newTree().Run(integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf}))
+		return buf.String()
+	}
+
+	dir := t.TempDir()
+	t.Setenv(EnvRecordDir, dir)
+	runTree()
+
+	file := filepath.Join(dir, RecordFileName([]string{"root", "a"}))
+	record, err := ReadRecord(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req Req
+	var resp Resp
+	if err := json.Unmarshal(record.Req, &req); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(record.Resp, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if req.A != 2 || resp.B != 20 || record.Err != "partial" {
+		t.Errorf("unexpected record: req=%s resp=%s err=%s", record.Req, record.Resp, record.Err)
+	}
+	if record.TestName != "root/a" {
+		t.Errorf("expect test name root/a, actual: %s", record.TestName)
+	}
+
+	t.Setenv(EnvRecordDir, "")
+	t.Setenv(EnvReplay, file)
+	runCount = 0
+	asserted = nil
+	output := runTree()
+	if runCount != 0 {
+		t.Errorf("expect Run not called in replay, actual: %d", runCount)
+	}
+	if len(asserted) != 1 || asserted[0] != "a" {
+		t.Errorf("expect only a asserted, actual: %v, output: %s", asserted, output)
+	}
+
+	os.Remove(file)
+	var buf bytes.Buffer
+	newTree().Run(integration.WithOptions(integration.Options{InfoWriter: &buf, ErrWriter: &buf}))
+	if !bytes.Contains(buf.Bytes(), []byte("replay:")) {
+		t.Errorf("expect replay error for missing artifact: %s", buf.String())
+	}
+}
+
+func TestRecordFileName(t *testing.T) {
+	paths := [][]string{
+		{"root", "a.b"},
+		{"root", "a_b"},
+		{"root", "a", "b"},
+		{"root", "a/b"},
+		{"root", ""},
+		{"root", "_"},
+	}
+	seen := make(map[string][]string, len(paths))
+	for _, path := range paths {
+		name := RecordFileName(path)
+		if prev, ok := seen[name]; ok {
+			t.Errorf("paths %q and %q share the file name %s", prev, path, name)
+		}
+		seen[name] = path
+	}
+	// paths with plain ids keep their readable names
+	if name := RecordFileName([]string{"root", "a_b"}); name != "root.a_b.json" {
+		t.Errorf("expect root.a_b.json, actual: %s", name)
+	}
+	if name := RecordFileName([]string{"root", "a.b"}); !strings.HasPrefix(name, "root.a_b~") {
+		t.Errorf("expect hashed name, actual: %s", name)
+	}
+}
@@ -14,7 +14,8 @@ func ParseFile(fset *token.FileSet, file string) (*ast.File, []byte, error) {
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to read file: %w", err)
 	}
-	astFile, err := ParseCode(fset, string(bytes))
+	// keep the file name so positions can be reported
+	astFile, err := parser.ParseFile(fset, file, bytes, parser.ParseComments)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to parse AST: %w", err)
 	}
@@ -0,0 +1,390 @@
+package t_tree_static
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+// ScaffoldOptions configures the code generated from a decision tree
+type ScaffoldOptions struct {
+	Package string // package of the generated file
+	Var     string // variable holding the nodes, default Nodes
+
+	// type arguments of t_tree.Node, default Req, Resp and TC
+	Request  string
+	Response string
+	Context  string
+	// DeclareTypes declares the type arguments as empty structs,
+	// for packages that do not define them yet
+	DeclareTypes bool
+	// DeclaredTypes are the types the package already declares,
+	// DeclareTypes only declares the other type arguments
+	DeclaredTypes map[string]bool
+	// Consts are string constants declared by other files of the
+	// package, used by ScaffoldMerge to resolve IDs like `ID: idValid`
+	Consts map[string]string
+}
+
+func (c *ScaffoldOptions) withDefaults() ScaffoldOptions {
+	opts := *c
+	if opts.Var == "" {
+		opts.Var = "Nodes"
+	}
+	if opts.Request == "" {
+		opts.Request = "Req"
+	}
+	if opts.Response == "" {
+		opts.Response = "Resp"
+	}
+	if opts.Context == "" {
+		opts.Context = "TC"
+	}
+	return opts
+}
+
+// scaffoldNode is a node of the decision tree flattened in pre-order
+type scaffoldNode struct {
+	node     *decision_tree.Node
+	id       string
+	parentID string
+	// key identifies nodes without ID by their position under the
+	// nearest ancestor with ID, e.g. "root/1/0", empty for nodes with
+	// ID. It is written next to the derived ID, so that merging finds
+	// relabelled nodes
+	key string
+}
+
+// scaffoldKeyComment marks derived IDs in the generated code
+const scaffoldKeyComment = "scaffold key: "
+
+// Scaffold generates a Go test file declaring the nodes of root as a flat
+// []*t_tree.Node linked by ParentID, with stubbed Setup and Assert funcs
+// carrying the node conditions as comments. Leaves are marked Todo
+// until their Assert is written.
+func Scaffold(root *decision_tree.Node, opts ScaffoldOptions) ([]byte, error) {
+	if root == nil {
+		return nil, fmt.Errorf("tree is nil")
+	}
+	opts = opts.withDefaults()
+	if opts.Package == "" {
+		return nil, fmt.Errorf("requires package")
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "package %s\n\n", opts.Package)
+	sb.WriteString("import (\n\t\"testing\"\n\n")
+	sb.WriteString("\t\"github.com/xhd2015/data-driven-testing/t_tree\"\n")
+	sb.WriteString("\t\"github.com/xhd2015/data-driven-testing/testing_ctx\"\n)\n\n")
+	if opts.DeclareTypes {
+		declared := make(map[string]bool, len(opts.DeclaredTypes)+3)
+		for name, ok := range opts.DeclaredTypes {
+			declared[name] = ok
+		}
+		for _, name := range []string{opts.Request, opts.Response, opts.Context} {
+			if declared[name] {
+				continue
+			}
+			declared[name] = true
+			fmt.Fprintf(&sb, "type %s struct{}\n\n", name)
+		}
+	}
+	fmt.Fprintf(&sb, "func Test%s(t *testing.T) {\n", opts.Var)
+	fmt.Fprintf(&sb, "\tt_tree.MustBuild(%s[0], %s[1:]).Run(testing_ctx.Std(t))\n}\n\n", opts.Var, opts.Var)
+	fmt.Fprintf(&sb, "// %s is scaffolded by go-ddt scaffold, re-running it only adds new nodes\n", opts.Var)
+	fmt.Fprintf(&sb, "var %s = []*t_tree.Node[%s, %s, %s]{\n", opts.Var, opts.Request, opts.Response, opts.Context)
+	for _, n := range flattenScaffold(root, nil) {
+		writeScaffoldNode(&sb, n, opts)
+	}
+	sb.WriteString("}\n")
+
+	return format.Source([]byte(sb.String()))
+}
+
+// ScaffoldMerge adds nodes of root missing in code to the slice
+// declared by opts.Var, nodes are matched by ID. IDs may be string
+// literals or constants declared in code or opts.Consts. Nodes
+// without ID are matched by the scaffold key written next to their
+// derived ID, so they are found after their label changes, as long
+// as they keep their position. Existing code is left as is, the type
+// arguments are taken from the declaration.
+// Returns the new code and IDs of added nodes.
+func ScaffoldMerge(code []byte, root *decision_tree.Node, opts ScaffoldOptions) ([]byte, []string, error) {
+	if root == nil {
+		return nil, nil, fmt.Errorf("tree is nil")
+	}
+	opts = opts.withDefaults()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", code, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	lit := findVarLit(f, opts.Var)
+	if lit == nil {
+		return nil, nil, fmt.Errorf("var %s not found", opts.Var)
+	}
+	if args := nodeTypeArgs(fset, lit.Type); len(args) == 3 {
+		opts.Request, opts.Response, opts.Context = args[0], args[1], args[2]
+	}
+
+	r := newResolver(fset)
+	for name, value := range opts.Consts {
+		r.consts[name] = value
+	}
+	r.collectConsts(f)
+
+	existing := make(map[string]bool)
+	keyIDs := make(map[string]string)
+	for _, elt := range lit.Elts {
+		nodeLit, ok := unref(elt).(*ast.CompositeLit)
+		if !ok {
+			continue
+		}
+		var id string
+		for _, field := range nodeLit.Elts {
+			kv, ok := field.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "ID" {
+				if id, ok = r.stringValue(kv.Value); !ok {
+					return nil, nil, fmt.Errorf("%s: cannot resolve ID", fset.Position(kv.Value.Pos()))
+				}
+			}
+		}
+		if id == "" {
+			continue
+		}
+		existing[id] = true
+		for _, group := range f.Comments {
+			if group.Pos() < nodeLit.Pos() || group.End() > nodeLit.End() {
+				continue
+			}
+			for _, comment := range group.List {
+				text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+				if key := strings.TrimPrefix(text, scaffoldKeyComment); key != text {
+					keyIDs[key] = id
+				}
+			}
+		}
+	}
+
+	var sb strings.Builder
+	var added []string
+	for _, n := range flattenScaffold(root, keyIDs) {
+		if existing[n.id] {
+			continue
+		}
+		writeScaffoldNode(&sb, n, opts)
+		added = append(added, n.id)
+	}
+	if len(added) == 0 {
+		return code, nil, nil
+	}
+
+	offset := fset.Position(lit.Rbrace).Offset
+	var buf bytes.Buffer
+	buf.Write(code[:offset])
+	if offset > 0 && code[offset-1] != '\n' {
+		buf.WriteString("\n")
+	}
+	buf.WriteString(sb.String())
+	buf.Write(code[offset:])
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	return formatted, added, nil
+}
+
+// flattenScaffold lists nodes in pre-order, nodes without ID
+// get the one recorded for their key in keyIDs, or else one
+// derived from their label
+func flattenScaffold(root *decision_tree.Node, keyIDs map[string]string) []*scaffoldNode {
+	var nodes []*scaffoldNode
+	used := make(map[string]bool)
+	var visit func(node *decision_tree.Node, parentID string, key string)
+	visit = func(node *decision_tree.Node, parentID string, key string) {
+		id := node.ID
+		if id != "" {
+			key = ""
+		} else if recorded, ok := keyIDs[key]; ok && !used[recorded] {
+			id = recorded
+		} else {
+			id = slugID(node.Label)
+			base := id
+			for i := 2; used[id]; i++ {
+				id = fmt.Sprintf("%s_%d", base, i)
+			}
+		}
+		used[id] = true
+		nodes = append(nodes, &scaffoldNode{node: node, id: id, parentID: parentID, key: key})
+		childKey := key
+		if node.ID != "" {
+			childKey = node.ID
+		}
+		for i, child := range node.Children {
+			visit(child, id, strings.TrimSuffix(childKey, "/")+"/"+strconv.Itoa(i))
+		}
+	}
+	// a root without ID is keyed "/", its children "/0", "/1"...
+	visit(root, "", "/")
+	return nodes
+}
+
+func writeScaffoldNode(sb *strings.Builder, n *scaffoldNode, opts ScaffoldOptions) {
+	node := n.node
+	isRoot := n.parentID == ""
+	isLeaf := len(node.Children) == 0
+
+	sb.WriteString("\t{\n")
+	if n.key != "" {
+		fmt.Fprintf(sb, "\t\tID: %s, // %s%s\n", strconv.Quote(n.id), scaffoldKeyComment, n.key)
+	} else {
+		fmt.Fprintf(sb, "\t\tID: %s,\n", strconv.Quote(n.id))
+	}
+	if !isRoot {
+		fmt.Fprintf(sb, "\t\tParentID: %s,\n", strconv.Quote(n.parentID))
+	}
+	if node.Label != "" && node.Label != n.id {
+		fmt.Fprintf(sb, "\t\tDescription: %s,\n", strconv.Quote(node.Label))
+	}
+	if node.EdgeLabel != "" {
+		fmt.Fprintf(sb, "\t\tCondition: %s,\n", strconv.Quote(node.EdgeLabel))
+	}
+	if tags := node.Tags(); len(tags) > 0 {
+		quoted := make([]string, len(tags))
+		for i, tag := range tags {
+			quoted[i] = strconv.Quote(tag)
+		}
+		fmt.Fprintf(sb, "\t\tTags: []string{%s},\n", strings.Join(quoted, ", "))
+	}
+	if isLeaf {
+		sb.WriteString("\t\tTodo: true, // remove once Assert is written\n")
+	}
+	if isRoot {
+		fmt.Fprintf(sb, "\t\tRun: func(t testing_ctx.T, tctx *%s, req *%s) (*%s, error) {\n", opts.Context, opts.Request, opts.Response)
+		sb.WriteString("\t\t\t// TODO: call the code under test\n")
+		sb.WriteString("\t\t\treturn nil, nil\n\t\t},\n")
+	}
+
+	comments := conditionComments(node.Conditions)
+	if isRoot || len(comments) > 0 {
+		fmt.Fprintf(sb, "\t\tSetup: func(t testing_ctx.T, tctx *%s, req *%s) (*%s, *%s) {\n", opts.Context, opts.Request, opts.Context, opts.Request)
+		for _, comment := range comments {
+			fmt.Fprintf(sb, "\t\t\t// %s\n", comment)
+		}
+		if isRoot {
+			fmt.Fprintf(sb, "\t\t\treturn tctx, &%s{}\n\t\t},\n", opts.Request)
+		} else {
+			sb.WriteString("\t\t\treturn tctx, req\n\t\t},\n")
+		}
+	}
+	if isLeaf {
+		fmt.Fprintf(sb, "\t\tAssert: func(t testing_ctx.T, tctx *%s, req *%s, resp *%s, err error) {\n", opts.Context, opts.Request, opts.Response)
+		sb.WriteString("\t\t\t// TODO: assert\n\t\t},\n")
+	}
+	sb.WriteString("\t},\n")
+}
+
+// conditionComments formats conditions other than tags as sorted "key: value"
+func conditionComments(conditions map[string]any) []string {
+	keys := make([]string, 0, len(conditions))
+	for k := range conditions {
+		if k != "tags" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var comments []string
+	for _, k := range keys {
+		value, ok := conditions[k].(string)
+		if !ok {
+			data, err := json.Marshal(conditions[k])
+			if err != nil {
+				value = fmt.Sprint(conditions[k])
+			} else {
+				value = string(data)
+			}
+		}
+		comments = append(comments, fmt.Sprintf("%s: %s", k, strings.ReplaceAll(value, "\n", " ")))
+	}
+	return comments
+}
+
+// slugID derives an ID like "valid_user" from a label
+func slugID(label string) string {
+	var sb strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(label) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			underscore = false
+		} else if !underscore && sb.Len() > 0 {
+			sb.WriteByte('_')
+			underscore = true
+		}
+	}
+	id := strings.TrimSuffix(sb.String(), "_")
+	if id == "" {
+		id = "node"
+	}
+	return id
+}
+
+// findVarLit finds the composite literal assigned to the package level var
+func findVarLit(f *ast.File, name string) *ast.CompositeLit {
+	for _, decl := range f.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valSpec := spec.(*ast.ValueSpec)
+			for i, ident := range valSpec.Names {
+				if ident.Name != name || i >= len(valSpec.Values) {
+					continue
+				}
+				lit, _ := unref(valSpec.Values[i]).(*ast.CompositeLit)
+				return lit
+			}
+		}
+	}
+	return nil
+}
+
+// nodeTypeArgs returns the type arguments of []*t_tree.Node[Q, R, TC]
+func nodeTypeArgs(fset *token.FileSet, expr ast.Expr) []string {
+	arr, ok := expr.(*ast.ArrayType)
+	if !ok {
+		return nil
+	}
+	elt := arr.Elt
+	if star, ok := elt.(*ast.StarExpr); ok {
+		elt = star.X
+	}
+	list, ok := elt.(*ast.IndexListExpr)
+	if !ok {
+		return nil
+	}
+	args := make([]string, 0, len(list.Indices))
+	for _, index := range list.Indices {
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, fset, index); err != nil {
+			return nil
+		}
+		args = append(args, buf.String())
+	}
+	return args
+}
@@ -0,0 +1,178 @@
+package t_tree_static
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+func TestScaffold(t *testing.T) {
+	root := &decision_tree.Node{
+		ID:    "root",
+		Label: "Root",
+		Children: []*decision_tree.Node{
+			{ID: "valid", Label: "Valid user", Conditions: map[string]any{"tags": []any{"happy_flow"}, "user_state": 1}},
+			{Label: "Invalid user", Children: []*decision_tree.Node{
+				{ID: "banned", Label: "Banned", EdgeLabel: "state=banned", Conditions: map[string]any{"reason": "spam"}},
+			}},
+		},
+	}
+	code, err := Scaffold(root, ScaffoldOptions{Package: "demo", DeclareTypes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(code)
+	for _, expect := range []string{
+		`ID:          "invalid_user",`,
+		`ParentID:    "invalid_user",`,
+		`Tags:        []string{"happy_flow"},`,
+		`// user_state: 1`,
+		`// reason: spam`,
+		`t_tree.MustBuild(Nodes[0], Nodes[1:]).Run(testing_ctx.Std(t))`,
+	} {
+		if !strings.Contains(src, expect) {
+			t.Errorf("expect code to contain %q:\n%s", expect, src)
+		}
+	}
+
+	// the scaffolded code reconstructs the same tree
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "tree_test.go", code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := BuildTree(fset, []*ast.File{f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := formatTree(tree); actual != strings.Join([]string{
+		"root Root",
+		"  valid Valid user tags=happy_flow styled",
+		"  invalid_user Invalid user",
+		"    banned Banned styled",
+	}, "\n") {
+		t.Errorf("unexpected tree:\n%s", actual)
+	}
+	if banned := tree.Children[1].Children[0]; banned.EdgeLabel != "state=banned" {
+		t.Errorf("expect edge label to be kept as Condition, got %q", banned.EdgeLabel)
+	}
+}
+
+func TestScaffoldDeclaredTypes(t *testing.T) {
+	root := &decision_tree.Node{ID: "root"}
+	code, err := Scaffold(root, ScaffoldOptions{
+		Package:       "demo",
+		Response:      "MyResp",
+		DeclareTypes:  true,
+		DeclaredTypes: map[string]bool{"Req": true, "Resp": true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(code)
+	for _, name := range []string{"MyResp", "TC"} {
+		if !strings.Contains(src, "type "+name+" struct{}") {
+			t.Errorf("expect missing type %s declared:\n%s", name, src)
+		}
+	}
+	if strings.Contains(src, "type Req ") {
+		t.Errorf("expect declared type Req not declared again:\n%s", src)
+	}
+}
+
+func TestScaffoldMerge(t *testing.T) {
+	root := &decision_tree.Node{ID: "root", Children: []*decision_tree.Node{{ID: "a"}}}
+	code, err := Scaffold(root, ScaffoldOptions{Package: "demo", Request: "MyReq"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// hand-written assert
+	handWritten := strings.Replace(string(code), "// TODO: assert", `if err != nil { t.Fatal(err) }`, 1)
+
+	root.Children = append(root.Children, &decision_tree.Node{ID: "b", Children: []*decision_tree.Node{{ID: "b1"}}})
+	merged, added, err := ScaffoldMerge([]byte(handWritten), root, ScaffoldOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(added, ",") != "b,b1" {
+		t.Errorf("expect b,b1 added, actual: %v", added)
+	}
+	src := string(merged)
+	if !strings.Contains(src, "t.Fatal(err)") {
+		t.Errorf("expect hand-written assert kept:\n%s", src)
+	}
+	if !strings.Contains(src, `ParentID: "b",`) || !strings.Contains(src, "req *MyReq") {
+		t.Errorf("expect b1 added with existing type arguments:\n%s", src)
+	}
+
+	again, added, err := ScaffoldMerge(merged, root, ScaffoldOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 0 || string(again) != src {
+		t.Errorf("expect re-running to add nothing, added: %v", added)
+	}
+
+	if _, _, err := ScaffoldMerge(merged, root, ScaffoldOptions{Var: "Missing"}); err == nil {
+		t.Errorf("expect error for missing var")
+	}
+}
+
+func TestScaffoldMergeDerivedID(t *testing.T) {
+	root := &decision_tree.Node{ID: "root", Children: []*decision_tree.Node{
+		{Label: "Invalid user", Children: []*decision_tree.Node{{ID: "banned"}}},
+	}}
+	code, err := Scaffold(root, ScaffoldOptions{Package: "demo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(code), `"invalid_user", // scaffold key: root/0`) {
+		t.Errorf("expect derived ID marked with its key:\n%s", code)
+	}
+
+	// relabelled, and a child without ID added under it
+	root.Children[0].Label = "Rejected user"
+	root.Children[0].Children = append(root.Children[0].Children, &decision_tree.Node{Label: "Deleted"})
+	merged, added, err := ScaffoldMerge(code, root, ScaffoldOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(added, ",") != "deleted" {
+		t.Errorf("expect only deleted added, actual: %v", added)
+	}
+	src := string(merged)
+	if strings.Contains(src, "rejected_user") {
+		t.Errorf("expect relabelled node not added again:\n%s", src)
+	}
+	if !strings.Contains(src, `"deleted", // scaffold key: root/0/1`) || strings.Count(src, `"invalid_user",`) != 3 {
+		t.Errorf("expect deleted added under the existing node:\n%s", src)
+	}
+}
+
+func TestScaffoldMergeConstID(t *testing.T) {
+	code := []byte(`package demo
+
+const idRoot = "root"
+
+var Nodes = []*t_tree.Node[Req, Resp, TC]{
+	{ID: idRoot},
+	{ID: idA, ParentID: idRoot},
+}
+`)
+	root := &decision_tree.Node{ID: "root", Children: []*decision_tree.Node{{ID: "a"}, {ID: "b"}}}
+	merged, added, err := ScaffoldMerge(code, root, ScaffoldOptions{Consts: map[string]string{"idA": "a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(added, ",") != "b" {
+		t.Errorf("expect only b added, actual: %v\n%s", added, merged)
+	}
+
+	if _, _, err := ScaffoldMerge(code, root, ScaffoldOptions{}); err == nil || !strings.Contains(err.Error(), "cannot resolve ID") {
+		t.Errorf("expect unresolved ID reported, actual: %v", err)
+	}
+}
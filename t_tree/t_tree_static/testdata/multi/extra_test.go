@@ -0,0 +1,9 @@
+package multi
+
+var extra = []*N{
+	{ID: "second_extra", ParentID: "second"},
+}
+
+var standaloneChildren = []*N{
+	{ID: "standalone_child", ParentID: "standalone"},
+}
@@ -0,0 +1,8 @@
+//go:build ddt_never
+
+package multi
+
+// excluded by its build constraint, otherwise it replaces extra
+var extra = []*N{
+	{ID: "from_tagged", ParentID: "second"},
+}
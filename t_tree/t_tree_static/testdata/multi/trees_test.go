@@ -0,0 +1,26 @@
+package multi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/t_tree"
+)
+
+type N = t_tree.Node[int, int, int]
+
+var first = t_tree.MustBuild(&N{ID: "first"}, nil)
+
+var second = t_tree.MustBuild(&N{ID: "second", Children: []*N{
+	{ID: "flaky", Skip: os.Getenv("SKIP_FLAKY")},
+}}, extra)
+
+var standalone = &N{ID: "standalone"}
+
+func TestThird(t *testing.T) {
+	third, err := t_tree.Build(&N{ID: "third"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	third.Run(t)
+}
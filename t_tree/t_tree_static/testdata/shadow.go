@@ -0,0 +1,22 @@
+package shadow
+
+import "testing"
+
+var detached = []*Node[int, int, int]{
+	{ID: "a2", ParentID: "a"},
+}
+
+func TestA(t *testing.T) {
+	root := &Node[int, int, int]{ID: "a", Children: []*Node[int, int, int]{
+		{ID: "a1"},
+	}}
+	MustBuild(root, detached).Run(t)
+}
+
+func TestB(t *testing.T) {
+	root := &Node[int, int, int]{ID: "b"}
+	detached := []*Node[int, int, int]{
+		{ID: "b1", ParentID: "b"},
+	}
+	MustBuild(root, detached).Run(t)
+}
@@ -0,0 +1,12 @@
+package single
+
+func init() {
+	root := &Node[int, int, int]{ID: "root", Children: []*Node[int, int, int]{
+		{ID: "a"},
+	}}
+	_ = root
+}
+
+var detached = []*Node[int, int, int]{
+	{ID: "b", ParentID: "a"},
+}
@@ -0,0 +1,29 @@
+package demo
+
+import (
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/t_tree"
+)
+
+type N = t_tree.Node[Req, Resp, TC]
+
+const idPrefix = "user_"
+
+var root = &N{
+	ID:          "root",
+	Description: "Root",
+	Children: []*N{
+		{ID: idPrefix + "valid", Description: "Valid user", Tags: []string{"happy_flow"}, Children: []*N{
+			{ID: "valid_paid", Description: "Paid"},
+		}},
+		invalid,
+	},
+}
+
+var invalid = &N{ID: "invalid", Description: "Invalid user", Todo: true}
+
+func TestTree(t *testing.T) {
+	tree := t_tree.MustBuild(root, nodes)
+	tree.Run(t)
+}
@@ -0,0 +1,7 @@
+package demo
+
+var nodes = []*N{
+	{ID: "valid_free", ParentID: "user_valid", Description: "Free", Skip: "flaky"},
+	{ID: "invalid_banned", ParentNode: invalid, Description: "Banned"},
+	{ID: "other", Description: "Attached to root"},
+}
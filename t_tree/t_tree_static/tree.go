@@ -0,0 +1,773 @@
+package t_tree_static
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/t_tree"
+)
+
+// node is the t_tree node reconstructed from source, only
+// static properties are filled, functions are left empty
+type node = t_tree.Node[struct{}, struct{}, struct{}]
+
+// LoadOptions selects the tree to load
+type LoadOptions struct {
+	// Var is the variable holding the tree, assigned a t_tree.Build or
+	// t_tree.MustBuild call or the root node. Package level variables
+	// are looked up first, then the locals of each function. Empty means
+	// the first Build call.
+	Var string
+}
+
+// LoadFile reconstructs the tree defined in file without executing it,
+// other files of the same package in the file's directory are parsed
+// as well, so references across files can be resolved
+func LoadFile(file string) (*decision_tree.Node, error) {
+	return LoadFileWith(file, LoadOptions{})
+}
+
+// LoadFileWith is LoadFile with options
+func LoadFileWith(file string, opts LoadOptions) (*decision_tree.Node, error) {
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	names, err := filepath.Glob(filepath.Join(filepath.Dir(absFile), "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string][]byte, len(names)+1)
+	for _, name := range append([]string{absFile}, names...) {
+		if _, ok := sources[name]; ok {
+			continue
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		sources[name] = data
+	}
+	return LoadSources(absFile, sources, opts)
+}
+
+// LoadSources is LoadFileWith on given contents, e.g. of a git revision.
+// sources maps the paths of the Go files in file's directory,
+// including file itself, to their content.
+// Other files are skipped if their build constraints do not match
+// go/build.Default, files requiring build tags are never loaded.
+func LoadSources(file string, sources map[string][]byte, opts LoadOptions) (*decision_tree.Node, error) {
+	data, ok := sources[file]
+	if !ok {
+		return nil, fmt.Errorf("%s: no source", file)
+	}
+	fset := token.NewFileSet()
+	// keep the file name so positions can be reported
+	primary, err := parser.ParseFile(fset, file, data, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse AST: %w", err)
+	}
+	files := []*ast.File{primary}
+
+	siblings := make([]string, 0, len(sources))
+	for name := range sources {
+		if name != file {
+			siblings = append(siblings, name)
+		}
+	}
+	sort.Strings(siblings)
+	for _, sibling := range siblings {
+		if !matchBuildContext(sibling, sources[sibling]) {
+			continue
+		}
+		astFile, err := parser.ParseFile(fset, sibling, sources[sibling], parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to parse AST: %w", sibling, err)
+		}
+		if astFile.Name.Name != primary.Name.Name {
+			continue
+		}
+		files = append(files, astFile)
+	}
+	return BuildTreeWith(fset, files, opts)
+}
+
+// matchBuildContext checks the file name and build constraints
+// of the file against go/build.Default
+func matchBuildContext(file string, data []byte) bool {
+	ctx := build.Default
+	ctx.OpenFile = func(path string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	match, err := ctx.MatchFile(filepath.Dir(file), filepath.Base(file))
+	return err == nil && match
+}
+
+// BuildTree reconstructs the tree from files, the first file is the
+// primary one. The tree is found by the first t_tree.Build or
+// t_tree.MustBuild call, preferring the primary file. Without such call,
+// the single top level node without parent is used as root.
+// Nested Children, ParentID and ParentNode are resolved, node
+// properties ID, Description, Tags, Skip, Todo and Focus are kept.
+// Nodes with ID carry their source location.
+// Skip values other than string literals and constants are kept
+// as their source, e.g. "skip: os.Getenv(\"CI\") != \"\"".
+func BuildTree(fset *token.FileSet, files []*ast.File) (*decision_tree.Node, error) {
+	return BuildTreeWith(fset, files, LoadOptions{})
+}
+
+// BuildTreeWith is BuildTree with options, opts.Var selects the tree
+func BuildTreeWith(fset *token.FileSet, files []*ast.File, opts LoadOptions) (*decision_tree.Node, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files")
+	}
+	r := newResolver(fset)
+	for _, f := range files {
+		r.collectTypes(f)
+		r.collectConsts(f)
+	}
+	for _, f := range files {
+		r.collectVars(f)
+	}
+
+	var root *node
+	var nodes []*node
+	var call *ast.CallExpr
+	var fn *ast.FuncDecl
+	var rootExpr ast.Expr
+	if opts.Var != "" {
+		value, valueFn, ok := findVar(files, opts.Var)
+		if !ok {
+			return nil, fmt.Errorf("var %s not found", opts.Var)
+		}
+		if call = findBuildCallIn(value); call != nil {
+			fn = valueFn
+		} else {
+			rootExpr = value
+			r.locals = r.funcVars[valueFn]
+		}
+	} else {
+		call, fn = findBuildCall(files)
+	}
+	switch {
+	case call != nil:
+		// names resolve to the locals of the function calling Build
+		r.locals = r.funcVars[fn]
+		roots := r.resolveNodes(call.Args[0])
+		if len(roots) != 1 {
+			return nil, fmt.Errorf("cannot resolve root of tree")
+		}
+		root = roots[0]
+		nodes = r.resolveNodes(call.Args[1])
+	case rootExpr != nil:
+		roots := r.resolveNodes(rootExpr)
+		if len(roots) != 1 {
+			return nil, fmt.Errorf("var %s is neither a t_tree.Build call nor a node", opts.Var)
+		}
+		root = roots[0]
+		_, detached := r.splitTopNodes()
+		nodes = attachedNodes(root, detached)
+	default:
+		var err error
+		root, nodes, err = r.guessRoot()
+		if err != nil {
+			return nil, err
+		}
+	}
+	tree, err := t_tree.Build(root, nodes)
+	if err != nil {
+		return nil, err
+	}
+	dt := tree.ToDecisionTree()
+	r.setSources(dt)
+	return dt, nil
+}
+
+// setSources sets source locations by ID, because
+// t_tree.Build copies nodes
+func (r *resolver) setSources(root *decision_tree.Node) {
+	sources := make(map[string]*decision_tree.Source, len(r.nodes))
+	for lit, nd := range r.nodes {
+		if nd.ID == "" {
+			continue
+		}
+		pos := r.fset.Position(lit.Pos())
+		sources[nd.ID] = &decision_tree.Source{File: pos.Filename, Line: pos.Line}
+	}
+	var visit func(node *decision_tree.Node)
+	visit = func(node *decision_tree.Node) {
+		if source, ok := sources[node.ID]; ok && source.File != "" {
+			node.Source = source
+		}
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	visit(root)
+}
+
+// findBuildCall finds the first call of Build or MustBuild with two args,
+// and the function it is in, nil if it is at package level
+func findBuildCall(files []*ast.File) (*ast.CallExpr, *ast.FuncDecl) {
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			if call := findBuildCallIn(decl); call != nil {
+				fn, _ := decl.(*ast.FuncDecl)
+				return call, fn
+			}
+		}
+	}
+	return nil, nil
+}
+
+// findVar finds the value assigned to the variable name, package
+// level variables first, then locals of functions, which are returned
+// with the function
+func findVar(files []*ast.File, name string) (ast.Expr, *ast.FuncDecl, bool) {
+	find := func(decl ast.Decl) ast.Expr {
+		var value ast.Expr
+		ast.Inspect(decl, func(n ast.Node) bool {
+			if value != nil {
+				return false
+			}
+			switch n := n.(type) {
+			case *ast.ValueSpec:
+				for i, ident := range n.Names {
+					if ident.Name == name && i < len(n.Values) {
+						value = n.Values[i]
+					}
+				}
+			case *ast.AssignStmt:
+				for i, lhs := range n.Lhs {
+					ident, ok := lhs.(*ast.Ident)
+					if !ok || ident.Name != name {
+						continue
+					}
+					if len(n.Lhs) == len(n.Rhs) {
+						value = n.Rhs[i]
+					} else if len(n.Rhs) == 1 && i == 0 {
+						// tree, err := t_tree.Build(...)
+						value = n.Rhs[0]
+					}
+				}
+			}
+			return true
+		})
+		return value
+	}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.VAR {
+				if value := find(decl); value != nil {
+					return value, nil, true
+				}
+			}
+		}
+	}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok {
+				if value := find(decl); value != nil {
+					return value, fn, true
+				}
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+func findBuildCallIn(root ast.Node) *ast.CallExpr {
+	var found *ast.CallExpr
+	ast.Inspect(root, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		fn := call.Fun
+		switch x := fn.(type) {
+		case *ast.IndexExpr:
+			fn = x.X
+		case *ast.IndexListExpr:
+			fn = x.X
+		}
+		var name string
+		switch x := fn.(type) {
+		case *ast.Ident:
+			name = x.Name
+		case *ast.SelectorExpr:
+			name = x.Sel.Name
+		}
+		if name == "Build" || name == "MustBuild" {
+			found = call
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+type resolver struct {
+	fset *token.FileSet
+
+	// names of types that are t_tree.Node, including aliases
+	nodeTypes map[string]bool
+	consts    map[string]string
+
+	// vars holds expressions assigned to package level vars,
+	// funcVars those assigned to local variables of each function
+	vars      *varScope
+	funcVars  map[*ast.FuncDecl]*varScope
+	funcOrder []*ast.FuncDecl
+	// locals are the local variables of the function being analysed,
+	// they shadow package level vars. Nil at package level.
+	locals *varScope
+
+	nodes map[*ast.CompositeLit]*node
+	// children records nodes referenced as child of other nodes
+	children map[*node]bool
+}
+
+func newResolver(fset *token.FileSet) *resolver {
+	return &resolver{
+		fset:      fset,
+		nodeTypes: map[string]bool{"Node": true},
+		consts:    make(map[string]string),
+		vars:      newVarScope(),
+		funcVars:  make(map[*ast.FuncDecl]*varScope),
+		nodes:     make(map[*ast.CompositeLit]*node),
+		children:  make(map[*node]bool),
+	}
+}
+
+// collectTypes collects aliases like `type N = t_tree.Node[Q, R, TC]`
+func (r *resolver) collectTypes(f *ast.File) {
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if ok && r.isNodeType(spec.Type) {
+			r.nodeTypes[spec.Name.Name] = true
+		}
+		return true
+	})
+}
+
+func (r *resolver) collectConsts(f *ast.File) {
+	for _, decl := range f.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valSpec := spec.(*ast.ValueSpec)
+			for i, name := range valSpec.Names {
+				if i >= len(valSpec.Values) {
+					break
+				}
+				if s, ok := stringLit(valSpec.Values[i]); ok {
+					r.consts[name.Name] = s
+				}
+			}
+		}
+	}
+}
+
+// varScope holds the expressions assigned to names in a scope,
+// names are kept in order of their first assignment
+type varScope struct {
+	values map[string]ast.Expr
+	order  []string
+}
+
+func newVarScope() *varScope {
+	return &varScope{values: make(map[string]ast.Expr)}
+}
+
+func (c *varScope) add(name string, value ast.Expr) {
+	if _, ok := c.values[name]; !ok {
+		c.order = append(c.order, name)
+	}
+	c.values[name] = value
+}
+
+// collectVars collects `var x = ...` and `x := ...` whose value is a node
+// or nodes, package level vars apart from the locals of each function, so
+// functions reusing a name like `tree` do not mix up their trees
+func (r *resolver) collectVars(f *ast.File) {
+	for _, decl := range f.Decls {
+		scope := r.vars
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			scope = newVarScope()
+			r.funcVars[fn] = scope
+			r.funcOrder = append(r.funcOrder, fn)
+		}
+		add := func(name string, value ast.Expr) {
+			if name == "_" || !r.isNodeExpr(value) {
+				return
+			}
+			scope.add(name, value)
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ValueSpec:
+				if len(n.Names) == len(n.Values) {
+					for i, name := range n.Names {
+						add(name.Name, n.Values[i])
+					}
+				}
+			case *ast.AssignStmt:
+				if len(n.Lhs) == len(n.Rhs) {
+					for i, lhs := range n.Lhs {
+						if ident, ok := lhs.(*ast.Ident); ok {
+							add(ident.Name, n.Rhs[i])
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// lookupVar returns the expression assigned to name, locals first,
+// and the scope the expression is in
+func (r *resolver) lookupVar(name string) (ast.Expr, *varScope, bool) {
+	if r.locals != nil {
+		if value, ok := r.locals.values[name]; ok {
+			return value, r.locals, true
+		}
+	}
+	value, ok := r.vars.values[name]
+	return value, nil, ok
+}
+
+// isNodeType checks whether expr is Node[...], t_tree.Node[...] or an alias of it
+func (r *resolver) isNodeType(expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch x := expr.(type) {
+	case *ast.IndexListExpr:
+		expr = x.X
+	case *ast.IndexExpr:
+		expr = x.X
+	}
+	switch x := expr.(type) {
+	case *ast.Ident:
+		return r.nodeTypes[x.Name]
+	case *ast.SelectorExpr:
+		return x.Sel.Name == "Node"
+	}
+	return false
+}
+
+func (r *resolver) isNodeSliceType(expr ast.Expr) bool {
+	arr, ok := expr.(*ast.ArrayType)
+	return ok && r.isNodeType(arr.Elt)
+}
+
+func (r *resolver) isNodeExpr(expr ast.Expr) bool {
+	lit, ok := unref(expr).(*ast.CompositeLit)
+	if !ok {
+		return false
+	}
+	return r.isNodeType(lit.Type) || r.isNodeSliceType(lit.Type)
+}
+
+// resolveNodes resolves expr as node or node slice
+func (r *resolver) resolveNodes(expr ast.Expr) []*node {
+	return r.resolveNodesWithType(expr, false, nil)
+}
+
+func (r *resolver) resolveNodesWithType(expr ast.Expr, elemIsNode bool, visiting map[string]bool) []*node {
+	switch x := unref(expr).(type) {
+	case *ast.Ident:
+		if x.Name == "nil" {
+			return nil
+		}
+		value, scope, ok := r.lookupVar(x.Name)
+		if !ok || visiting[x.Name] {
+			return nil
+		}
+		if visiting == nil {
+			visiting = make(map[string]bool)
+		}
+		visiting[x.Name] = true
+		defer delete(visiting, x.Name)
+		// names in package level vars do not see locals
+		locals := r.locals
+		r.locals = scope
+		defer func() { r.locals = locals }()
+		return r.resolveNodesWithType(value, false, visiting)
+	case *ast.IndexExpr:
+		// nodes[0]
+		nodes := r.resolveNodesWithType(x.X, false, visiting)
+		i, ok := intLit(x.Index)
+		if !ok || i >= len(nodes) {
+			return nil
+		}
+		return nodes[i : i+1]
+	case *ast.SliceExpr:
+		// nodes[1:]
+		nodes := r.resolveNodesWithType(x.X, false, visiting)
+		low, high := 0, len(nodes)
+		var ok bool
+		if x.Low != nil {
+			if low, ok = intLit(x.Low); !ok {
+				return nil
+			}
+		}
+		if x.High != nil {
+			if high, ok = intLit(x.High); !ok {
+				return nil
+			}
+		}
+		if low > high || high > len(nodes) {
+			return nil
+		}
+		return nodes[low:high]
+	case *ast.CompositeLit:
+		if r.isNodeSliceType(x.Type) {
+			var nodes []*node
+			for _, elt := range x.Elts {
+				nodes = append(nodes, r.resolveNodesWithType(elt, true, visiting)...)
+			}
+			return nodes
+		}
+		if x.Type == nil && !elemIsNode {
+			return nil
+		}
+		if x.Type != nil && !r.isNodeType(x.Type) {
+			return nil
+		}
+		return []*node{r.parseNode(x, visiting)}
+	}
+	return nil
+}
+
+// parseNode converts a node literal, the same literal
+// always yields the same node so ParentNode can be matched
+func (r *resolver) parseNode(lit *ast.CompositeLit, visiting map[string]bool) *node {
+	if nd, ok := r.nodes[lit]; ok {
+		return nd
+	}
+	nd := &node{}
+	r.nodes[lit] = nd
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		switch key.Name {
+		case "ID":
+			nd.ID, _ = r.stringValue(kv.Value)
+		case "ParentID":
+			nd.ParentID, _ = r.stringValue(kv.Value)
+		case "Description":
+			nd.Description, _ = r.stringValue(kv.Value)
+		case "Condition":
+			nd.Condition, _ = r.stringValue(kv.Value)
+		case "Skip":
+			if s, ok := r.stringValue(kv.Value); ok {
+				nd.Skip = s
+			} else {
+				// only known at runtime, show the expression
+				nd.Skip = "skip: " + r.exprString(kv.Value)
+			}
+		case "Todo":
+			nd.Todo = isTrue(kv.Value)
+		case "Focus":
+			nd.Focus = isTrue(kv.Value)
+		case "Tags":
+			if tagsLit, ok := kv.Value.(*ast.CompositeLit); ok {
+				for _, tag := range tagsLit.Elts {
+					if s, ok := r.stringValue(tag); ok {
+						nd.Tags = append(nd.Tags, s)
+					}
+				}
+			}
+		case "ParentNode":
+			if parents := r.resolveNodesWithType(kv.Value, true, visiting); len(parents) == 1 {
+				nd.ParentNode = parents[0]
+			}
+		case "Children":
+			for _, child := range r.resolveNodesWithType(kv.Value, true, visiting) {
+				r.children[child] = true
+				nd.Children = append(nd.Children, child)
+			}
+		}
+	}
+	return nd
+}
+
+// guessRoot finds the root among top level nodes, when
+// there is no Build call, e.g. the tree is built in a helper
+func (r *resolver) guessRoot() (*node, []*node, error) {
+	roots, nodes := r.splitTopNodes()
+	if len(roots) != 1 {
+		var ids []string
+		for _, root := range roots {
+			ids = append(ids, root.ID)
+		}
+		return nil, nil, fmt.Errorf("no t_tree.Build call found and cannot determine root from %d candidates: %s", len(roots), strings.Join(ids, ","))
+	}
+	return roots[0], nodes, nil
+}
+
+// attachedNodes returns the nodes of detached that are attached to
+// the tree of root, directly or through other detached nodes
+func attachedNodes(root *node, detached []*node) []*node {
+	inTree := make(map[*node]bool)
+	ids := make(map[string]bool)
+	var add func(nd *node)
+	add = func(nd *node) {
+		inTree[nd] = true
+		if nd.ID != "" {
+			ids[nd.ID] = true
+		}
+		for _, child := range nd.Children {
+			add(child)
+		}
+	}
+	add(root)
+	var nodes []*node
+	for changed := true; changed; {
+		changed = false
+		for _, nd := range detached {
+			if inTree[nd] {
+				continue
+			}
+			if (nd.ParentID != "" && ids[nd.ParentID]) || (nd.ParentNode != nil && inTree[nd.ParentNode]) {
+				add(nd)
+				nodes = append(nodes, nd)
+				changed = true
+			}
+		}
+	}
+	return nodes
+}
+
+// splitTopNodes splits nodes assigned to variables, and not child of
+// other nodes, into the ones without parent and the ones attached by
+// ParentID or ParentNode
+func (r *resolver) splitTopNodes() ([]*node, []*node) {
+	var topNodes []*node
+	seen := make(map[*node]bool)
+	locals := r.locals
+	collect := func(scope *varScope) {
+		for _, name := range scope.order {
+			for _, nd := range r.resolveNodes(scope.values[name]) {
+				if !seen[nd] {
+					seen[nd] = true
+					topNodes = append(topNodes, nd)
+				}
+			}
+		}
+	}
+	r.locals = nil
+	collect(r.vars)
+	// each function's locals are resolved in its own scope
+	for _, fn := range r.funcOrder {
+		r.locals = r.funcVars[fn]
+		collect(r.locals)
+	}
+	r.locals = locals
+	var roots []*node
+	var nodes []*node
+	for _, nd := range topNodes {
+		if r.children[nd] {
+			continue
+		}
+		if nd.ParentID == "" && nd.ParentNode == nil {
+			roots = append(roots, nd)
+			continue
+		}
+		nodes = append(nodes, nd)
+	}
+	return roots, nodes
+}
+
+func (r *resolver) stringValue(expr ast.Expr) (string, bool) {
+	if s, ok := stringLit(expr); ok {
+		return s, true
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		s, ok := r.consts[ident.Name]
+		return s, ok
+	}
+	if bin, ok := expr.(*ast.BinaryExpr); ok && bin.Op == token.ADD {
+		x, ok := r.stringValue(bin.X)
+		if !ok {
+			return "", false
+		}
+		y, ok := r.stringValue(bin.Y)
+		if !ok {
+			return "", false
+		}
+		return x + y, true
+	}
+	return "", false
+}
+
+// exprString formats expr as in source
+func (r *resolver) exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, r.fset, expr); err != nil {
+		return "?"
+	}
+	return buf.String()
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+func intLit(expr ast.Expr) (int, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return 0, false
+	}
+	i, err := strconv.Atoi(lit.Value)
+	if err != nil || i < 0 {
+		return 0, false
+	}
+	return i, true
+}
+
+func isTrue(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "true"
+}
+
+// unref strips the leading &
+func unref(expr ast.Expr) ast.Expr {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		return unary.X
+	}
+	return expr
+}
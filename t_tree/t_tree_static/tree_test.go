@@ -0,0 +1,127 @@
+package t_tree_static
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+)
+
+func formatTree(node *decision_tree.Node) string {
+	var lines []string
+	var visit func(node *decision_tree.Node, depth int)
+	visit = func(node *decision_tree.Node, depth int) {
+		line := strings.Repeat("  ", depth) + node.ID + " " + node.Label
+		if tags, ok := node.Conditions["tags"]; ok {
+			line += " tags=" + strings.Join(tags.([]string), ",")
+		}
+		if node.Style != nil {
+			line += " styled"
+		}
+		lines = append(lines, line)
+		for _, child := range node.Children {
+			visit(child, depth+1)
+		}
+	}
+	visit(node, 0)
+	return strings.Join(lines, "\n")
+}
+
+func TestLoadFile(t *testing.T) {
+	tree, err := LoadFile("testdata/split/main_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := strings.Join([]string{
+		"root Root",
+		"  user_valid Valid user tags=happy_flow",
+		"    valid_paid Paid",
+		"    valid_free Free styled",
+		"  invalid Invalid user styled",
+		"    invalid_banned Banned",
+		"  other Attached to root",
+	}, "\n")
+	if actual := formatTree(tree); actual != expect {
+		t.Errorf("expect:\n%s\nactual:\n%s", expect, actual)
+	}
+
+	free := tree.Children[0].Children[1]
+	if free.Source == nil || !strings.HasSuffix(free.Source.File, "nodes_test.go") || free.Source.Line != 4 {
+		t.Errorf("expect valid_free at nodes_test.go:4, actual: %+v", free.Source)
+	}
+}
+
+func TestLoadFileWithoutBuild(t *testing.T) {
+	tree, err := LoadFile("testdata/single.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := strings.Join([]string{
+		"root root",
+		"  a a",
+		"    b b",
+	}, "\n")
+	if actual := formatTree(tree); actual != expect {
+		t.Errorf("expect:\n%s\nactual:\n%s", expect, actual)
+	}
+}
+
+func TestLoadFileLocalsPerFunction(t *testing.T) {
+	tree, err := LoadFile("testdata/shadow.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first Build call is in TestA, TestB's root and
+	// detached must not be taken for TestA's
+	expect := strings.Join([]string{
+		"a a",
+		"  a1 a1",
+		"  a2 a2",
+	}, "\n")
+	if actual := formatTree(tree); actual != expect {
+		t.Errorf("expect:\n%s\nactual:\n%s", expect, actual)
+	}
+}
+
+func TestLoadFileWithVar(t *testing.T) {
+	file := "testdata/multi/trees_test.go"
+	cases := []struct {
+		varName string
+		expect  []string
+	}{
+		{"", []string{"first first"}},
+		{"second", []string{
+			"second second",
+			"  flaky flaky styled",
+			"  second_extra second_extra",
+		}},
+		{"third", []string{"third third"}},
+		{"standalone", []string{
+			"standalone standalone",
+			"  standalone_child standalone_child",
+		}},
+	}
+	for _, c := range cases {
+		tree, err := LoadFileWith(file, LoadOptions{Var: c.varName})
+		if err != nil {
+			t.Errorf("var %q: %v", c.varName, err)
+			continue
+		}
+		if actual := formatTree(tree); actual != strings.Join(c.expect, "\n") {
+			t.Errorf("var %q, expect:\n%s\nactual:\n%s", c.varName, strings.Join(c.expect, "\n"), actual)
+		}
+	}
+
+	tree, err := LoadFileWith(file, LoadOptions{Var: "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Skip only known at runtime keeps its expression
+	if flaky := tree.Find("flaky"); flaky.Conditions["skip"] != `skip: os.Getenv("SKIP_FLAKY")` {
+		t.Errorf("expect skip expression, actual: %v", flaky.Conditions)
+	}
+
+	if _, err := LoadFileWith(file, LoadOptions{Var: "missing"}); err == nil {
+		t.Errorf("expect error for missing var")
+	}
+}
@@ -2,6 +2,7 @@ package t_tree
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/xhd2015/data-driven-testing/testing_ctx"
 )
@@ -16,6 +17,12 @@ type Tree[Q any, R any, TC any] struct {
 	buildingNodeToInternalNode map[*Node[Q, R, TC]]*Node[Q, R, TC]
 	childToParent              map[*Node[Q, R, TC]]*Node[Q, R, TC]
 	idToNode                   map[string]*Node[Q, R, TC]
+
+	middlewares []Middleware[Q, R, TC]
+
+	// focus
+	focusedIDs   []string
+	focusSubtree map[*Node[Q, R, TC]]bool // nodes that are focused or have focused descendants
 }
 
 func MustBuild[Q any, R any, TC any](root *Node[Q, R, TC], nodes []*Node[Q, R, TC]) *Tree[Q, R, TC] {
@@ -115,41 +122,123 @@ func Build[Q any, R any, TC any](root *Node[Q, R, TC], nodes []*Node[Q, R, TC])
 func (c *Tree[Q, R, TC]) init() {
 	c.childToParent = make(map[*Node[Q, R, TC]]*Node[Q, R, TC])
 	c.idToNode = make(map[string]*Node[Q, R, TC])
-	var buildChildToParent func(node *Node[Q, R, TC])
-	buildChildToParent = func(node *Node[Q, R, TC]) {
+	c.focusedIDs = nil
+	c.focusSubtree = make(map[*Node[Q, R, TC]]bool)
+	var buildChildToParent func(node *Node[Q, R, TC]) bool
+	buildChildToParent = func(node *Node[Q, R, TC]) bool {
 		if node.ID != "" {
 			c.idToNode[node.ID] = node
 		}
+		hasFocus := node.Focus
+		if node.Focus {
+			c.focusedIDs = append(c.focusedIDs, node.ID)
+		}
 		for _, child := range node.Children {
 			c.childToParent[child] = node
-			buildChildToParent(child)
+			if buildChildToParent(child) {
+				hasFocus = true
+			}
+		}
+		if hasFocus {
+			c.focusSubtree[node] = true
 		}
+		return hasFocus
 	}
 	buildChildToParent(c.Root)
 }
 
+// Run runs all nodes in the tree.
+// If any node is focused, only focused subtrees run.
+// If EnvReplay is set, only the recorded path runs.
 func (c *Tree[Q, R, TC]) Run(t testing_ctx.T) {
-	c.run(t, NodePath[Q, R, TC]{c.Root})
+	replay, ok := loadReplay(t)
+	if !ok {
+		return
+	}
+	if replay == nil && !c.checkFocus(t) {
+		return
+	}
+	c.run(t, NodePath[Q, R, TC]{c.Root}, false, replay)
 }
 
+// RunNode runs the path from root to the given node.
+// If the tree has focused nodes, the path is skipped unless it is focused.
 func (c *Tree[Q, R, TC]) RunNode(t testing_ctx.T, node *Node[Q, R, TC]) {
+	replay, ok := loadReplay(t)
+	if !ok {
+		return
+	}
 	nodePath := c.GetNodePath(node)
-	nodePath.Run(t)
+	if replay != nil {
+		if _, exact := nodePath.matchRecord(replay); !exact {
+			t.Skip("not the replayed path")
+			return
+		}
+		c.runPath(t, nodePath, replay)
+		return
+	}
+	if !c.checkFocus(t) {
+		return
+	}
+	if c.HasFocus() && !nodePath.IsFocused() {
+		t.Skip("not focused")
+		return
+	}
+	c.runPath(t, nodePath, nil)
 }
 
-func (c *Tree[Q, R, TC]) run(t testing_ctx.T, nodePath NodePath[Q, R, TC]) {
+func (c *Tree[Q, R, TC]) run(t testing_ctx.T, nodePath NodePath[Q, R, TC], inFocus bool, replay *Record) {
 	node := nodePath[len(nodePath)-1]
+	if replay != nil {
+		prefix, exact := nodePath.matchRecord(replay)
+		if !prefix {
+			return
+		}
+		t.Run(node.ID, func(t testing_ctx.T) {
+			if exact {
+				c.runPath(t, nodePath, replay)
+				return
+			}
+			for _, child := range node.Children {
+				c.run(t, append(nodePath, child), inFocus, replay)
+			}
+		})
+		return
+	}
+	if node.Focus {
+		inFocus = true
+	}
+	if c.HasFocus() && !inFocus && !c.focusSubtree[node] {
+		return
+	}
 	id := node.ID
 	t.Run(id, func(t testing_ctx.T) {
-		if node.Assert != nil {
-			nodePath.Run(t)
+		if node.Skip != "" {
+			t.Skip(node.Skip)
+			return
+		}
+		if (node.Assert != nil || node.Expect != nil || node.Todo) && (inFocus || !c.HasFocus()) {
+			c.runPath(t, nodePath, nil)
 		}
 		for _, child := range node.Children {
-			c.run(t, append(nodePath, child))
+			c.run(t, append(nodePath, child), inFocus, nil)
 		}
 	})
 }
 
+// runPath runs the path with tree middlewares,
+// plus recorder or replayer if enabled
+func (c *Tree[Q, R, TC]) runPath(t testing_ctx.T, nodePath NodePath[Q, R, TC], replay *Record) {
+	middlewares := c.middlewares
+	if replay != nil {
+		// replayer is the innermost so that user middlewares still apply
+		middlewares = append(middlewares[:len(middlewares):len(middlewares)], newReplayer[Q, R, TC](replay))
+	} else if dir := os.Getenv(EnvRecordDir); dir != "" {
+		middlewares = append(middlewares[:len(middlewares):len(middlewares)], NewRecorder[Q, R, TC](dir))
+	}
+	nodePath.RunWith(t, middlewares...)
+}
+
 func (c *Tree[Q, R, TC]) FindNode(id string) *Node[Q, R, TC] {
 	return c.idToNode[id]
 }
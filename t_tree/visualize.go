@@ -3,110 +3,61 @@ package t_tree
 import (
 	"fmt"
 	"html"
-	"strings"
+
+	"github.com/xhd2015/data-driven-testing/decision_tree"
+	"github.com/xhd2015/data-driven-testing/decision_tree/export"
 )
 
 // ToMermaid generates a Mermaid flowchart diagram representation of the tree.
-// The diagram uses TD (top-down) layout with nodes and connections.
+// The diagram uses TD (top-down) layout with nodes and connections, written
+// by export.ToMermaidWith. Skipped, todo and focused nodes are marked with
+// the skip, todo and focus classes.
 // Returns a string in Mermaid syntax that can be rendered in Markdown.
+//
+// Since it is written by the export package, quotes in labels are
+// escaped as #quot; instead of \", ids keep only letters, digits and _,
+// and nodes without id are named node_1, node_2... in pre-order
+// instead of by their address.
 func (c *Tree[Q, R, TC]) ToMermaid() string {
-	var sb strings.Builder
-	sb.WriteString("graph TD;\n")
-
-	// Handle empty tree case
 	if c.Root == nil {
-		return sb.String()
+		return export.ToMermaid(nil)
 	}
-
-	// Process nodes recursively
-	nodeIDs := make(map[*Node[Q, R, TC]]string)
-	processNode(&sb, c.Root, "", nodeIDs)
-
-	return sb.String()
+	return export.ToMermaidWith(convertNode(c.Root, mermaidLabel[Q, R, TC]), mermaidOptions)
 }
 
-// processNode recursively processes a node and its children to build the Mermaid diagram
-func processNode[Q, R, TC any](sb *strings.Builder, node *Node[Q, R, TC], parentID string, nodeIDs map[*Node[Q, R, TC]]string) string {
-	// Generate or retrieve node ID
-	nodeID := getNodeID(node, nodeIDs)
-
-	// Create node label (use description if available, otherwise ID or "Node")
-	nodeLabel := getNodeLabel(node)
-
-	// Add node to diagram with appropriate styling
+var mermaidOptions = export.MermaidOptions{
+	// markers are shown by their classes
+	HideConditions: true,
 	// Use different node shapes based on node characteristics:
 	// - Root nodes (no parent): rounded rectangle
-	// - Leaf nodes (no children): stadium shape
-	// - Other nodes: default rectangle
-	if parentID == "" {
-		// Root node - rounded rectangle
-		fmt.Fprintf(sb, "    %s(\"%s\");\n", nodeID, escapeLabel(nodeLabel))
-	} else if len(node.Children) == 0 {
-		// Leaf node - stadium shape
-		fmt.Fprintf(sb, "    %s[\"%s\"];\n", nodeID, escapeLabel(nodeLabel))
-	} else {
-		// Internal node - rectangle with rounded edges
-		fmt.Fprintf(sb, "    %s{\"%s\"};\n", nodeID, escapeLabel(nodeLabel))
-	}
-
-	// Add connection to parent if exists
-	if parentID != "" {
-		fmt.Fprintf(sb, "    %s --> %s;\n", parentID, nodeID)
-	}
-
-	// Process children recursively
-	for _, child := range node.Children {
-		processNode(sb, child, nodeID, nodeIDs)
-	}
-
-	return nodeID
+	// - Leaf nodes (no children): rectangle
+	// - Other nodes: rhombus
+	Shape: func(node *decision_tree.Node, depth int) (string, string) {
+		switch {
+		case depth == 0:
+			return "(", ")"
+		case len(node.Children) == 0:
+			return "[", "]"
+		default:
+			return "{", "}"
+		}
+	},
+	ClassDefs: map[string]string{
+		skipStyle.Class:  "fill:#eeeeee,stroke:#999999,stroke-dasharray:5 5,color:#999999",
+		todoStyle.Class:  "fill:#fff8dc,stroke:#d4a017",
+		focusStyle.Class: "fill:#e6f4ff,stroke:#1e90ff,stroke-width:3px",
+	},
 }
 
-// getNodeID returns a unique ID for the node, using the node's ID if available
-// or generating a unique one based on memory address
-func getNodeID[Q, R, TC any](node *Node[Q, R, TC], nodeIDs map[*Node[Q, R, TC]]string) string {
-	// Check if we already assigned an ID to this node
-	if id, exists := nodeIDs[node]; exists {
-		return id
-	}
-
-	// Use node's ID if available
-	if node.ID != "" {
-		// Replace spaces and special characters for Mermaid compatibility
-		id := strings.ReplaceAll(node.ID, " ", "_")
-		id = strings.ReplaceAll(id, "-", "_")
-		nodeIDs[node] = id
-		return id
-	}
-
-	// Generate a unique ID based on memory address
-	id := fmt.Sprintf("node_%p", node)
-	nodeIDs[node] = id
-	return id
-}
-
-// getNodeLabel returns a formatted label for the node, including both description and ID
-func getNodeLabel[Q, R, TC any](node *Node[Q, R, TC]) string {
-	var label string
-
+// mermaidLabel returns a formatted label for the node, including both description and ID
+func mermaidLabel[Q, R, TC any](node *Node[Q, R, TC]) string {
 	if node.Description == "" && node.ID == "" {
-		label = "Node"
+		return "Node"
 	} else if node.Description == "" {
-		label = node.ID
+		return node.ID
 	} else if node.ID == "" {
-		label = node.Description
-	} else {
-		// html escape description
-		label = fmt.Sprintf("%s<br><i>%s</i>", node.ID, html.EscapeString(node.Description))
+		return node.Description
 	}
-
-	return label
-}
-
-// escapeLabel escapes special characters in Mermaid labels
-func escapeLabel(label string) string {
-	// Escape quotes and other special characters
-	label = strings.ReplaceAll(label, "\"", "\\\"")
-	// Don't escape HTML tags as Mermaid supports them for formatting
-	return label
+	// html escape description
+	return fmt.Sprintf("%s<br><i>%s</i>", node.ID, html.EscapeString(node.Description))
 }
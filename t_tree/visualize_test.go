@@ -101,3 +101,85 @@ func TestToMermaid(t *testing.T) {
 		t.Errorf("Empty tree should generate 'graph TD;\\n', got: %s", emptyMermaid)
 	}
 }
+
+func TestToMermaidMarkers(t *testing.T) {
+	tree := &Tree[string, string, string]{
+		Root: &Node[string, string, string]{
+			ID: "root",
+			Children: []*Node[string, string, string]{
+				{ID: "skipped", Skip: "not ready"},
+				{ID: "todo", Todo: true},
+				{ID: "focused", Focus: true},
+				{ID: "normal"},
+			},
+		},
+	}
+	tree.init()
+
+	mermaid := tree.ToMermaid()
+	expected := []string{
+		"classDef skip fill:#eeeeee,stroke:#999999,stroke-dasharray:5 5,color:#999999;",
+		"class skipped skip;",
+		"class todo todo;",
+		"class focused focus;",
+	}
+	for _, s := range expected {
+		if !strings.Contains(mermaid, s) {
+			t.Errorf("Mermaid diagram should contain '%s', got: %s", s, mermaid)
+		}
+	}
+	if strings.Contains(mermaid, "normal skip") || strings.Contains(mermaid, "class normal") {
+		t.Errorf("normal node should not be marked: %s", mermaid)
+	}
+}
+
+func TestToMermaidEdgeCondition(t *testing.T) {
+	tree := &Tree[string, string, string]{
+		Root: &Node[string, string, string]{
+			ID: "root",
+			Children: []*Node[string, string, string]{
+				{ID: "matched", Condition: `user_state="3"`},
+				{ID: "other"},
+			},
+		},
+	}
+	tree.init()
+
+	mermaid := tree.ToMermaid()
+	if !strings.Contains(mermaid, `root -- "user_state=#quot;3#quot;" --> matched`) {
+		t.Errorf("Mermaid diagram should label the edge with the condition, got: %s", mermaid)
+	}
+	if !strings.Contains(mermaid, "root --> other") {
+		t.Errorf("Mermaid diagram should keep plain edges without condition, got: %s", mermaid)
+	}
+}
+
+func TestToMermaidEscaping(t *testing.T) {
+	tree := &Tree[string, string, string]{
+		Root: &Node[string, string, string]{
+			ID: "root",
+			Children: []*Node[string, string, string]{
+				{ID: `say "hi"`},
+				{ID: "a-b.c"},
+				{Description: "anonymous"},
+			},
+		},
+	}
+	tree.init()
+
+	mermaid := tree.ToMermaid()
+	expected := []string{
+		// quotes in labels are written as #quot;
+		`say__hi_["say #quot;hi#quot;"];`,
+		// ids keep only letters, digits and _
+		`a_b_c["a-b.c"];`,
+		// nodes without id are numbered
+		`node_1["anonymous"];`,
+		"root --> node_1;",
+	}
+	for _, s := range expected {
+		if !strings.Contains(mermaid, s) {
+			t.Errorf("Mermaid diagram should contain '%s', got: %s", s, mermaid)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/xhd2015/data-driven-testing/testing_ctx"
@@ -54,6 +55,21 @@ func WithOptions(opts Options) *IntegrationContext {
 	return t
 }
 
+// Name returns the full name of the running test,
+// sub test names are joined by "/"
+func (t *IntegrationContext) Name() string {
+	var names []string
+	for p := t; p != nil; p = p.parent {
+		if p.name != "" {
+			names = append(names, p.name)
+		}
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, "/")
+}
+
 func (t *IntegrationContext) getPrefix() string {
 	if t.parent == nil {
 		return ""
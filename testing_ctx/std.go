@@ -0,0 +1,30 @@
+package testing_ctx
+
+import "testing"
+
+// Std adapts *testing.T to T
+func Std(t *testing.T) T {
+	return stdT{T: t}
+}
+
+type stdT struct {
+	*testing.T
+}
+
+var _ T = stdT{}
+
+func (c stdT) Run(name string, f func(t T)) {
+	c.T.Run(name, func(t *testing.T) {
+		f(stdT{T: t})
+	})
+}
+
+func (c stdT) Status() Status {
+	if c.T.Failed() {
+		return StatusFail
+	}
+	if c.T.Skipped() {
+		return StatusSkip
+	}
+	return StatusRunning
+}